@@ -72,6 +72,11 @@ You can find more information in https://goployer.dev`,
 	rootCmd.AddCommand(NewStatusCommand())
 	rootCmd.AddCommand(NewAddCommand())
 	rootCmd.AddCommand(NewUpdateCommand())
+	rootCmd.AddCommand(NewRollbackCommand())
+	rootCmd.AddCommand(NewPromoteCommand())
+	rootCmd.AddCommand(NewAbortCommand())
+	rootCmd.AddCommand(NewResumeCommand())
+	rootCmd.AddCommand(NewLockCommand())
 
 	rootCmd.PersistentFlags().StringVarP(&v, "log-level", "v", constants.DefaultLogLevel.String(), "Log level (debug, info, warn, error, fatal, panic)")
 
@@ -48,14 +48,21 @@ const (
 
 var zeroTimeout = 0 * time.Minute
 var zeroPollingInterval = 0 * time.Second
+var zeroLockLease = 0 * time.Minute
 
 var flagKey = map[string]string{
-	"deploy": "fullSet",
-	"delete": "fullSet",
-	"init":   "initSet",
-	"status": "statusSet",
-	"update": "updateSet",
-	"add":    "addSet",
+	"deploy":   "fullSet",
+	"delete":   "fullSet",
+	"init":     "initSet",
+	"status":   "statusSet",
+	"update":   "updateSet",
+	"add":      "addSet",
+	"rollback": "rollbackSet",
+	"promote":  "promoteSet",
+	"abort":    "promoteSet",
+	"resume":   "promoteSet",
+	"list":     "lockSet",
+	"release":  "lockReleaseSet",
 }
 
 var CommonFlagRegistry = []Flag{
@@ -206,6 +213,178 @@ var FlagRegistry = map[string][]Flag{
 			DefValue:      false,
 			FlagAddMethod: "BoolVar",
 		},
+		{
+			Name:          "rollback-on-failure",
+			Usage:         "Delete the new version and keep previous versions if health checking fails",
+			Value:         aws.Bool(false),
+			DefValue:      false,
+			FlagAddMethod: "BoolVar",
+		},
+		{
+			Name:          "manual-promotion",
+			Usage:         "Wait for manual promotion after health checking before cleaning previous versions",
+			Value:         aws.Bool(false),
+			DefValue:      false,
+			FlagAddMethod: "BoolVar",
+		},
+		{
+			Name:          "plan",
+			Usage:         "Print changes of deployment without creating, updating or deleting any resources",
+			Value:         aws.Bool(false),
+			DefValue:      false,
+			FlagAddMethod: "BoolVar",
+		},
+		{
+			Name:          "output",
+			Shorthand:     "o",
+			Usage:         "Output format of plan. One of: table, json",
+			Value:         aws.String(constants.DefaultPlanOutput),
+			DefValue:      constants.DefaultPlanOutput,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "state-store",
+			Usage:         "Location of deployment states. One of: local, s3://<bucket>/<prefix>, dynamodb, dynamodb://<table>",
+			Value:         aws.String(constants.LocalStateStore),
+			DefValue:      constants.LocalStateStore,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "state-store-region",
+			Usage:         "Region of state store. Region of metrics is used for dynamodb if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock",
+			Usage:         "Location of deployment lock. One of: dynamodb, dynamodb://<table>. Deployment lock is disabled if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock-region",
+			Usage:         "Region of deployment lock. Region of metrics is used if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock-lease",
+			Usage:         "Lease of deployment lock which is renewed while goployer is running (default 5m)",
+			Value:         &zeroLockLease,
+			DefValue:      constants.DefaultLockLease,
+			FlagAddMethod: "DurationVar",
+		},
+		{
+			Name:          "api-test-report-dir",
+			Usage:         "Directory to write JSON, JUnit XML, raw results and HTML plot of API test",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+	},
+	"rollbackSet": {
+		{
+			Name:          "manifest",
+			Shorthand:     "m",
+			Usage:         "The manifest configuration file to use. (required)",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "manifest-s3-region",
+			Usage:         "Region of bucket containing the manifest configuration file to use. (required if –manifest starts with s3://)",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "stack",
+			Usage:         "stack that should be rolled back.(required)",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "to",
+			Usage:         "Version to roll back to like v012. The last healthy version before the current one is used if not specified",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "region",
+			Usage:         "The region to roll back.",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "fast",
+			Usage:         "Scale a standby autoscaling group back up instead of redeploying the recorded version",
+			Value:         aws.Bool(false),
+			DefValue:      false,
+			FlagAddMethod: "BoolVar",
+		},
+		{
+			Name:          "assume-role",
+			Usage:         "The Role ARN to assume into.",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "timeout",
+			Usage:         "Time to wait for deploy to finish before timing out (default 60m)",
+			Value:         &zeroTimeout,
+			DefValue:      timeout,
+			FlagAddMethod: "DurationVar",
+		},
+		{
+			Name:          "polling-interval",
+			Usage:         "Time to interval for polling health check (default 60s)",
+			Value:         &zeroPollingInterval,
+			DefValue:      pollingInterval,
+			FlagAddMethod: "DurationVar",
+		},
+		{
+			Name:          "slack-off",
+			Usage:         "Turn off slack alarm",
+			Value:         aws.Bool(false),
+			DefValue:      false,
+			FlagAddMethod: "BoolVar",
+		},
+		{
+			Name:          "auto-apply",
+			Usage:         "Apply command without confirmation from local terminal",
+			Value:         aws.Bool(false),
+			DefValue:      false,
+			FlagAddMethod: "BoolVar",
+		},
+		{
+			Name:          "lock",
+			Usage:         "Location of deployment lock. One of: dynamodb, dynamodb://<table>. Deployment lock is disabled if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock-region",
+			Usage:         "Region of deployment lock. Region of metrics is used if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock-lease",
+			Usage:         "Lease of deployment lock which is renewed while goployer is running (default 5m)",
+			Value:         &zeroLockLease,
+			DefValue:      constants.DefaultLockLease,
+			FlagAddMethod: "DurationVar",
+		},
 	},
 	"initSet": {
 		{
@@ -287,6 +466,96 @@ var FlagRegistry = map[string][]Flag{
 			FlagAddMethod: "DurationVar",
 		},
 	},
+	"promoteSet": {
+		{
+			Name:          "auto-apply",
+			Usage:         "Apply command without confirmation from local terminal",
+			Value:         aws.Bool(false),
+			DefValue:      false,
+			FlagAddMethod: "BoolVar",
+		},
+		{
+			Name:          "state-store",
+			Usage:         "Location of deployment states. One of: local, s3://<bucket>/<prefix>, dynamodb, dynamodb://<table>",
+			Value:         aws.String(constants.LocalStateStore),
+			DefValue:      constants.LocalStateStore,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "state-store-region",
+			Usage:         "Region of state store. Region of metrics is used for dynamodb if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock",
+			Usage:         "Location of deployment lock. One of: dynamodb, dynamodb://<table>. Deployment lock is disabled if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock-region",
+			Usage:         "Region of deployment lock. Region of metrics is used if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock-lease",
+			Usage:         "Lease of deployment lock which is renewed while goployer is running (default 5m)",
+			Value:         &zeroLockLease,
+			DefValue:      constants.DefaultLockLease,
+			FlagAddMethod: "DurationVar",
+		},
+	},
+	"lockSet": {
+		{
+			Name:          "lock",
+			Usage:         "Location of deployment lock. One of: dynamodb, dynamodb://<table>. Metric table is used for dynamodb",
+			Value:         aws.String(constants.DynamoDBStateStore),
+			DefValue:      constants.DynamoDBStateStore,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock-region",
+			Usage:         "Region of deployment lock. Region of metrics is used if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+	},
+	"lockReleaseSet": {
+		{
+			Name:          "lock",
+			Usage:         "Location of deployment lock. One of: dynamodb, dynamodb://<table>. Metric table is used for dynamodb",
+			Value:         aws.String(constants.DynamoDBStateStore),
+			DefValue:      constants.DynamoDBStateStore,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "lock-region",
+			Usage:         "Region of deployment lock. Region of metrics is used if undefined",
+			Value:         aws.String(constants.EmptyString),
+			DefValue:      constants.EmptyString,
+			FlagAddMethod: "StringVar",
+		},
+		{
+			Name:          "force",
+			Usage:         "Release deployment lock regardless of the owner",
+			Value:         aws.Bool(false),
+			DefValue:      false,
+			FlagAddMethod: "BoolVar",
+		},
+		{
+			Name:          "auto-apply",
+			Usage:         "Apply command without confirmation from local terminal",
+			Value:         aws.Bool(false),
+			DefValue:      false,
+			FlagAddMethod: "BoolVar",
+		},
+	},
 }
 
 func (fl *Flag) flag() *pflag.Flag {
@@ -0,0 +1,101 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/DevopsArtFactory/goployer/pkg/runner"
+)
+
+// Create new lock command
+func NewLockCommand() *cobra.Command {
+	lockCmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Manage deployment locks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	lockCmd.AddCommand(NewLockListCommand())
+	lockCmd.AddCommand(NewLockReleaseCommand())
+
+	return lockCmd
+}
+
+// Create new lock list command
+func NewLockListCommand() *cobra.Command {
+	return NewCmd("list").
+		WithDescription("List deployment locks").
+		SetFlags().
+		RunWithNoArgs(funcLockList)
+}
+
+// Create new lock release command
+func NewLockReleaseCommand() *cobra.Command {
+	return NewCmd("release").
+		WithDescription("Release a deployment lock which is stuck").
+		SetFlags().
+		RunWithArgs(funcLockRelease)
+}
+
+// funcLockList shows deployment locks
+func funcLockList(ctx context.Context, _ io.Writer, mode string) error {
+	return runWithoutExecutor(ctx, func() error {
+		//Create new builder
+		builderSt, err := runner.SetupBuilder(mode)
+		if err != nil {
+			return err
+		}
+
+		//Start runner
+		if err := runner.Start(ctx, builderSt, "lock-list"); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// funcLockRelease deletes deployment lock regardless of the owner
+func funcLockRelease(ctx context.Context, _ io.Writer, args []string, mode string) error {
+	if len(args) != 1 {
+		return errors.New("usage: goployer lock release <lock id> --force")
+	}
+
+	return runWithoutExecutor(ctx, func() error {
+		//Create new builder
+		builderSt, err := runner.SetupBuilder(mode)
+		if err != nil {
+			return err
+		}
+
+		builderSt.Config.LockID = args[0]
+
+		//Start runner
+		if err := runner.Start(ctx, builderSt, "lock-release"); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
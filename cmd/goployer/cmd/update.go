@@ -51,7 +51,7 @@ func funcUpdate(ctx context.Context, _ io.Writer, args []string, mode string) er
 		builderSt.Config.LogLevel = "debug"
 
 		//Start runner
-		if err := runner.Start(builderSt, mode); err != nil {
+		if err := runner.Start(ctx, builderSt, mode); err != nil {
 			return err
 		}
 
@@ -45,6 +45,12 @@ type ManifestClient struct {
 	S3Service S3Client
 }
 
+type StateClient struct {
+	Region          string
+	S3Service       S3Client
+	DynamoDBService DynamoDBClient
+}
+
 // GetAwsSession generates new aws session
 func GetAwsSession() *session.Session {
 	profile := viper.GetString("profile")
@@ -121,3 +127,22 @@ func BootstrapManifestService(region string, assumeRole string) ManifestClient {
 
 	return client
 }
+
+// BootstrapStateService creates AWS clients for deployment state store
+func BootstrapStateService(region string, assumeRole string) StateClient {
+	awsSession := GetAwsSession()
+
+	var creds *credentials.Credentials
+	if len(assumeRole) != 0 {
+		creds = stscreds.NewCredentials(awsSession, assumeRole)
+	}
+
+	//Get all clients
+	client := StateClient{
+		Region:          region,
+		S3Service:       NewS3Client(awsSession, region, creds),
+		DynamoDBService: NewDynamoDBClient(awsSession, region, creds),
+	}
+
+	return client
+}
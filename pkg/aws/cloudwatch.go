@@ -17,6 +17,7 @@ limitations under the license.
 package aws
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -52,7 +53,7 @@ func getCloudWatchClientFn(session client.ConfigProvider, region string, creds *
 }
 
 // CreateScalingAlarms creates scaling alarms
-func (c CloudWatchClient) CreateScalingAlarms(asgName string, alarms []schemas.AlarmConfigs, policyArns map[string]string) error {
+func (c CloudWatchClient) CreateScalingAlarms(ctx context.Context, asgName string, alarms []schemas.AlarmConfigs, policyArns map[string]string) error {
 	if len(alarms) == 0 {
 		return nil
 	}
@@ -64,7 +65,7 @@ func (c CloudWatchClient) CreateScalingAlarms(asgName string, alarms []schemas.A
 			arns = append(arns, policyArns[action])
 		}
 		alarm.AlarmActions = arns
-		if err := c.CreateCloudWatchAlarm(asgName, alarm); err != nil {
+		if err := c.CreateCloudWatchAlarm(ctx, asgName, alarm); err != nil {
 			return err
 		}
 	}
@@ -73,7 +74,7 @@ func (c CloudWatchClient) CreateScalingAlarms(asgName string, alarms []schemas.A
 }
 
 // CreateCloudWatchAlarm creates cloudwatch alarms for autoscaling group
-func (c CloudWatchClient) CreateCloudWatchAlarm(asgName string, alarm schemas.AlarmConfigs) error {
+func (c CloudWatchClient) CreateCloudWatchAlarm(ctx context.Context, asgName string, alarm schemas.AlarmConfigs) error {
 	input := &cloudwatch.PutMetricAlarmInput{
 		AlarmName:          aws.String(createAlarmName(asgName, alarm.Name)),
 		AlarmActions:       aws.StringSlice(alarm.AlarmActions),
@@ -92,7 +93,7 @@ func (c CloudWatchClient) CreateCloudWatchAlarm(asgName string, alarm schemas.Al
 		},
 	}
 
-	_, err := c.Client.PutMetricAlarm(input)
+	_, err := c.Client.PutMetricAlarmWithContext(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -344,6 +345,74 @@ func (c CloudWatchClient) GetOneDayStatisticsOfLoadBalancer(lb string, startTime
 	return ret, sum, nil
 }
 
+// GetAlarmStates returns state of each alarm
+func (c CloudWatchClient) GetAlarmStates(ctx context.Context, names []string) (map[string]string, error) {
+	input := &cloudwatch.DescribeAlarmsInput{
+		AlarmNames: aws.StringSlice(names),
+		AlarmTypes: aws.StringSlice([]string{cloudwatch.AlarmTypeMetricAlarm, cloudwatch.AlarmTypeCompositeAlarm}),
+	}
+
+	ret := map[string]string{}
+	err := c.Client.DescribeAlarmsPagesWithContext(ctx, input, func(page *cloudwatch.DescribeAlarmsOutput, lastPage bool) bool {
+		for _, alarm := range page.MetricAlarms {
+			ret[*alarm.AlarmName] = *alarm.StateValue
+		}
+		for _, alarm := range page.CompositeAlarms {
+			ret[*alarm.AlarmName] = *alarm.StateValue
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// GetLatestMetricValue returns the latest value of metric within the last few periods
+// Nil is returned if no data point exists
+func (c CloudWatchClient) GetLatestMetricValue(ctx context.Context, metric schemas.BakeMetric, dimensions map[string]string, period int64) (*float64, error) {
+	var dims []*cloudwatch.Dimension
+	for name, value := range dimensions {
+		dims = append(dims, &cloudwatch.Dimension{
+			Name:  aws.String(name),
+			Value: aws.String(value),
+		})
+	}
+
+	endTime := time.Now()
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(endTime.Add(-3 * time.Duration(period) * time.Second)),
+		EndTime:   aws.Time(endTime),
+		ScanBy:    aws.String(cloudwatch.ScanByTimestampDescending),
+		MetricDataQueries: []*cloudwatch.MetricDataQuery{
+			{
+				Id: aws.String("bake"),
+				MetricStat: &cloudwatch.MetricStat{
+					Metric: &cloudwatch.Metric{
+						Dimensions: dims,
+						MetricName: aws.String(metric.Metric),
+						Namespace:  aws.String(metric.Namespace),
+					},
+					Period: aws.Int64(period),
+					Stat:   aws.String(metric.Statistic),
+				},
+			},
+		},
+	}
+
+	result, err := c.Client.GetMetricDataWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.MetricDataResults) == 0 || len(result.MetricDataResults[0].Values) == 0 {
+		return nil, nil
+	}
+
+	return result.MetricDataResults[0].Values[0], nil
+}
+
 // CheckMetricTimeValidation validates metric time
 func CheckMetricTimeValidation(startTime time.Time, endTime time.Time) bool {
 	return endTime.Sub(startTime) > 0
@@ -321,3 +321,216 @@ func (d DynamoDBClient) UpdateStatistics(asg string, tableName, timezone string,
 
 	return nil
 }
+
+// UpdateAttribute sets string attribute of the item
+func (d DynamoDBClient) UpdateAttribute(identifier, key, value, tableName string) error {
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]*string{
+			"#K": aws.String(key),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":v": {
+				S: aws.String(value),
+			},
+		},
+		Key: map[string]*dynamodb.AttributeValue{
+			constants.HashKey: {
+				S: aws.String(identifier),
+			},
+		},
+		TableName:        aws.String(tableName),
+		UpdateExpression: aws.String("SET #K = :v"),
+	}
+
+	if _, err := d.Client.UpdateItem(input); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PutDeploymentState saves the state of deployment as a single item
+func (d DynamoDBClient) PutDeploymentState(identifier, status, deploymentState, tableName string) error {
+	input := &dynamodb.PutItemInput{
+		Item: map[string]*dynamodb.AttributeValue{
+			constants.HashKey: {
+				S: aws.String(identifier),
+			},
+			"deployment_status": {
+				S: aws.String(status),
+			},
+			"deployment_state": {
+				S: aws.String(deploymentState),
+			},
+		},
+		TableName: aws.String(tableName),
+	}
+
+	if _, err := d.Client.PutItem(input); err != nil {
+		return err
+	}
+
+	Logger.Debugf("deployment state is saved : %s", identifier)
+
+	return nil
+}
+
+// DeleteItem deletes single item from the table
+func (d DynamoDBClient) DeleteItem(identifier, tableName string) error {
+	input := &dynamodb.DeleteItemInput{
+		Key: map[string]*dynamodb.AttributeValue{
+			constants.HashKey: {
+				S: aws.String(identifier),
+			},
+		},
+		TableName: aws.String(tableName),
+	}
+
+	if _, err := d.Client.DeleteItem(input); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PutLock takes the deployment lock if no one holds it or the lease of it is expired
+// It returns false if the lock is held by another owner
+func (d DynamoDBClient) PutLock(identifier, owner, deploymentID string, now, expiresAt int64, tableName string) (bool, error) {
+	input := &dynamodb.PutItemInput{
+		Item: map[string]*dynamodb.AttributeValue{
+			constants.HashKey: {
+				S: aws.String(identifier),
+			},
+			"lock_owner": {
+				S: aws.String(owner),
+			},
+			"deployment_id": {
+				S: aws.String(deploymentID),
+			},
+			"acquired_at": {
+				N: aws.String(fmt.Sprintf("%d", now)),
+			},
+			"expires_at": {
+				N: aws.String(fmt.Sprintf("%d", expiresAt)),
+			},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(#K) OR #E < :now OR #O = :owner"),
+		ExpressionAttributeNames: map[string]*string{
+			"#K": aws.String(constants.HashKey),
+			"#E": aws.String("expires_at"),
+			"#O": aws.String("lock_owner"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {
+				N: aws.String(fmt.Sprintf("%d", now)),
+			},
+			":owner": {
+				S: aws.String(owner),
+			},
+		},
+		TableName: aws.String(tableName),
+	}
+
+	if _, err := d.Client.PutItem(input); err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RenewLock extends the lease of the deployment lock held by the owner
+// It returns false if the lock is taken by another owner
+func (d DynamoDBClient) RenewLock(identifier, owner string, expiresAt int64, tableName string) (bool, error) {
+	input := &dynamodb.UpdateItemInput{
+		ConditionExpression: aws.String("#O = :owner"),
+		ExpressionAttributeNames: map[string]*string{
+			"#E": aws.String("expires_at"),
+			"#O": aws.String("lock_owner"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":expiresAt": {
+				N: aws.String(fmt.Sprintf("%d", expiresAt)),
+			},
+			":owner": {
+				S: aws.String(owner),
+			},
+		},
+		Key: map[string]*dynamodb.AttributeValue{
+			constants.HashKey: {
+				S: aws.String(identifier),
+			},
+		},
+		TableName:        aws.String(tableName),
+		UpdateExpression: aws.String("SET #E = :expiresAt"),
+	}
+
+	if _, err := d.Client.UpdateItem(input); err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DeleteLock releases the deployment lock only if it is held by the owner
+func (d DynamoDBClient) DeleteLock(identifier, owner, tableName string) error {
+	input := &dynamodb.DeleteItemInput{
+		ConditionExpression: aws.String("#O = :owner"),
+		ExpressionAttributeNames: map[string]*string{
+			"#O": aws.String("lock_owner"),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":owner": {
+				S: aws.String(owner),
+			},
+		},
+		Key: map[string]*dynamodb.AttributeValue{
+			constants.HashKey: {
+				S: aws.String(identifier),
+			},
+		},
+		TableName: aws.String(tableName),
+	}
+
+	if _, err := d.Client.DeleteItem(input); err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			Logger.Warnf("deployment lock is already taken by another owner : %s", identifier)
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ScanItemsWithPrefix retrieves all items of which hash key starts with the prefix
+func (d DynamoDBClient) ScanItemsWithPrefix(prefix, tableName string) ([]map[string]*dynamodb.AttributeValue, error) {
+	input := &dynamodb.ScanInput{
+		ExpressionAttributeNames: map[string]*string{
+			"#K": aws.String(constants.HashKey),
+		},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":prefix": {
+				S: aws.String(prefix),
+			},
+		},
+		FilterExpression: aws.String("begins_with(#K, :prefix)"),
+		TableName:        aws.String(tableName),
+	}
+
+	var items []map[string]*dynamodb.AttributeValue
+	err := d.Client.ScanPages(input, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		items = append(items, page.Items...)
+		return !lastPage
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
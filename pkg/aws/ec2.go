@@ -17,6 +17,7 @@ limitations under the license.
 package aws
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -61,8 +62,8 @@ func getAsgClientFn(session client.ConfigProvider, region string, creds *credent
 	return autoscaling.New(session, &aws.Config{Region: aws.String(region), Credentials: creds})
 }
 
-func (e EC2Client) GetMatchingAutoscalingGroup(name string) (*autoscaling.Group, error) {
-	asgGroup, err := getSingleAutoScalingGroup(e.AsClient, name)
+func (e EC2Client) GetMatchingAutoscalingGroup(ctx context.Context, name string) (*autoscaling.Group, error) {
+	asgGroup, err := getSingleAutoScalingGroup(ctx, e.AsClient, name)
 	if err != nil {
 		return nil, err
 	}
@@ -71,12 +72,12 @@ func (e EC2Client) GetMatchingAutoscalingGroup(name string) (*autoscaling.Group,
 }
 
 // GetMatchingLaunchTemplate returns information of launch template with matched ID
-func (e EC2Client) GetMatchingLaunchTemplate(ltID string) (*ec2.LaunchTemplateVersion, error) {
+func (e EC2Client) GetMatchingLaunchTemplate(ctx context.Context, ltID string) (*ec2.LaunchTemplateVersion, error) {
 	input := &ec2.DescribeLaunchTemplateVersionsInput{
 		LaunchTemplateId: aws.String(ltID),
 	}
 
-	ret, err := e.Client.DescribeLaunchTemplateVersions(input)
+	ret, err := e.Client.DescribeLaunchTemplateVersionsWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -85,12 +86,12 @@ func (e EC2Client) GetMatchingLaunchTemplate(ltID string) (*ec2.LaunchTemplateVe
 }
 
 // GetSecurityGroupDetails returns detailed information for security group
-func (e EC2Client) GetSecurityGroupDetails(sgIds []*string) ([]*ec2.SecurityGroup, error) {
+func (e EC2Client) GetSecurityGroupDetails(ctx context.Context, sgIds []*string) ([]*ec2.SecurityGroup, error) {
 	input := &ec2.DescribeSecurityGroupsInput{
 		GroupIds: sgIds,
 	}
 
-	result, err := e.Client.DescribeSecurityGroups(input)
+	result, err := e.Client.DescribeSecurityGroupsWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -99,12 +100,12 @@ func (e EC2Client) GetSecurityGroupDetails(sgIds []*string) ([]*ec2.SecurityGrou
 }
 
 // Delete All Launch Configurations belongs to the autoscaling group
-func (e EC2Client) DeleteLaunchConfigurations(asgName string) error {
-	lcs := getAllLaunchConfigurations(e.AsClient, []*autoscaling.LaunchConfiguration{}, nil)
+func (e EC2Client) DeleteLaunchConfigurations(ctx context.Context, asgName string) error {
+	lcs := getAllLaunchConfigurations(ctx, e.AsClient, []*autoscaling.LaunchConfiguration{}, nil)
 
 	for _, lc := range lcs {
 		if strings.HasPrefix(*lc.LaunchConfigurationName, asgName) {
-			err := deleteLaunchConfiguration(e.AsClient, *lc.LaunchConfigurationName)
+			err := deleteLaunchConfiguration(ctx, e.AsClient, *lc.LaunchConfigurationName)
 			if err != nil {
 				return err
 			}
@@ -115,12 +116,12 @@ func (e EC2Client) DeleteLaunchConfigurations(asgName string) error {
 }
 
 // Delete all launch template belongs to the autoscaling group
-func (e EC2Client) DeleteLaunchTemplates(asgName string) error {
-	lts := getAllLaunchTemplates(e.Client, []*ec2.LaunchTemplate{}, nil)
+func (e EC2Client) DeleteLaunchTemplates(ctx context.Context, asgName string) error {
+	lts := getAllLaunchTemplates(ctx, e.Client, []*ec2.LaunchTemplate{}, nil)
 
 	for _, lt := range lts {
 		if strings.HasPrefix(*lt.LaunchTemplateName, asgName) {
-			err := deleteLaunchTemplate(e.Client, *lt.LaunchTemplateName)
+			err := deleteLaunchTemplate(ctx, e.Client, *lt.LaunchTemplateName)
 			if err != nil {
 				return err
 			}
@@ -133,12 +134,27 @@ func (e EC2Client) DeleteLaunchTemplates(asgName string) error {
 // Delete Autoscaling group Set
 // 1. Autoscaling Group
 // 2. Luanch Configurations in asg
-func (e EC2Client) DeleteAutoscalingSet(asgName string) error {
+func (e EC2Client) DeleteAutoscalingSet(ctx context.Context, asgName string) error {
 	input := &autoscaling.DeleteAutoScalingGroupInput{
 		AutoScalingGroupName: aws.String(asgName),
 	}
 
-	_, err := e.AsClient.DeleteAutoScalingGroup(input)
+	_, err := e.AsClient.DeleteAutoScalingGroupWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ForceDeleteAutoscalingGroup deletes autoscaling group with instances in it
+func (e EC2Client) ForceDeleteAutoscalingGroup(ctx context.Context, asgName string) error {
+	input := &autoscaling.DeleteAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(asgName),
+		ForceDelete:          aws.Bool(true),
+	}
+
+	_, err := e.AsClient.DeleteAutoScalingGroupWithContext(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -148,9 +164,9 @@ func (e EC2Client) DeleteAutoscalingSet(asgName string) error {
 
 // Get All matching autoscaling groups with aws prefix
 // By this function, you could get the latest version of deployment
-func (e EC2Client) GetAllMatchingAutoscalingGroupsWithPrefix(prefix string) []*autoscaling.Group {
+func (e EC2Client) GetAllMatchingAutoscalingGroupsWithPrefix(ctx context.Context, prefix string) []*autoscaling.Group {
 	asgGroups := []*autoscaling.Group{}
-	asgGroups = getAutoScalingGroups(e.AsClient, asgGroups, nil)
+	asgGroups = getAutoScalingGroups(ctx, e.AsClient, asgGroups, nil)
 
 	ret := []*autoscaling.Group{}
 	for _, asgGroup := range asgGroups {
@@ -164,31 +180,35 @@ func (e EC2Client) GetAllMatchingAutoscalingGroupsWithPrefix(prefix string) []*a
 
 // Batch of retrieving list of autoscaling group
 // By Token, if needed, you could get all autoscaling groups with paging.
-func getAutoScalingGroups(client *autoscaling.AutoScaling, asgGroup []*(autoscaling.Group), nextToken *string) []*autoscaling.Group {
+func getAutoScalingGroups(ctx context.Context, client *autoscaling.AutoScaling, asgGroup []*(autoscaling.Group), nextToken *string) []*autoscaling.Group {
 	input := &autoscaling.DescribeAutoScalingGroupsInput{
 		NextToken: nextToken,
 	}
-	ret, err := client.DescribeAutoScalingGroups(input)
+	ret, err := client.DescribeAutoScalingGroupsWithContext(ctx, input)
 	if err != nil {
+		// cancelled request should not stop cleaning up resources
+		if ctx.Err() != nil {
+			return asgGroup
+		}
 		tool.FatalError(err)
 	}
 
 	asgGroup = append(asgGroup, ret.AutoScalingGroups...)
 
 	if ret.NextToken != nil {
-		return getAutoScalingGroups(client, asgGroup, ret.NextToken)
+		return getAutoScalingGroups(ctx, client, asgGroup, ret.NextToken)
 	}
 
 	return asgGroup
 }
 
 // Batch of retrieving all launch configurations
-func getAllLaunchConfigurations(client *autoscaling.AutoScaling, lcs []*autoscaling.LaunchConfiguration, nextToken *string) []*autoscaling.LaunchConfiguration {
+func getAllLaunchConfigurations(ctx context.Context, client *autoscaling.AutoScaling, lcs []*autoscaling.LaunchConfiguration, nextToken *string) []*autoscaling.LaunchConfiguration {
 	input := &autoscaling.DescribeLaunchConfigurationsInput{
 		NextToken: nextToken,
 	}
 
-	ret, err := client.DescribeLaunchConfigurations(input)
+	ret, err := client.DescribeLaunchConfigurationsWithContext(ctx, input)
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			switch aerr.Code() {
@@ -210,19 +230,19 @@ func getAllLaunchConfigurations(client *autoscaling.AutoScaling, lcs []*autoscal
 	lcs = append(lcs, ret.LaunchConfigurations...)
 
 	if ret.NextToken != nil {
-		return getAllLaunchConfigurations(client, lcs, ret.NextToken)
+		return getAllLaunchConfigurations(ctx, client, lcs, ret.NextToken)
 	}
 
 	return lcs
 }
 
 // Batch of retrieving all launch templates
-func getAllLaunchTemplates(client *ec2.EC2, lts []*ec2.LaunchTemplate, nextToken *string) []*ec2.LaunchTemplate {
+func getAllLaunchTemplates(ctx context.Context, client *ec2.EC2, lts []*ec2.LaunchTemplate, nextToken *string) []*ec2.LaunchTemplate {
 	input := &ec2.DescribeLaunchTemplatesInput{
 		NextToken: nextToken,
 	}
 
-	ret, err := client.DescribeLaunchTemplates(input)
+	ret, err := client.DescribeLaunchTemplatesWithContext(ctx, input)
 	if err != nil {
 		return nil
 	}
@@ -230,19 +250,19 @@ func getAllLaunchTemplates(client *ec2.EC2, lts []*ec2.LaunchTemplate, nextToken
 	lts = append(lts, ret.LaunchTemplates...)
 
 	if ret.NextToken != nil {
-		return getAllLaunchTemplates(client, lts, ret.NextToken)
+		return getAllLaunchTemplates(ctx, client, lts, ret.NextToken)
 	}
 
 	return lts
 }
 
 // Delete Single Launch Configuration
-func deleteLaunchConfiguration(client *autoscaling.AutoScaling, lcName string) error {
+func deleteLaunchConfiguration(ctx context.Context, client *autoscaling.AutoScaling, lcName string) error {
 	input := &autoscaling.DeleteLaunchConfigurationInput{
 		LaunchConfigurationName: aws.String(lcName),
 	}
 
-	_, err := client.DeleteLaunchConfiguration(input)
+	_, err := client.DeleteLaunchConfigurationWithContext(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -251,12 +271,12 @@ func deleteLaunchConfiguration(client *autoscaling.AutoScaling, lcName string) e
 }
 
 // Delete Single Launch Template
-func deleteLaunchTemplate(client *ec2.EC2, ltName string) error {
+func deleteLaunchTemplate(ctx context.Context, client *ec2.EC2, ltName string) error {
 	input := &ec2.DeleteLaunchTemplateInput{
 		LaunchTemplateName: aws.String(ltName),
 	}
 
-	_, err := client.DeleteLaunchTemplate(input)
+	_, err := client.DeleteLaunchTemplateWithContext(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -265,7 +285,7 @@ func deleteLaunchTemplate(client *ec2.EC2, ltName string) error {
 }
 
 // Create New Launch Configuration
-func (e EC2Client) CreateNewLaunchConfiguration(name, ami, instanceType, keyName, iamProfileName, userdata string, ebsOptimized bool, securityGroups []*string, blockDevices []*autoscaling.BlockDeviceMapping) bool {
+func (e EC2Client) CreateNewLaunchConfiguration(ctx context.Context, name, ami, instanceType, keyName, iamProfileName, userdata string, ebsOptimized bool, securityGroups []*string, blockDevices []*autoscaling.BlockDeviceMapping) bool {
 	input := &autoscaling.CreateLaunchConfigurationInput{
 		LaunchConfigurationName: aws.String(name),
 		ImageId:                 aws.String(ami),
@@ -278,7 +298,7 @@ func (e EC2Client) CreateNewLaunchConfiguration(name, ami, instanceType, keyName
 		BlockDeviceMappings:     blockDevices,
 	}
 
-	_, err := e.AsClient.CreateLaunchConfiguration(input)
+	_, err := e.AsClient.CreateLaunchConfigurationWithContext(ctx, input)
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			switch aerr.Code() {
@@ -305,67 +325,129 @@ func (e EC2Client) CreateNewLaunchConfiguration(name, ami, instanceType, keyName
 }
 
 // Create New Launch Template
-func (e EC2Client) CreateNewLaunchTemplate(name, ami, instanceType, keyName, iamProfileName, userdata string, ebsOptimized, mixedInstancePolicyEnabled bool, securityGroups []*string, blockDevices []*ec2.LaunchTemplateBlockDeviceMappingRequest, instanceMarketOptions *schemas.InstanceMarketOptions, detailedMonitoringEnabled bool) error {
+func (e EC2Client) CreateNewLaunchTemplate(ctx context.Context, name, ami, instanceType, keyName, iamProfileName, userdata string, ebsOptimized, mixedInstancePolicyEnabled bool, securityGroups []*string, blockDevices []*ec2.LaunchTemplateBlockDeviceMappingRequest, instanceMarketOptions *schemas.InstanceMarketOptions, detailedMonitoringEnabled bool) error {
 	input := &ec2.CreateLaunchTemplateInput{
-		LaunchTemplateData: &ec2.RequestLaunchTemplateData{
-			ImageId:      aws.String(ami),
-			InstanceType: aws.String(instanceType),
-			KeyName:      aws.String(keyName),
-			IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{
-				Name: aws.String(iamProfileName),
-			},
-			UserData:         aws.String(userdata),
-			SecurityGroupIds: securityGroups,
-			EbsOptimized:     aws.Bool(ebsOptimized),
-			Monitoring:       &ec2.LaunchTemplatesMonitoringRequest{Enabled: aws.Bool(detailedMonitoringEnabled)},
-		},
+		LaunchTemplateData: makeLaunchTemplateData(ami, instanceType, keyName, iamProfileName, userdata, ebsOptimized, mixedInstancePolicyEnabled, securityGroups, blockDevices, instanceMarketOptions, detailedMonitoringEnabled),
+		LaunchTemplateName: aws.String(name),
+	}
+
+	_, err := e.Client.CreateLaunchTemplateWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	Logger.Info("Successfully create new launch template : ", name)
+
+	return nil
+}
+
+// CreateLaunchTemplateVersion creates new version of launch template and makes it default
+func (e EC2Client) CreateLaunchTemplateVersion(ctx context.Context, name, ami, instanceType, keyName, iamProfileName, userdata string, ebsOptimized, mixedInstancePolicyEnabled bool, securityGroups []*string, blockDevices []*ec2.LaunchTemplateBlockDeviceMappingRequest, instanceMarketOptions *schemas.InstanceMarketOptions, detailedMonitoringEnabled bool) (int64, error) {
+	input := &ec2.CreateLaunchTemplateVersionInput{
+		LaunchTemplateData: makeLaunchTemplateData(ami, instanceType, keyName, iamProfileName, userdata, ebsOptimized, mixedInstancePolicyEnabled, securityGroups, blockDevices, instanceMarketOptions, detailedMonitoringEnabled),
+		LaunchTemplateName: aws.String(name),
+	}
+
+	result, err := e.Client.CreateLaunchTemplateVersionWithContext(ctx, input)
+	if err != nil {
+		return 0, err
+	}
+
+	version := *result.LaunchTemplateVersion.VersionNumber
+	if err := e.SetDefaultLaunchTemplateVersion(ctx, name, version); err != nil {
+		return 0, err
+	}
+
+	Logger.Infof("Successfully create new launch template version : %s(%d)", name, version)
+
+	return version, nil
+}
+
+// GetDefaultLaunchTemplateVersion returns default version of launch template
+func (e EC2Client) GetDefaultLaunchTemplateVersion(ctx context.Context, name string) (int64, error) {
+	input := &ec2.DescribeLaunchTemplatesInput{
+		LaunchTemplateNames: aws.StringSlice([]string{name}),
+	}
+
+	result, err := e.Client.DescribeLaunchTemplatesWithContext(ctx, input)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(result.LaunchTemplates) == 0 {
+		return 0, fmt.Errorf("no launch template exists with name: %s", name)
+	}
+
+	return *result.LaunchTemplates[0].DefaultVersionNumber, nil
+}
+
+// SetDefaultLaunchTemplateVersion changes default version of launch template
+func (e EC2Client) SetDefaultLaunchTemplateVersion(ctx context.Context, name string, version int64) error {
+	input := &ec2.ModifyLaunchTemplateInput{
 		LaunchTemplateName: aws.String(name),
+		DefaultVersion:     aws.String(fmt.Sprintf("%d", version)),
+	}
+
+	_, err := e.Client.ModifyLaunchTemplateWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// makeLaunchTemplateData creates launch template data
+func makeLaunchTemplateData(ami, instanceType, keyName, iamProfileName, userdata string, ebsOptimized, mixedInstancePolicyEnabled bool, securityGroups []*string, blockDevices []*ec2.LaunchTemplateBlockDeviceMappingRequest, instanceMarketOptions *schemas.InstanceMarketOptions, detailedMonitoringEnabled bool) *ec2.RequestLaunchTemplateData {
+	data := &ec2.RequestLaunchTemplateData{
+		ImageId:      aws.String(ami),
+		InstanceType: aws.String(instanceType),
+		KeyName:      aws.String(keyName),
+		IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{
+			Name: aws.String(iamProfileName),
+		},
+		UserData:         aws.String(userdata),
+		SecurityGroupIds: securityGroups,
+		EbsOptimized:     aws.Bool(ebsOptimized),
+		Monitoring:       &ec2.LaunchTemplatesMonitoringRequest{Enabled: aws.Bool(detailedMonitoringEnabled)},
 	}
 
 	if len(blockDevices) > 0 {
-		input.LaunchTemplateData.BlockDeviceMappings = blockDevices
+		data.BlockDeviceMappings = blockDevices
 	}
 
 	if instanceMarketOptions != nil && !mixedInstancePolicyEnabled {
-		input.LaunchTemplateData.InstanceMarketOptions = &ec2.LaunchTemplateInstanceMarketOptionsRequest{
+		data.InstanceMarketOptions = &ec2.LaunchTemplateInstanceMarketOptionsRequest{
 			MarketType:  aws.String(instanceMarketOptions.MarketType),
 			SpotOptions: &ec2.LaunchTemplateSpotMarketOptionsRequest{},
 		}
 
 		if instanceMarketOptions.SpotOptions.BlockDurationMinutes > 0 {
-			input.LaunchTemplateData.InstanceMarketOptions.SpotOptions.BlockDurationMinutes = aws.Int64(instanceMarketOptions.SpotOptions.BlockDurationMinutes)
+			data.InstanceMarketOptions.SpotOptions.BlockDurationMinutes = aws.Int64(instanceMarketOptions.SpotOptions.BlockDurationMinutes)
 		}
 
 		if len(instanceMarketOptions.SpotOptions.InstanceInterruptionBehavior) > 0 {
-			input.LaunchTemplateData.InstanceMarketOptions.SpotOptions.InstanceInterruptionBehavior = aws.String(instanceMarketOptions.SpotOptions.InstanceInterruptionBehavior)
+			data.InstanceMarketOptions.SpotOptions.InstanceInterruptionBehavior = aws.String(instanceMarketOptions.SpotOptions.InstanceInterruptionBehavior)
 		}
 
 		if len(instanceMarketOptions.SpotOptions.SpotInstanceType) > 0 {
-			input.LaunchTemplateData.InstanceMarketOptions.SpotOptions.SpotInstanceType = aws.String(instanceMarketOptions.SpotOptions.SpotInstanceType)
+			data.InstanceMarketOptions.SpotOptions.SpotInstanceType = aws.String(instanceMarketOptions.SpotOptions.SpotInstanceType)
 		}
 
 		if len(instanceMarketOptions.SpotOptions.MaxPrice) > 0 {
-			input.LaunchTemplateData.InstanceMarketOptions.SpotOptions.MaxPrice = aws.String(instanceMarketOptions.SpotOptions.MaxPrice)
+			data.InstanceMarketOptions.SpotOptions.MaxPrice = aws.String(instanceMarketOptions.SpotOptions.MaxPrice)
 		}
 	}
 
-	_, err := e.Client.CreateLaunchTemplate(input)
-	if err != nil {
-		return err
-	}
-
-	Logger.Info("Successfully create new launch template : ", name)
-
-	return nil
+	return data
 }
 
 // Get All Security Group Information New Launch Configuration
-func (e EC2Client) GetSecurityGroupList(vpc string, sgList []string) ([]*string, error) {
+func (e EC2Client) GetSecurityGroupList(ctx context.Context, vpc string, sgList []string) ([]*string, error) {
 	if len(sgList) == 0 {
 		return nil, errors.New("need to specify at least one security group")
 	}
 
-	vpcID, err := e.GetVPCId(vpc)
+	vpcID, err := e.GetVPCId(ctx, vpc)
 	if err != nil {
 		return nil, err
 	}
@@ -394,7 +476,7 @@ func (e EC2Client) GetSecurityGroupList(vpc string, sgList []string) ([]*string,
 			},
 		}
 
-		result, err := e.Client.DescribeSecurityGroups(input)
+		result, err := e.Client.DescribeSecurityGroupsWithContext(ctx, input)
 		if err != nil {
 			return nil, err
 		}
@@ -483,7 +565,7 @@ func (e EC2Client) MakeLaunchTemplateBlockDeviceMappings(blocks []schemas.BlockD
 	return ret
 }
 
-func (e EC2Client) GetVPCId(vpc string) (string, error) {
+func (e EC2Client) GetVPCId(ctx context.Context, vpc string) (string, error) {
 	ret, err := regexp.MatchString("vpc-[0-9A-Fa-f]{17}", vpc)
 	if err != nil {
 		return constants.EmptyString, fmt.Errorf("error occurs when checking regex %v", err.Error())
@@ -504,7 +586,7 @@ func (e EC2Client) GetVPCId(vpc string) (string, error) {
 		},
 	}
 
-	result, err := e.Client.DescribeVpcs(input)
+	result, err := e.Client.DescribeVpcsWithContext(ctx, input)
 	if err != nil {
 		return constants.EmptyString, err
 	}
@@ -523,7 +605,7 @@ func (e EC2Client) GetVPCId(vpc string) (string, error) {
 }
 
 // CreateAutoScalingGroup creates new autoscaling group
-func (e EC2Client) CreateAutoScalingGroup(name, launchTemplateName, healthcheckType string,
+func (e EC2Client) CreateAutoScalingGroup(ctx context.Context, name, launchTemplateName, healthcheckType string,
 	healthcheckGracePeriod int64,
 	capacity schemas.Capacity,
 	loadbalancers, availabilityZones []string,
@@ -592,7 +674,7 @@ func (e EC2Client) CreateAutoScalingGroup(name, launchTemplateName, healthcheckT
 		input.LifecycleHookSpecificationList = hooks
 	}
 
-	_, err := e.AsClient.CreateAutoScalingGroup(input)
+	_, err := e.AsClient.CreateAutoScalingGroupWithContext(ctx, input)
 	if err != nil {
 		return false, err
 	}
@@ -696,9 +778,9 @@ func (e EC2Client) GenerateTags(tagList []string, asgName, app, stack, ansibleTa
 }
 
 // GetAvailabilityZones get all available availability zones
-func (e EC2Client) GetAvailabilityZones(vpc string, azs []string) ([]string, error) {
+func (e EC2Client) GetAvailabilityZones(ctx context.Context, vpc string, azs []string) ([]string, error) {
 	var ret []string
-	vpcID, err := e.GetVPCId(vpc)
+	vpcID, err := e.GetVPCId(ctx, vpc)
 	if err != nil {
 		return nil, err
 	}
@@ -714,7 +796,7 @@ func (e EC2Client) GetAvailabilityZones(vpc string, azs []string) ([]string, err
 		},
 	}
 
-	result, err := e.Client.DescribeSubnets(input)
+	result, err := e.Client.DescribeSubnetsWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -730,8 +812,8 @@ func (e EC2Client) GetAvailabilityZones(vpc string, azs []string) ([]string, err
 }
 
 // GetSubnets retrieves all subnets available
-func (e EC2Client) GetSubnets(vpc string, usePublicSubnets bool, azs []string) ([]string, error) {
-	vpcID, err := e.GetVPCId(vpc)
+func (e EC2Client) GetSubnets(ctx context.Context, vpc string, usePublicSubnets bool, azs []string) ([]string, error) {
+	vpcID, err := e.GetVPCId(ctx, vpc)
 	if err != nil {
 		return nil, err
 	}
@@ -747,7 +829,7 @@ func (e EC2Client) GetSubnets(vpc string, usePublicSubnets bool, azs []string) (
 		},
 	}
 
-	result, err := e.Client.DescribeSubnets(input)
+	result, err := e.Client.DescribeSubnetsWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -773,7 +855,7 @@ func (e EC2Client) GetSubnets(vpc string, usePublicSubnets bool, azs []string) (
 }
 
 // Update Autoscaling Group size
-func (e EC2Client) UpdateAutoScalingGroupSize(asg string, min, max, desired, retry int64) (int64, error) {
+func (e EC2Client) UpdateAutoScalingGroupSize(ctx context.Context, asg string, min, max, desired, retry int64) (int64, error) {
 	input := &autoscaling.UpdateAutoScalingGroupInput{
 		AutoScalingGroupName: aws.String(asg),
 		MaxSize:              aws.Int64(max),
@@ -781,7 +863,7 @@ func (e EC2Client) UpdateAutoScalingGroupSize(asg string, min, max, desired, ret
 		DesiredCapacity:      aws.Int64(desired),
 	}
 
-	_, err := e.AsClient.UpdateAutoScalingGroup(input)
+	_, err := e.AsClient.UpdateAutoScalingGroupWithContext(ctx, input)
 	if err != nil {
 		return retry - 1, err
 	}
@@ -790,7 +872,7 @@ func (e EC2Client) UpdateAutoScalingGroupSize(asg string, min, max, desired, ret
 }
 
 //CreateScalingPolicy creates scaling policy
-func (e EC2Client) CreateScalingPolicy(policy schemas.ScalePolicy, asgName string) (*string, error) {
+func (e EC2Client) CreateScalingPolicy(ctx context.Context, policy schemas.ScalePolicy, asgName string) (*string, error) {
 	input := &autoscaling.PutScalingPolicyInput{
 		AdjustmentType:       aws.String(policy.AdjustmentType),
 		AutoScalingGroupName: aws.String(asgName),
@@ -799,7 +881,7 @@ func (e EC2Client) CreateScalingPolicy(policy schemas.ScalePolicy, asgName strin
 		Cooldown:             aws.Int64(policy.Cooldown),
 	}
 
-	result, err := e.AsClient.PutScalingPolicy(input)
+	result, err := e.AsClient.PutScalingPolicyWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -808,13 +890,13 @@ func (e EC2Client) CreateScalingPolicy(policy schemas.ScalePolicy, asgName strin
 }
 
 // EnableMetrics enables metric monitoring of autoscaling group
-func (e EC2Client) EnableMetrics(asgName string) error {
+func (e EC2Client) EnableMetrics(ctx context.Context, asgName string) error {
 	input := &autoscaling.EnableMetricsCollectionInput{
 		AutoScalingGroupName: aws.String(asgName),
 		Granularity:          aws.String("1Minute"),
 	}
 
-	_, err := e.AsClient.EnableMetricsCollection(input)
+	_, err := e.AsClient.EnableMetricsCollectionWithContext(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -876,14 +958,14 @@ func createSingleLifecycleHookSpecification(l schemas.LifecycleHookSpecification
 }
 
 // GetTargetGroup returns list of target group ARN of autoscaling group
-func (e EC2Client) GetTargetGroups(asgName string) ([]*string, error) {
+func (e EC2Client) GetTargetGroups(ctx context.Context, asgName string) ([]*string, error) {
 	input := &autoscaling.DescribeAutoScalingGroupsInput{
 		AutoScalingGroupNames: []*string{
 			aws.String(asgName),
 		},
 	}
 
-	result, err := e.AsClient.DescribeAutoScalingGroups(input)
+	result, err := e.AsClient.DescribeAutoScalingGroupsWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -897,11 +979,11 @@ func (e EC2Client) GetTargetGroups(asgName string) ([]*string, error) {
 }
 
 // getSingleAutoScalingGroup return detailed information of autoscaling group
-func getSingleAutoScalingGroup(client *autoscaling.AutoScaling, asgName string) (*autoscaling.Group, error) {
+func getSingleAutoScalingGroup(ctx context.Context, client *autoscaling.AutoScaling, asgName string) (*autoscaling.Group, error) {
 	input := &autoscaling.DescribeAutoScalingGroupsInput{
 		AutoScalingGroupNames: aws.StringSlice([]string{asgName}),
 	}
-	ret, err := client.DescribeAutoScalingGroups(input)
+	ret, err := client.DescribeAutoScalingGroupsWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -914,7 +996,7 @@ func getSingleAutoScalingGroup(client *autoscaling.AutoScaling, asgName string)
 }
 
 // Update Autoscaling Group information
-func (e EC2Client) UpdateAutoScalingGroup(asg string, capacity schemas.Capacity) error {
+func (e EC2Client) UpdateAutoScalingGroup(ctx context.Context, asg string, capacity schemas.Capacity) error {
 	input := &autoscaling.UpdateAutoScalingGroupInput{
 		AutoScalingGroupName: aws.String(asg),
 		MaxSize:              aws.Int64(capacity.Max),
@@ -922,7 +1004,7 @@ func (e EC2Client) UpdateAutoScalingGroup(asg string, capacity schemas.Capacity)
 		DesiredCapacity:      aws.Int64(capacity.Desired),
 	}
 
-	_, err := e.AsClient.UpdateAutoScalingGroup(input)
+	_, err := e.AsClient.UpdateAutoScalingGroupWithContext(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -931,7 +1013,7 @@ func (e EC2Client) UpdateAutoScalingGroup(asg string, capacity schemas.Capacity)
 }
 
 // CreateScheduledActions creates scheduled actions
-func (e EC2Client) CreateScheduledActions(asg string, actions []schemas.ScheduledAction) error {
+func (e EC2Client) CreateScheduledActions(ctx context.Context, asg string, actions []schemas.ScheduledAction) error {
 	input := &autoscaling.BatchPutScheduledUpdateGroupActionInput{
 		AutoScalingGroupName: aws.String(asg),
 	}
@@ -951,10 +1033,219 @@ func (e EC2Client) CreateScheduledActions(asg string, actions []schemas.Schedule
 
 	input.ScheduledUpdateGroupActions = scheduledUpdateGroupActions
 
-	_, err := e.AsClient.BatchPutScheduledUpdateGroupAction(input)
+	_, err := e.AsClient.BatchPutScheduledUpdateGroupActionWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AttachTargetGroups attaches target groups to autoscaling group
+func (e EC2Client) AttachTargetGroups(ctx context.Context, asg string, targetGroupArns []*string) error {
+	input := &autoscaling.AttachLoadBalancerTargetGroupsInput{
+		AutoScalingGroupName: aws.String(asg),
+		TargetGroupARNs:      targetGroupArns,
+	}
+
+	_, err := e.AsClient.AttachLoadBalancerTargetGroupsWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DetachTargetGroups detaches target groups from autoscaling group
+func (e EC2Client) DetachTargetGroups(ctx context.Context, asg string, targetGroupArns []*string) error {
+	input := &autoscaling.DetachLoadBalancerTargetGroupsInput{
+		AutoScalingGroupName: aws.String(asg),
+		TargetGroupARNs:      targetGroupArns,
+	}
+
+	_, err := e.AsClient.DetachLoadBalancerTargetGroupsWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AttachLoadBalancers attaches classic load balancers to autoscaling group
+func (e EC2Client) AttachLoadBalancers(ctx context.Context, asg string, loadbalancers []string) error {
+	input := &autoscaling.AttachLoadBalancersInput{
+		AutoScalingGroupName: aws.String(asg),
+		LoadBalancerNames:    aws.StringSlice(loadbalancers),
+	}
+
+	_, err := e.AsClient.AttachLoadBalancersWithContext(ctx, input)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// DetachLoadBalancers detaches classic load balancers from autoscaling group
+func (e EC2Client) DetachLoadBalancers(ctx context.Context, asg string, loadbalancers []string) error {
+	input := &autoscaling.DetachLoadBalancersInput{
+		AutoScalingGroupName: aws.String(asg),
+		LoadBalancerNames:    aws.StringSlice(loadbalancers),
+	}
+
+	_, err := e.AsClient.DetachLoadBalancersWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// StartInstanceRefresh starts rolling replacement of instances in autoscaling group
+func (e EC2Client) StartInstanceRefresh(ctx context.Context, asg string, minHealthyPercentage, instanceWarmup int64) (*string, error) {
+	input := &autoscaling.StartInstanceRefreshInput{
+		AutoScalingGroupName: aws.String(asg),
+		Strategy:             aws.String(autoscaling.RefreshStrategyRolling),
+		Preferences: &autoscaling.RefreshPreferences{
+			MinHealthyPercentage: aws.Int64(minHealthyPercentage),
+		},
+	}
+
+	if instanceWarmup > 0 {
+		input.Preferences.InstanceWarmup = aws.Int64(instanceWarmup)
+	}
+
+	result, err := e.AsClient.StartInstanceRefreshWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.InstanceRefreshId, nil
+}
+
+// GetInstanceRefresh returns status of instance refresh
+func (e EC2Client) GetInstanceRefresh(ctx context.Context, asg, refreshID string) (*autoscaling.InstanceRefresh, error) {
+	input := &autoscaling.DescribeInstanceRefreshesInput{
+		AutoScalingGroupName: aws.String(asg),
+		InstanceRefreshIds:   aws.StringSlice([]string{refreshID}),
+	}
+
+	result, err := e.AsClient.DescribeInstanceRefreshesWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.InstanceRefreshes) == 0 {
+		return nil, fmt.Errorf("no instance refresh exists: %s", refreshID)
+	}
+
+	return result.InstanceRefreshes[0], nil
+}
+
+// CancelInstanceRefresh cancels instance refresh in progress
+func (e EC2Client) CancelInstanceRefresh(ctx context.Context, asg string) error {
+	input := &autoscaling.CancelInstanceRefreshInput{
+		AutoScalingGroupName: aws.String(asg),
+	}
+
+	_, err := e.AsClient.CancelInstanceRefreshWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateStandbyTag adds or removes standby tag of autoscaling group
+func (e EC2Client) UpdateStandbyTag(ctx context.Context, asg string, standby bool) error {
+	tag := &autoscaling.Tag{
+		Key:               aws.String(constants.StandbyTagKey),
+		ResourceId:        aws.String(asg),
+		ResourceType:      aws.String("auto-scaling-group"),
+		Value:             aws.String("true"),
+		PropagateAtLaunch: aws.Bool(false),
+	}
+
+	if standby {
+		_, err := e.AsClient.CreateOrUpdateTagsWithContext(ctx, &autoscaling.CreateOrUpdateTagsInput{
+			Tags: []*autoscaling.Tag{tag},
+		})
+		return err
+	}
+
+	_, err := e.AsClient.DeleteTagsWithContext(ctx, &autoscaling.DeleteTagsInput{
+		Tags: []*autoscaling.Tag{tag},
+	})
+	return err
+}
+
+// SuspendProcesses suspends scaling processes of autoscaling group
+func (e EC2Client) SuspendProcesses(ctx context.Context, asg string, processes []string) error {
+	input := &autoscaling.ScalingProcessQuery{
+		AutoScalingGroupName: aws.String(asg),
+		ScalingProcesses:     aws.StringSlice(processes),
+	}
+
+	_, err := e.AsClient.SuspendProcessesWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResumeProcesses resumes suspended scaling processes of autoscaling group
+func (e EC2Client) ResumeProcesses(ctx context.Context, asg string, processes []string) error {
+	input := &autoscaling.ScalingProcessQuery{
+		AutoScalingGroupName: aws.String(asg),
+		ScalingProcesses:     aws.StringSlice(processes),
+	}
+
+	_, err := e.AsClient.ResumeProcessesWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CompleteLifecycleAction completes the lifecycle action of the instance with the result
+func (e EC2Client) CompleteLifecycleAction(ctx context.Context, asg, hook, instanceID, result string) error {
+	input := &autoscaling.CompleteLifecycleActionInput{
+		AutoScalingGroupName:  aws.String(asg),
+		LifecycleHookName:     aws.String(hook),
+		InstanceId:            aws.String(instanceID),
+		LifecycleActionResult: aws.String(result),
+	}
+
+	_, err := e.AsClient.CompleteLifecycleActionWithContext(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPrivateIPAddresses returns private ip addresses of instances
+func (e EC2Client) GetPrivateIPAddresses(ctx context.Context, instanceIDs []string) (map[string]string, error) {
+	input := &ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice(instanceIDs),
+	}
+
+	ret := map[string]string{}
+	err := e.Client.DescribeInstancesPagesWithContext(ctx, input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.PrivateIpAddress != nil {
+					ret[*instance.InstanceId] = *instance.PrivateIpAddress
+				}
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
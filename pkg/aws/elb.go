@@ -17,6 +17,9 @@ limitations under the license.
 package aws
 
 import (
+	"context"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -45,12 +48,12 @@ func getELBClientFn(session client.ConfigProvider, region string, creds *credent
 }
 
 // GetHostInELB returns instances in ELB
-func (e ELBClient) GetHealthyHostInELB(group *autoscaling.Group, elbName string) ([]HealthcheckHost, error) {
+func (e ELBClient) GetHealthyHostInELB(ctx context.Context, group *autoscaling.Group, elbName string) ([]HealthcheckHost, error) {
 	input := &elb.DescribeInstanceHealthInput{
 		LoadBalancerName: aws.String(elbName),
 	}
 
-	result, err := e.Client.DescribeInstanceHealth(input)
+	result, err := e.Client.DescribeInstanceHealthWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -74,3 +77,27 @@ func (e ELBClient) GetHealthyHostInELB(group *autoscaling.Group, elbName string)
 
 	return ret, nil
 }
+
+// GetConnectionDrainingTimeout returns the longest connection draining timeout of classic load balancers
+func (e ELBClient) GetConnectionDrainingTimeout(ctx context.Context, loadbalancers []string) (time.Duration, error) {
+	var timeout time.Duration
+	for _, lb := range loadbalancers {
+		result, err := e.Client.DescribeLoadBalancerAttributesWithContext(ctx, &elb.DescribeLoadBalancerAttributesInput{
+			LoadBalancerName: aws.String(lb),
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		draining := result.LoadBalancerAttributes.ConnectionDraining
+		if draining == nil || !aws.BoolValue(draining.Enabled) {
+			continue
+		}
+
+		if d := time.Duration(aws.Int64Value(draining.Timeout)) * time.Second; d > timeout {
+			timeout = d
+		}
+	}
+
+	return timeout, nil
+}
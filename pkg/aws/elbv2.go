@@ -17,6 +17,10 @@ limitations under the license.
 package aws
 
 import (
+	"context"
+	"strconv"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -53,7 +57,7 @@ func getElbClientFn(session client.ConfigProvider, region string, creds *credent
 }
 
 // GetTargetGroupARNs returns arn list of target groups
-func (e ELBV2Client) GetTargetGroupARNs(targetGroups []string) ([]*string, error) {
+func (e ELBV2Client) GetTargetGroupARNs(ctx context.Context, targetGroups []string) ([]*string, error) {
 	if len(targetGroups) == 0 {
 		return nil, nil
 	}
@@ -62,7 +66,7 @@ func (e ELBV2Client) GetTargetGroupARNs(targetGroups []string) ([]*string, error
 		Names: aws.StringSlice(targetGroups),
 	}
 
-	result, err := e.Client.DescribeTargetGroups(input)
+	result, err := e.Client.DescribeTargetGroupsWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -76,12 +80,12 @@ func (e ELBV2Client) GetTargetGroupARNs(targetGroups []string) ([]*string, error
 }
 
 // GetHostInTarget gets host instance
-func (e ELBV2Client) GetHostInTarget(group *autoscaling.Group, targetGroupArn *string, isUpdate, downSizingUpdate bool) ([]HealthcheckHost, error) {
+func (e ELBV2Client) GetHostInTarget(ctx context.Context, group *autoscaling.Group, targetGroupArn *string, isUpdate, downSizingUpdate bool) ([]HealthcheckHost, error) {
 	input := &elbv2.DescribeTargetHealthInput{
 		TargetGroupArn: aws.String(*targetGroupArn),
 	}
 
-	result, err := e.Client.DescribeTargetHealth(input)
+	result, err := e.Client.DescribeTargetHealthWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -115,12 +119,12 @@ func (e ELBV2Client) GetHostInTarget(group *autoscaling.Group, targetGroupArn *s
 }
 
 // GetLoadBalancerFromTG returns list of loadbalancer from target groups
-func (e ELBV2Client) GetLoadBalancerFromTG(targetGroups []*string) ([]*string, error) {
+func (e ELBV2Client) GetLoadBalancerFromTG(ctx context.Context, targetGroups []*string) ([]*string, error) {
 	input := &elbv2.DescribeTargetGroupsInput{
 		TargetGroupArns: targetGroups,
 	}
 
-	result, err := e.Client.DescribeTargetGroups(input)
+	result, err := e.Client.DescribeTargetGroupsWithContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -136,3 +140,121 @@ func (e ELBV2Client) GetLoadBalancerFromTG(targetGroups []*string) ([]*string, e
 
 	return aws.StringSlice(lbs), nil
 }
+
+// UpdateForwardWeights changes weights of target groups in forward action of listener or listener rule
+func (e ELBV2Client) UpdateForwardWeights(ctx context.Context, listenerArn, ruleArn string, weights map[string]int64) error {
+	tuples := []*elbv2.TargetGroupTuple{}
+	for tg, weight := range weights {
+		tuples = append(tuples, &elbv2.TargetGroupTuple{
+			TargetGroupArn: aws.String(tg),
+			Weight:         aws.Int64(weight),
+		})
+	}
+
+	actions := []*elbv2.Action{
+		{
+			Type: aws.String(elbv2.ActionTypeEnumForward),
+			ForwardConfig: &elbv2.ForwardActionConfig{
+				TargetGroups: tuples,
+			},
+		},
+	}
+
+	if len(ruleArn) > 0 {
+		_, err := e.Client.ModifyRuleWithContext(ctx, &elbv2.ModifyRuleInput{
+			RuleArn: aws.String(ruleArn),
+			Actions: actions,
+		})
+		return err
+	}
+
+	_, err := e.Client.ModifyListenerWithContext(ctx, &elbv2.ModifyListenerInput{
+		ListenerArn:    aws.String(listenerArn),
+		DefaultActions: actions,
+	})
+
+	return err
+}
+
+// GetForwardWeights returns weights of target groups in forward action of listener or listener rule
+func (e ELBV2Client) GetForwardWeights(ctx context.Context, listenerArn, ruleArn string) (map[string]int64, error) {
+	var actions []*elbv2.Action
+	if len(ruleArn) > 0 {
+		result, err := e.Client.DescribeRulesWithContext(ctx, &elbv2.DescribeRulesInput{
+			RuleArns: []*string{aws.String(ruleArn)},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range result.Rules {
+			actions = append(actions, rule.Actions...)
+		}
+	} else {
+		result, err := e.Client.DescribeListenersWithContext(ctx, &elbv2.DescribeListenersInput{
+			ListenerArns: []*string{aws.String(listenerArn)},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, listener := range result.Listeners {
+			actions = append(actions, listener.DefaultActions...)
+		}
+	}
+
+	weights := map[string]int64{}
+	for _, action := range actions {
+		if *action.Type != elbv2.ActionTypeEnumForward {
+			continue
+		}
+
+		if action.ForwardConfig != nil && len(action.ForwardConfig.TargetGroups) > 0 {
+			for _, tg := range action.ForwardConfig.TargetGroups {
+				// weight is 1 if it is not specified
+				weight := int64(1)
+				if tg.Weight != nil {
+					weight = *tg.Weight
+				}
+				weights[*tg.TargetGroupArn] = weight
+			}
+			continue
+		}
+
+		if action.TargetGroupArn != nil {
+			weights[*action.TargetGroupArn] = 1
+		}
+	}
+
+	return weights, nil
+}
+
+// GetDeregistrationDelay returns the longest deregistration delay of target groups
+func (e ELBV2Client) GetDeregistrationDelay(ctx context.Context, targetGroupArns []*string) (time.Duration, error) {
+	var delay time.Duration
+	for _, arn := range targetGroupArns {
+		result, err := e.Client.DescribeTargetGroupAttributesWithContext(ctx, &elbv2.DescribeTargetGroupAttributesInput{
+			TargetGroupArn: arn,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, attr := range result.Attributes {
+			if *attr.Key != constants.DeregistrationDelayAttribute {
+				continue
+			}
+
+			seconds, err := strconv.Atoi(*attr.Value)
+			if err != nil {
+				return 0, err
+			}
+
+			if d := time.Duration(seconds) * time.Second; d > delay {
+				delay = d
+			}
+		}
+	}
+
+	return delay, nil
+}
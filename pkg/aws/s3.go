@@ -17,9 +17,11 @@ limitations under the license.
 package aws
 
 import (
+	"bytes"
 	"io/ioutil"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -60,3 +62,52 @@ func (s S3Client) GetManifest(bucket, key string) ([]byte, error) {
 
 	return body, nil
 }
+
+// GetObject returns body of the object
+func (s S3Client) GetObject(bucket, key string) ([]byte, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+
+	result, err := s.Client.GetObject(input)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer result.Body.Close()
+
+	return ioutil.ReadAll(result.Body)
+}
+
+// PutObject uploads body to the bucket
+func (s S3Client) PutObject(bucket, key string, body []byte) error {
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	}
+
+	if _, err := s.Client.PutObject(input); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteObject deletes the object from the bucket
+func (s S3Client) DeleteObject(bucket, key string) error {
+	input := &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+
+	if _, err := s.Client.DeleteObject(input); err != nil {
+		return err
+	}
+
+	return nil
+}
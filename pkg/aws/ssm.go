@@ -17,12 +17,18 @@ limitations under the license.
 package aws
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/sirupsen/logrus"
+
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
 )
 
 type SSMClient struct {
@@ -43,18 +49,20 @@ func getSsmClientFn(session client.ConfigProvider, region string, creds *credent
 }
 
 //SSM Send command
-func (s SSMClient) SendCommand(target []*string, commands []*string) bool {
+// It returns id of the command to check the results of it
+func (s SSMClient) SendCommand(ctx context.Context, target []*string, commands []*string, timeout time.Duration) (string, error) {
 	input := &ssm.SendCommandInput{
 		DocumentName:   aws.String("AWS-RunShellScript"),
 		TimeoutSeconds: aws.Int64(3600),
 		InstanceIds:    target,
 		Comment:        aws.String("goployer lifecycle callbacks"),
 		Parameters: map[string][]*string{
-			"commands": commands,
+			"commands":         commands,
+			"executionTimeout": {aws.String(fmt.Sprintf("%.0f", timeout.Seconds()))},
 		},
 	}
 
-	_, err := s.Client.SendCommand(input)
+	result, err := s.Client.SendCommandWithContext(ctx, input)
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			switch aerr.Code() {
@@ -74,8 +82,41 @@ func (s SSMClient) SendCommand(target []*string, commands []*string) bool {
 			// Message from an error.
 			logrus.Errorln(err.Error())
 		}
-		return false
+		return constants.EmptyString, err
+	}
+
+	return *result.Command.CommandId, nil
+}
+
+// ListCommandInvocations returns invocations of the command for all target instances
+func (s SSMClient) ListCommandInvocations(ctx context.Context, commandID string) ([]*ssm.CommandInvocation, error) {
+	input := &ssm.ListCommandInvocationsInput{
+		CommandId: aws.String(commandID),
+	}
+
+	var invocations []*ssm.CommandInvocation
+	err := s.Client.ListCommandInvocationsPagesWithContext(ctx, input, func(page *ssm.ListCommandInvocationsOutput, lastPage bool) bool {
+		invocations = append(invocations, page.CommandInvocations...)
+		return !lastPage
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invocations, nil
+}
+
+// GetCommandInvocation returns the result of the command on the instance
+func (s SSMClient) GetCommandInvocation(ctx context.Context, commandID, instanceID string) (*ssm.GetCommandInvocationOutput, error) {
+	input := &ssm.GetCommandInvocationInput{
+		CommandId:  aws.String(commandID),
+		InstanceId: aws.String(instanceID),
+	}
+
+	result, err := s.Client.GetCommandInvocationWithContext(ctx, input)
+	if err != nil {
+		return nil, err
 	}
 
-	return true
+	return result, nil
 }
@@ -149,6 +149,14 @@ func (b Builder) SetStacks(stacks []schemas.Stack) Builder {
 		if b.Config.PollingInterval > 0 {
 			stacks[i].PollingInterval = b.Config.PollingInterval
 		}
+
+		if b.Config.RollbackOnFailure {
+			stacks[i].RollbackOnFailure = true
+		}
+
+		if b.Config.ManualPromotion {
+			stacks[i].ManualPromotion = true
+		}
 	}
 
 	b.Stacks = stacks
@@ -179,6 +187,14 @@ func (b Builder) CheckValidation() error {
 		}
 	}
 
+	if err := checkStackDependencies(b.Stacks); err != nil {
+		return err
+	}
+
+	if b.Config.Plan && !tool.IsStringInArray(b.Config.Output, constants.AvailablePlanOutputs) {
+		return fmt.Errorf("output of plan should be one of : %s", strings.Join(constants.AvailablePlanOutputs, ","))
+	}
+
 	if len(b.AwsConfig.ScheduledActions) > 0 {
 		for _, sa := range b.AwsConfig.ScheduledActions {
 			if len(sa.Name) == 0 {
@@ -273,6 +289,34 @@ func (b Builder) CheckValidation() error {
 				if strings.ToUpper(api.Method) == "GET" && len(api.Body) > 0 {
 					return errors.New("api with GET request cannot have body")
 				}
+
+				if api.Assertions != nil {
+					if err := checkAPITestAssertions(*api.Assertions); err != nil {
+						return fmt.Errorf("%s : %s", err.Error(), api.URL)
+					}
+				}
+			}
+
+			if att.Assertions != nil {
+				if err := checkAPITestAssertions(*att.Assertions); err != nil {
+					return fmt.Errorf("%s : %s", err.Error(), att.Name)
+				}
+			}
+
+			for _, scenario := range att.Scenarios {
+				if err := checkAPIScenario(*scenario); err != nil {
+					return fmt.Errorf("%s : %s", err.Error(), att.Name)
+				}
+			}
+
+			if att.Regression != nil {
+				if att.Regression.MaxLatencyP99Increase < 0 || att.Regression.MaxLatencyP95Increase < 0 {
+					return fmt.Errorf("tolerance of latency increase cannot be negative : %s", att.Name)
+				}
+
+				if att.Regression.MaxSuccessRatioDecrease < 0 || att.Regression.MaxSuccessRatioDecrease > 1 {
+					return fmt.Errorf("max_success_ratio_decrease should be between 0 and 1 : %s", att.Name)
+				}
 			}
 		}
 	}
@@ -287,6 +331,97 @@ func (b Builder) CheckValidation() error {
 			return fmt.Errorf("you cannot use prohibited tags : %s", strings.Join(constants.ProhibitedTags, ","))
 		}
 
+		// Check replacement type
+		if len(stack.ReplacementType) > 0 && !tool.IsStringInArray(stack.ReplacementType, constants.AvailableReplacementTypes) {
+			return fmt.Errorf("no valid replacement type : %s", stack.ReplacementType)
+		}
+
+		// Check listener traffic switch
+		if len(stack.TrafficSwitch) > 0 {
+			if !tool.IsStringInArray(stack.TrafficSwitch, constants.AvailableTrafficSwitches) {
+				return fmt.Errorf("no valid traffic switch : %s", stack.TrafficSwitch)
+			}
+
+			if len(stack.ReplacementType) > 0 && stack.ReplacementType != constants.BlueGreenDeployment {
+				return fmt.Errorf("traffic_switch is only available with %s replacement type : %s", constants.BlueGreenDeployment, stack.Stack)
+			}
+
+			for _, region := range stack.Regions {
+				if len(region.BlueTargetGroup) == 0 || len(region.GreenTargetGroup) == 0 {
+					return fmt.Errorf("blue_target_group and green_target_group are required for listener traffic switch : %s", region.Region)
+				}
+
+				if region.BlueTargetGroup == region.GreenTargetGroup {
+					return fmt.Errorf("blue_target_group and green_target_group should be different : %s", region.Region)
+				}
+
+				if len(region.ListenerArn) == 0 && len(region.ListenerRuleArn) == 0 {
+					return fmt.Errorf("listener_arn or listener_rule_arn is required for listener traffic switch : %s", region.Region)
+				}
+
+				// health of new version is checked in blue or green target group
+				if len(region.HealthcheckTargetGroup) > 0 || len(region.HealthcheckLB) > 0 {
+					return fmt.Errorf("healthcheck_target_group and healthcheck_load_balancer cannot be used with listener traffic switch : %s", region.Region)
+				}
+
+				if tool.IsStringInArray(region.BlueTargetGroup, region.TargetGroups) || tool.IsStringInArray(region.GreenTargetGroup, region.TargetGroups) {
+					return fmt.Errorf("blue_target_group and green_target_group cannot be used in target_groups : %s", region.Region)
+				}
+			}
+		}
+
+		// Check warmup setting
+		if stack.Warmup != nil {
+			if err := checkWarmup(stack); err != nil {
+				return err
+			}
+		}
+
+		// Check canary setting
+		if stack.ReplacementType == constants.CanaryDeployment {
+			if stack.Canary == nil {
+				return fmt.Errorf("you have to set canary configuration with %s replacement type : %s", constants.CanaryDeployment, stack.Stack)
+			}
+
+			if stack.Canary.CapacityPercentage < 1 || stack.Canary.CapacityPercentage > 100 {
+				return errors.New("capacity_percentage of canary should be between 1 and 100")
+			}
+
+			if len(stack.Canary.TrafficSteps) == 0 {
+				return errors.New("you have to set at least one traffic step for canary")
+			}
+
+			prevStep := int64(0)
+			for _, step := range stack.Canary.TrafficSteps {
+				if step < 1 || step > 100 {
+					return fmt.Errorf("traffic step of canary should be between 1 and 100 : %d", step)
+				}
+
+				if step <= prevStep {
+					return errors.New("traffic steps of canary should be in ascending order")
+				}
+				prevStep = step
+			}
+
+			for _, region := range stack.Regions {
+				if len(region.CanaryTargetGroup) == 0 {
+					return fmt.Errorf("canary_target_group is required for canary deployment : %s", region.Region)
+				}
+
+				if len(region.HealthcheckTargetGroup) == 0 {
+					return fmt.Errorf("healthcheck_target_group is required for canary deployment : %s", region.Region)
+				}
+
+				if len(region.ListenerArn) == 0 && len(region.ListenerRuleArn) == 0 {
+					return fmt.Errorf("listener_arn or listener_rule_arn is required for canary deployment : %s", region.Region)
+				}
+
+				if region.CanaryTargetGroup == region.HealthcheckTargetGroup || tool.IsStringInArray(region.CanaryTargetGroup, region.TargetGroups) {
+					return fmt.Errorf("canary_target_group cannot be used as healthcheck_target_group or in target_groups : %s", region.Region)
+				}
+			}
+		}
+
 		// Check AMI
 		// Check Autoscaling and Alarm setting
 		if len(stack.Autoscaling) != 0 && len(stack.Alarms) != 0 {
@@ -309,6 +444,70 @@ func (b Builder) CheckValidation() error {
 			}
 		}
 
+		// Check rolling setting
+		if stack.ReplacementType == constants.RollingDeployment && stack.Rolling != nil {
+			if stack.Rolling.MinHealthyPercentage < 0 || stack.Rolling.MinHealthyPercentage > 100 {
+				return errors.New("min_healthy_percentage of rolling should be between 0 and 100")
+			}
+
+			if stack.Rolling.InstanceWarmup < 0 {
+				return errors.New("instance_warmup of rolling cannot be negative")
+			}
+
+			if stack.Rolling.UnhealthyTimeout < 0 {
+				return errors.New("unhealthy_timeout of rolling cannot be negative")
+			}
+		}
+
+		// Check rollout setting
+		if stack.Rollout != nil {
+			if err := checkRolloutWaves(stack); err != nil {
+				return err
+			}
+
+			if stack.Rollout.BakeTime < 0 {
+				return errors.New("bake_time of rollout cannot be negative")
+			}
+
+			if stack.ManualPromotion && len(stack.Rollout.Waves) > 1 {
+				return errors.New("manual_promotion cannot be used with multiple rollout waves")
+			}
+		}
+
+		if stack.Bake != nil {
+			if err := checkBake(*stack.Bake); err != nil {
+				return fmt.Errorf("%s : %s", err.Error(), stack.Stack)
+			}
+		}
+
+		// Check standby setting
+		if stack.RetainPreviousVersions < 0 {
+			return errors.New("retain_previous_versions cannot be negative")
+		}
+
+		if stack.RetainPreviousVersions > 0 && stack.ReplacementType == constants.RollingDeployment {
+			return errors.New("retain_previous_versions is not supported with rolling deployment")
+		}
+
+		// Check drain setting
+		if stack.Drain != nil {
+			if stack.ReplacementType == constants.RollingDeployment {
+				return errors.New("drain is not supported with rolling deployment")
+			}
+
+			if stack.Drain.Duration < 0 {
+				return errors.New("duration of drain cannot be negative")
+			}
+
+			if stack.Drain.StepSize < 0 {
+				return errors.New("step_size of drain cannot be negative")
+			}
+
+			if stack.Drain.StepInterval < 0 {
+				return errors.New("step_interval of drain cannot be negative")
+			}
+		}
+
 		// Check Spot Options
 		if stack.InstanceMarketOptions != nil {
 			if stack.InstanceMarketOptions.MarketType != "spot" {
@@ -373,11 +572,21 @@ func (b Builder) CheckValidation() error {
 					if l.HeartbeatTimeout == 0 {
 						Logger.Warnf("you didn't specify the heartbeat timeout. you might have to wait too long time.")
 					}
+
+					if l.Validation != nil {
+						if err := checkInstanceCheck(*l.Validation); err != nil {
+							return fmt.Errorf("%s : %s", err.Error(), l.LifecycleHookName)
+						}
+					}
 				}
 			}
 
 			if len(stack.LifecycleHooks.TerminateTransition) > 0 {
 				for _, l := range stack.LifecycleHooks.TerminateTransition {
+					if l.Validation != nil {
+						return fmt.Errorf("validation is only supported in launch_transition : %s", l.LifecycleHookName)
+					}
+
 					if len(l.NotificationTargetARN) > 0 && len(l.RoleARN) == 0 {
 						return fmt.Errorf("role_arn is needed if notification_target_arn is not empty : %s", l.LifecycleHookName)
 					}
@@ -405,7 +614,8 @@ func (b Builder) CheckValidation() error {
 			}
 
 			// Check target group
-			if len(region.TargetGroups) > 0 && region.HealthcheckTargetGroup == "" {
+			// health is checked in blue or green target group with listener traffic switch
+			if len(region.TargetGroups) > 0 && region.HealthcheckTargetGroup == "" && stack.TrafficSwitch != constants.ListenerTrafficSwitch {
 				return errors.New("you have to choose one target group as healthcheck_target_group")
 			}
 
@@ -424,6 +634,17 @@ func (b Builder) CheckValidation() error {
 				return errors.New("you cannot use healthcheck_target_group and healthcheck_load_balancer at the same time")
 			}
 
+			// Check direct healthcheck
+			if region.Healthcheck != nil {
+				if region.HealthcheckLB != "" || region.HealthcheckTargetGroup != "" {
+					return errors.New("you cannot use healthcheck with healthcheck_target_group or healthcheck_load_balancer")
+				}
+
+				if err := checkInstanceCheck(*region.Healthcheck); err != nil {
+					return fmt.Errorf("%s : %s", err.Error(), region.Region)
+				}
+			}
+
 			// Check userdata
 			if stack.Userdata.Type == "local" && len(stack.Userdata.Path) > 0 && !tool.CheckFileExists(stack.Userdata.Path) {
 				return errors.New("script file does not exists")
@@ -478,6 +699,24 @@ func (b Builder) CheckValidation() error {
 				return fmt.Errorf("template does not exist in the list: %s", stack.APITestTemplate)
 			}
 		}
+
+		if stack.APITestBeforeCleanup && !stack.APITestEnabled {
+			return fmt.Errorf("api_test_enabled is needed to run api test before cleanup: %s", stack.Stack)
+		}
+
+		for _, region := range stack.Regions {
+			if len(region.APITestTargetGroup) == 0 {
+				continue
+			}
+
+			if !stack.APITestBeforeCleanup {
+				return fmt.Errorf("api_test_target_group is only used with api_test_before_cleanup: %s", region.Region)
+			}
+
+			if region.APITestTargetGroup == region.HealthcheckTargetGroup || tool.IsStringInArray(region.APITestTargetGroup, region.TargetGroups) {
+				return fmt.Errorf("api_test_target_group should not be one of target_groups: %s", region.APITestTargetGroup)
+			}
+		}
 	}
 
 	return nil
@@ -729,6 +968,270 @@ func RefineConfig(config schemas.Config) (schemas.Config, error) {
 	return config, nil
 }
 
+// checkStackDependencies checks if dependencies between stacks are valid and have no cycle
+func checkStackDependencies(stacks []schemas.Stack) error {
+	dependencies := map[string][]string{}
+	for _, stack := range stacks {
+		dependencies[stack.Stack] = stack.DependsOn
+	}
+
+	for _, stack := range stacks {
+		for _, upstream := range stack.DependsOn {
+			if _, ok := dependencies[upstream]; !ok {
+				return fmt.Errorf("stack in depends_on does not exist : %s", upstream)
+			}
+		}
+	}
+
+	for _, stack := range stacks {
+		if !stack.ManualPromotion {
+			continue
+		}
+
+		for _, s := range stacks {
+			if tool.IsStringInArray(stack.Stack, s.DependsOn) {
+				return fmt.Errorf("manual_promotion cannot be used for the stack which other stacks depend on : %s", stack.Stack)
+			}
+		}
+	}
+
+	// 0: not visited, 1: visiting, 2: visited
+	state := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		path = append(path, name)
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("circular dependency exists between stacks : %s", strings.Join(path, " -> "))
+		case 2:
+			return nil
+		}
+
+		state[name] = 1
+		for _, upstream := range dependencies[name] {
+			if err := visit(upstream, path); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+
+		return nil
+	}
+
+	for _, stack := range stacks {
+		if err := visit(stack.Stack, []string{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkAPITestAssertions checks if thresholds of API test are valid
+func checkAPITestAssertions(assertions schemas.APITestAssertions) error {
+	if assertions.MinSuccessRatio < 0 || assertions.MinSuccessRatio > 1 {
+		return errors.New("min_success_ratio should be between 0 and 1")
+	}
+
+	if assertions.MaxLatencyP95 < 0 || assertions.MaxLatencyP99 < 0 {
+		return errors.New("max latency of API test cannot be negative")
+	}
+
+	if assertions.MaxErrors != nil && *assertions.MaxErrors < 0 {
+		return errors.New("max_errors cannot be negative")
+	}
+
+	for _, code := range assertions.AllowedStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid status code in allowed_status_codes : %d", code)
+		}
+	}
+
+	return nil
+}
+
+// checkAPIScenario checks if steps of API test scenario are valid
+func checkAPIScenario(scenario schemas.APIScenario) error {
+	if len(scenario.Name) == 0 {
+		return errors.New("name of API test scenario is required")
+	}
+
+	if len(scenario.Steps) == 0 {
+		return fmt.Errorf("steps of API test scenario are required : %s", scenario.Name)
+	}
+
+	for _, step := range scenario.Steps {
+		if len(step.Name) == 0 || len(step.URL) == 0 {
+			return fmt.Errorf("name and url are needed for step of API test scenario : %s", scenario.Name)
+		}
+
+		if !tool.IsStringInArray(strings.ToUpper(step.Method), constants.AllowedRequestMethod) {
+			return fmt.Errorf("api is not allowed: %s : %s", step.Method, step.Name)
+		}
+
+		if strings.ToUpper(step.Method) == "GET" && len(step.Body) > 0 {
+			return fmt.Errorf("api with GET request cannot have body : %s", step.Name)
+		}
+
+		if step.Timeout < 0 {
+			return fmt.Errorf("timeout of step cannot be negative : %s", step.Name)
+		}
+
+		if step.Expect != nil {
+			for _, code := range step.Expect.StatusCodes {
+				if code < 100 || code > 599 {
+					return fmt.Errorf("invalid status code in status_codes : %d : %s", code, step.Name)
+				}
+			}
+		}
+
+		for name, path := range step.Capture {
+			if len(name) == 0 || len(path) == 0 {
+				return fmt.Errorf("name and JSON path are needed for capture : %s", step.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkBake checks if alarms and metrics of bake are valid
+func checkBake(bake schemas.BakeConfig) error {
+	if bake.Duration <= 0 {
+		return errors.New("duration of bake should be positive")
+	}
+
+	if len(bake.Alarms) == 0 && len(bake.Metrics) == 0 {
+		return errors.New("you have to specify at least one alarm or metric for bake")
+	}
+
+	comparisons := []string{"GreaterThanThreshold", "GreaterThanOrEqualToThreshold", "LessThanThreshold", "LessThanOrEqualToThreshold"}
+	for _, metric := range bake.Metrics {
+		if len(metric.Name) == 0 || len(metric.Namespace) == 0 || len(metric.Metric) == 0 || len(metric.Statistic) == 0 {
+			return errors.New("name, namespace, metric and statistic are needed for bake metric")
+		}
+
+		if !tool.IsStringInArray(metric.Comparison, comparisons) {
+			return fmt.Errorf("comparison of bake metric should be one of %s : %s", strings.Join(comparisons, ", "), metric.Name)
+		}
+
+		if metric.Period < 0 || metric.Period%60 != 0 {
+			return fmt.Errorf("period of bake metric should be a multiple of 60 : %s", metric.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkInstanceCheck checks if the check of instance is valid
+func checkInstanceCheck(check schemas.InstanceCheck) error {
+	switch check.Type {
+	case constants.HTTPCheck, constants.TCPCheck:
+		if check.Port <= 0 {
+			return fmt.Errorf("port is needed for %s check", check.Type)
+		}
+	case constants.SSMCheck:
+		if len(check.Commands) == 0 {
+			return errors.New("commands are needed for ssm-command check")
+		}
+	default:
+		return fmt.Errorf("type of check should be one of http, tcp or ssm-command : %s", check.Type)
+	}
+
+	if check.ExpectedStatus != 0 && (check.Type != constants.HTTPCheck || check.ExpectedStatus < 100 || check.ExpectedStatus > 599) {
+		return fmt.Errorf("expected_status is only allowed as a valid status code of http check : %d", check.ExpectedStatus)
+	}
+
+	if check.SuccessCount < 0 {
+		return errors.New("success_count of check cannot be negative")
+	}
+
+	if check.Timeout < 0 {
+		return errors.New("timeout of check cannot be negative")
+	}
+
+	return nil
+}
+
+// checkWarmup checks if instances can be checked and warmed up before attaching load balancing targets
+func checkWarmup(stack schemas.Stack) error {
+	if len(stack.ReplacementType) > 0 && stack.ReplacementType != constants.BlueGreenDeployment {
+		return fmt.Errorf("warmup is only available with %s replacement type : %s", constants.BlueGreenDeployment, stack.Stack)
+	}
+
+	warmup := stack.Warmup
+	if warmup.Healthcheck != nil {
+		if err := checkInstanceCheck(*warmup.Healthcheck); err != nil {
+			return fmt.Errorf("%s : warmup", err.Error())
+		}
+	} else if stack.LifecycleHooks == nil || len(stack.LifecycleHooks.LaunchTransition) == 0 {
+		// without healthcheck or launch lifecycle hook, InService only means that instances are running
+		return fmt.Errorf("healthcheck of warmup or launch_transition lifecycle hook is required for warmup : %s", stack.Stack)
+	}
+
+	if len(warmup.Requests) > 0 && warmup.Port <= 0 {
+		return errors.New("port of warmup is needed for warmup requests")
+	}
+
+	for _, request := range warmup.Requests {
+		if !tool.IsStringInArray(strings.ToUpper(request.Method), constants.AllowedRequestMethod) {
+			return fmt.Errorf("method of warmup request is not allowed : %s", request.Method)
+		}
+	}
+
+	if warmup.RequestPerSecond < 0 {
+		return errors.New("request_per_second of warmup cannot be negative")
+	}
+
+	if warmup.Duration < 0 {
+		return errors.New("duration of warmup cannot be negative")
+	}
+
+	if warmup.MinSuccessRatio < 0 || warmup.MinSuccessRatio > 1 {
+		return errors.New("min_success_ratio of warmup should be between 0 and 1")
+	}
+
+	return nil
+}
+
+// checkRolloutWaves checks if every region of stack belongs to exactly one rollout wave
+func checkRolloutWaves(stack schemas.Stack) error {
+	if len(stack.Rollout.Waves) == 0 {
+		return fmt.Errorf("you have to set at least one wave for rollout : %s", stack.Stack)
+	}
+
+	regions := []string{}
+	for _, region := range stack.Regions {
+		regions = append(regions, region.Region)
+	}
+
+	waveRegions := []string{}
+	for _, wave := range stack.Rollout.Waves {
+		if len(wave) == 0 {
+			return fmt.Errorf("rollout wave cannot be empty : %s", stack.Stack)
+		}
+
+		for _, region := range wave {
+			if !tool.IsStringInArray(region, regions) {
+				return fmt.Errorf("region of rollout wave does not exist in the stack : %s", region)
+			}
+
+			if tool.IsStringInArray(region, waveRegions) {
+				return fmt.Errorf("region is duplicated in rollout waves : %s", region)
+			}
+			waveRegions = append(waveRegions, region)
+		}
+	}
+
+	for _, region := range regions {
+		if !tool.IsStringInArray(region, waveRegions) {
+			return fmt.Errorf("region is not in any rollout wave : %s", region)
+		}
+	}
+
+	return nil
+}
+
 // HasProhibited checks if there is any prohibited tags
 func HasProhibited(tags []string) bool {
 	for _, t := range tags {
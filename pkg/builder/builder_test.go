@@ -59,6 +59,13 @@ func TestCheckValidationConfig(t *testing.T) {
 	}
 	b.Stacks[0].Stack = "artd"
 
+	b.Config.Plan = true
+	b.Config.Output = "yaml"
+	if err := b.CheckValidation(); err == nil || err.Error() != "output of plan should be one of : table,json" {
+		t.Errorf("validation failed: plan output")
+	}
+	b.Config.Output = constants.DefaultPlanOutput
+
 	b.Config.Ami = "ami-test"
 	if err := b.CheckValidation(); err == nil || err.Error() != fmt.Sprintf("ami id cannot be used in different regions : %s", b.Config.Ami) {
 		t.Errorf("validation failed: global ami")
@@ -201,6 +208,56 @@ func TestCheckValidationScheduledAction(t *testing.T) {
 		t.Errorf("validation failed: api-test get-body mismatching")
 	}
 	b.APITestTemplates[0].APIs[0].Method = "POST"
+	b.APITestTemplates[0].APIs[0].URL = "https://example.com/post"
+
+	b.APITestTemplates[0].APIs[0].Assertions = &schemas.APITestAssertions{AllowedStatusCodes: []int{200, 1000}}
+	if err := b.CheckValidation(); err == nil || err.Error() != "invalid status code in allowed_status_codes : 1000 : https://example.com/post" {
+		t.Errorf("validation failed: api-test allowed status codes")
+	}
+	b.APITestTemplates[0].APIs[0].Assertions = nil
+
+	b.APITestTemplates[0].Assertions = &schemas.APITestAssertions{MinSuccessRatio: 1.5}
+	if err := b.CheckValidation(); err == nil || err.Error() != "min_success_ratio should be between 0 and 1 : test" {
+		t.Errorf("validation failed: api-test success ratio")
+	}
+	b.APITestTemplates[0].Assertions = nil
+
+	b.APITestTemplates[0].Scenarios = []*schemas.APIScenario{
+		{
+			Name: "login",
+			Steps: []*schemas.APIStep{
+				{Name: "token", Method: "GET", URL: "https://example.com/login", Body: []string{"user=test"}},
+			},
+		},
+	}
+	if err := b.CheckValidation(); err == nil || err.Error() != "api with GET request cannot have body : token : test" {
+		t.Errorf("validation failed: api-test scenario get-body mismatching")
+	}
+	b.APITestTemplates[0].Scenarios[0].Steps[0].Method = "POST"
+
+	b.APITestTemplates[0].Scenarios[0].Steps[0].Expect = &schemas.APIExpectation{StatusCodes: []int{0}}
+	if err := b.CheckValidation(); err == nil || err.Error() != "invalid status code in status_codes : 0 : token : test" {
+		t.Errorf("validation failed: api-test scenario status codes")
+	}
+	b.APITestTemplates[0].Scenarios[0].Steps[0].Expect = nil
+
+	b.APITestTemplates[0].Scenarios[0].Steps[0].Capture = map[string]string{"token": ""}
+	if err := b.CheckValidation(); err == nil || err.Error() != "name and JSON path are needed for capture : token : test" {
+		t.Errorf("validation failed: api-test scenario capture")
+	}
+	b.APITestTemplates[0].Scenarios[0].Steps[0].Capture = map[string]string{"token": "$.data.token"}
+
+	b.APITestTemplates[0].Scenarios = append(b.APITestTemplates[0].Scenarios, &schemas.APIScenario{Name: "empty"})
+	if err := b.CheckValidation(); err == nil || err.Error() != "steps of API test scenario are required : empty : test" {
+		t.Errorf("validation failed: api-test scenario steps")
+	}
+	b.APITestTemplates[0].Scenarios = b.APITestTemplates[0].Scenarios[:1]
+
+	b.APITestTemplates[0].Regression = &schemas.APITestRegression{MaxSuccessRatioDecrease: 2}
+	if err := b.CheckValidation(); err == nil || err.Error() != "max_success_ratio_decrease should be between 0 and 1 : test" {
+		t.Errorf("validation failed: api-test regression success ratio")
+	}
+	b.APITestTemplates[0].Regression = &schemas.APITestRegression{MaxLatencyP99Increase: 0.2, MaxSuccessRatioDecrease: 0.01}
 
 	if err := b.CheckValidation(); err == nil || err.Error() != "scheduled action is not defined: fake_action" {
 		t.Errorf("validation failed: scheduled action existence")
@@ -407,6 +464,36 @@ func TestCheckValidationStack(t *testing.T) {
 	if err := b.CheckValidation(); err == nil || err.Error() != "notification_target_arn is needed if role_arn is not empty : test" {
 		t.Errorf("validation failed: lifecycle hook role")
 	}
+
+	b.Stacks[0].LifecycleHooks = &schemas.LifecycleHooks{
+		LaunchTransition: []schemas.LifecycleHookSpecification{
+			{
+				LifecycleHookName: "test",
+				HeartbeatTimeout:  300,
+				Validation:        &schemas.InstanceCheck{Type: "http"},
+			},
+		},
+	}
+	if err := b.CheckValidation(); err == nil || err.Error() != "port is needed for http check : test" {
+		t.Errorf("validation failed: lifecycle hook validation without port")
+	}
+
+	b.Stacks[0].LifecycleHooks.LaunchTransition[0].Validation = &schemas.InstanceCheck{Type: "icmp"}
+	if err := b.CheckValidation(); err == nil || err.Error() != "type of check should be one of http, tcp or ssm-command : icmp : test" {
+		t.Errorf("validation failed: lifecycle hook validation type")
+	}
+
+	b.Stacks[0].LifecycleHooks.LaunchTransition[0].Validation = nil
+	b.Stacks[0].LifecycleHooks.TerminateTransition = []schemas.LifecycleHookSpecification{
+		{
+			LifecycleHookName: "drain",
+			HeartbeatTimeout:  300,
+			Validation:        &schemas.InstanceCheck{Type: "ssm-command", Commands: []string{"service hello stop"}},
+		},
+	}
+	if err := b.CheckValidation(); err == nil || err.Error() != "validation is only supported in launch_transition : drain" {
+		t.Errorf("validation failed: lifecycle hook validation in terminate transition")
+	}
 	b.Stacks[0].LifecycleHooks = nil
 
 	b.Stacks[0].Regions = []schemas.RegionConfig{
@@ -450,6 +537,24 @@ func TestCheckValidationStack(t *testing.T) {
 	b.Stacks[0].Regions[0].HealthcheckLB = ""
 	b.Stacks[0].Regions[0].LoadBalancers = nil
 
+	b.Stacks[0].Regions[0].Healthcheck = &schemas.InstanceCheck{Type: "tcp", Port: 8080}
+	if err := b.CheckValidation(); err == nil || err.Error() != "you cannot use healthcheck with healthcheck_target_group or healthcheck_load_balancer" {
+		t.Errorf("validation failed: mixing healthcheck and healthcheck target group")
+	}
+	b.Stacks[0].Regions[0].HealthcheckTargetGroup = ""
+
+	b.Stacks[0].Regions[0].Healthcheck = &schemas.InstanceCheck{Type: "tcp", Port: 8080, ExpectedStatus: 200}
+	if err := b.CheckValidation(); err == nil || err.Error() != "expected_status is only allowed as a valid status code of http check : 200 : ap-northeast-2" {
+		t.Errorf("validation failed: expected status of tcp check")
+	}
+
+	b.Stacks[0].Regions[0].Healthcheck = &schemas.InstanceCheck{Type: "ssm-command"}
+	if err := b.CheckValidation(); err == nil || err.Error() != "commands are needed for ssm-command check : ap-northeast-2" {
+		t.Errorf("validation failed: ssm-command check without commands")
+	}
+	b.Stacks[0].Regions[0].Healthcheck = nil
+	b.Stacks[0].Regions[0].HealthcheckTargetGroup = "test-tg"
+
 	b.Stacks[0].Userdata = schemas.Userdata{
 		Type: "local",
 		Path: "script/cannotfindpath.yaml",
@@ -485,11 +590,508 @@ func TestCheckValidationStack(t *testing.T) {
 	}
 	b.Stacks[0].APITestTemplate = "api-test"
 
+	b.Stacks[0].Regions[0].APITestTargetGroup = "hello-test"
+	if err := b.CheckValidation(); err == nil || err.Error() != fmt.Sprintf("api_test_target_group is only used with api_test_before_cleanup: %s", b.Stacks[0].Regions[0].Region) {
+		t.Errorf("validation failed: api test target group without api_test_before_cleanup")
+	}
+
+	b.Stacks[0].APITestBeforeCleanup = true
+	b.Stacks[0].Regions[0].APITestTargetGroup = b.Stacks[0].Regions[0].HealthcheckTargetGroup
+	if err := b.CheckValidation(); err == nil || err.Error() != fmt.Sprintf("api_test_target_group should not be one of target_groups: %s", b.Stacks[0].Regions[0].HealthcheckTargetGroup) {
+		t.Errorf("validation failed: api test target group in target groups")
+	}
+	b.Stacks[0].Regions[0].APITestTargetGroup = "hello-test"
+
+	if err := b.CheckValidation(); err != nil {
+		t.Errorf("validation failed: no error")
+	}
+}
+
+func TestCheckValidationCanary(t *testing.T) {
+	b := Builder{
+		Config: schemas.Config{
+			Manifest:        "config/hello.yaml",
+			Timeout:         constants.DefaultDeploymentTimeout,
+			PollingInterval: constants.DefaultPollingInterval,
+			DisableMetrics:  true,
+		},
+		Stacks: []schemas.Stack{
+			{
+				Stack:           "artd",
+				Account:         "dev",
+				Env:             "dev",
+				ReplacementType: "Rainbow",
+				Regions: []schemas.RegionConfig{
+					{
+						Region:                 "ap-northeast-2",
+						AmiID:                  "ami-test",
+						InstanceType:           "t3.small",
+						HealthcheckTargetGroup: "artd-dev-apne2",
+					},
+				},
+			},
+		},
+	}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "no valid replacement type : Rainbow" {
+		t.Errorf("validation failed: replacement type")
+	}
+	b.Stacks[0].ReplacementType = constants.CanaryDeployment
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "you have to set canary configuration with Canary replacement type : artd" {
+		t.Errorf("validation failed: no canary configuration")
+	}
+	b.Stacks[0].Canary = &schemas.CanaryConfig{}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "capacity_percentage of canary should be between 1 and 100" {
+		t.Errorf("validation failed: canary capacity percentage")
+	}
+	b.Stacks[0].Canary.CapacityPercentage = 10
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "you have to set at least one traffic step for canary" {
+		t.Errorf("validation failed: no traffic steps")
+	}
+	b.Stacks[0].Canary.TrafficSteps = []int64{10, 120}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "traffic step of canary should be between 1 and 100 : 120" {
+		t.Errorf("validation failed: traffic step range")
+	}
+	b.Stacks[0].Canary.TrafficSteps = []int64{50, 10, 100}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "traffic steps of canary should be in ascending order" {
+		t.Errorf("validation failed: traffic step order")
+	}
+	b.Stacks[0].Canary.TrafficSteps = []int64{10, 50, 100}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "canary_target_group is required for canary deployment : ap-northeast-2" {
+		t.Errorf("validation failed: no canary target group")
+	}
+	b.Stacks[0].Regions[0].CanaryTargetGroup = "artd-dev-apne2"
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "listener_arn or listener_rule_arn is required for canary deployment : ap-northeast-2" {
+		t.Errorf("validation failed: no listener")
+	}
+	b.Stacks[0].Regions[0].ListenerArn = "arn:aws:elasticloadbalancing:ap-northeast-2:123456789012:listener/app/artd/50dc6c495c0c9188/f2f7dc8efc522ab2"
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "canary_target_group cannot be used as healthcheck_target_group or in target_groups : ap-northeast-2" {
+		t.Errorf("validation failed: duplicated canary target group")
+	}
+	b.Stacks[0].Regions[0].CanaryTargetGroup = "artd-dev-apne2-canary"
+
+	if err := b.CheckValidation(); err != nil {
+		t.Errorf("validation failed: no error")
+	}
+
+	b.Stacks[0].ReplacementType = constants.RollingDeployment
+	b.Stacks[0].Rolling = &schemas.RollingConfig{MinHealthyPercentage: 110}
+	if err := b.CheckValidation(); err == nil || err.Error() != "min_healthy_percentage of rolling should be between 0 and 100" {
+		t.Errorf("validation failed: rolling min healthy percentage")
+	}
+	b.Stacks[0].Rolling.MinHealthyPercentage = 50
+
+	b.Stacks[0].Rolling.InstanceWarmup = -1
+	if err := b.CheckValidation(); err == nil || err.Error() != "instance_warmup of rolling cannot be negative" {
+		t.Errorf("validation failed: rolling instance warmup")
+	}
+	b.Stacks[0].Rolling.InstanceWarmup = 300
+
+	b.Stacks[0].Rolling.UnhealthyTimeout = -1 * time.Minute
+	if err := b.CheckValidation(); err == nil || err.Error() != "unhealthy_timeout of rolling cannot be negative" {
+		t.Errorf("validation failed: rolling unhealthy timeout")
+	}
+	b.Stacks[0].Rolling.UnhealthyTimeout = 5 * time.Minute
+
+	b.Stacks[0].RetainPreviousVersions = 2
+	if err := b.CheckValidation(); err == nil || err.Error() != "retain_previous_versions is not supported with rolling deployment" {
+		t.Errorf("validation failed: retain previous versions with rolling")
+	}
+	b.Stacks[0].ReplacementType = constants.BlueGreenDeployment
+
+	b.Stacks[0].RetainPreviousVersions = -1
+	if err := b.CheckValidation(); err == nil || err.Error() != "retain_previous_versions cannot be negative" {
+		t.Errorf("validation failed: negative retain previous versions")
+	}
+	b.Stacks[0].RetainPreviousVersions = 2
+
 	if err := b.CheckValidation(); err != nil {
 		t.Errorf("validation failed: no error")
 	}
 }
 
+func TestCheckValidationTrafficSwitch(t *testing.T) {
+	b := Builder{
+		Config: schemas.Config{
+			Manifest:        "config/hello.yaml",
+			Timeout:         constants.DefaultDeploymentTimeout,
+			PollingInterval: constants.DefaultPollingInterval,
+			DisableMetrics:  true,
+		},
+		Stacks: []schemas.Stack{
+			{
+				Stack:           "artd",
+				Account:         "dev",
+				Env:             "dev",
+				ReplacementType: constants.CanaryDeployment,
+				TrafficSwitch:   "dns",
+				Regions: []schemas.RegionConfig{
+					{
+						Region:                 "ap-northeast-2",
+						AmiID:                  "ami-test",
+						InstanceType:           "t3.small",
+						HealthcheckTargetGroup: "artd-dev-apne2",
+					},
+				},
+			},
+		},
+	}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "no valid traffic switch : dns" {
+		t.Errorf("validation failed: traffic switch")
+	}
+	b.Stacks[0].TrafficSwitch = constants.ListenerTrafficSwitch
+	b.Stacks[0].Canary = &schemas.CanaryConfig{CapacityPercentage: 10, TrafficSteps: []int64{10, 100}}
+	b.Stacks[0].Regions[0].CanaryTargetGroup = "artd-dev-apne2-canary"
+	b.Stacks[0].Regions[0].ListenerArn = "arn:aws:elasticloadbalancing:ap-northeast-2:123456789012:listener/app/artd/50dc6c495c0c9188/f2f7dc8efc522ab2"
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "traffic_switch is only available with BlueGreen replacement type : artd" {
+		t.Errorf("validation failed: traffic switch with canary")
+	}
+	b.Stacks[0].ReplacementType = constants.BlueGreenDeployment
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "blue_target_group and green_target_group are required for listener traffic switch : ap-northeast-2" {
+		t.Errorf("validation failed: no blue and green target group")
+	}
+	b.Stacks[0].Regions[0].BlueTargetGroup = "artd-dev-apne2-blue"
+	b.Stacks[0].Regions[0].GreenTargetGroup = "artd-dev-apne2-blue"
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "blue_target_group and green_target_group should be different : ap-northeast-2" {
+		t.Errorf("validation failed: same blue and green target group")
+	}
+	b.Stacks[0].Regions[0].GreenTargetGroup = "artd-dev-apne2-green"
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "healthcheck_target_group and healthcheck_load_balancer cannot be used with listener traffic switch : ap-northeast-2" {
+		t.Errorf("validation failed: healthcheck target group with listener traffic switch")
+	}
+	b.Stacks[0].Regions[0].HealthcheckTargetGroup = ""
+	b.Stacks[0].Regions[0].TargetGroups = []string{"artd-dev-apne2-internal", "artd-dev-apne2-green"}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "blue_target_group and green_target_group cannot be used in target_groups : ap-northeast-2" {
+		t.Errorf("validation failed: blue or green target group in target groups")
+	}
+	b.Stacks[0].Regions[0].TargetGroups = []string{"artd-dev-apne2-internal"}
+	b.Stacks[0].Regions[0].ListenerArn = ""
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "listener_arn or listener_rule_arn is required for listener traffic switch : ap-northeast-2" {
+		t.Errorf("validation failed: no listener")
+	}
+	b.Stacks[0].Regions[0].ListenerRuleArn = "arn:aws:elasticloadbalancing:ap-northeast-2:123456789012:listener-rule/app/artd/50dc6c495c0c9188/f2f7dc8efc522ab2/9683b2d02a6cabee"
+
+	if err := b.CheckValidation(); err != nil {
+		t.Errorf("validation failed: %s", err.Error())
+	}
+}
+
+func TestCheckValidationWarmup(t *testing.T) {
+	b := Builder{
+		Config: schemas.Config{
+			Manifest:        "config/hello.yaml",
+			Timeout:         constants.DefaultDeploymentTimeout,
+			PollingInterval: constants.DefaultPollingInterval,
+			DisableMetrics:  true,
+		},
+		Stacks: []schemas.Stack{
+			{
+				Stack:           "artd",
+				Account:         "dev",
+				Env:             "dev",
+				ReplacementType: constants.RollingDeployment,
+				Warmup:          &schemas.WarmupConfig{},
+				Regions: []schemas.RegionConfig{
+					{
+						Region:                 "ap-northeast-2",
+						AmiID:                  "ami-test",
+						InstanceType:           "t3.small",
+						HealthcheckTargetGroup: "artd-dev-apne2",
+					},
+				},
+			},
+		},
+	}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "warmup is only available with BlueGreen replacement type : artd" {
+		t.Errorf("validation failed: warmup with rolling")
+	}
+	b.Stacks[0].ReplacementType = constants.BlueGreenDeployment
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "healthcheck of warmup or launch_transition lifecycle hook is required for warmup : artd" {
+		t.Errorf("validation failed: no instance check")
+	}
+	b.Stacks[0].LifecycleHooks = &schemas.LifecycleHooks{
+		TerminateTransition: []schemas.LifecycleHookSpecification{
+			{LifecycleHookName: "terminate", HeartbeatTimeout: 300},
+		},
+	}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "healthcheck of warmup or launch_transition lifecycle hook is required for warmup : artd" {
+		t.Errorf("validation failed: only terminate lifecycle hook")
+	}
+	b.Stacks[0].LifecycleHooks.LaunchTransition = []schemas.LifecycleHookSpecification{
+		{LifecycleHookName: "launch", HeartbeatTimeout: 300},
+	}
+
+	if err := b.CheckValidation(); err != nil {
+		t.Errorf("validation failed: %s", err.Error())
+	}
+	b.Stacks[0].LifecycleHooks.LaunchTransition[0].Validation = &schemas.InstanceCheck{Type: constants.TCPCheck, Port: 8080}
+
+	if err := b.CheckValidation(); err != nil {
+		t.Errorf("validation failed: %s", err.Error())
+	}
+	b.Stacks[0].LifecycleHooks = nil
+	b.Stacks[0].Warmup.Healthcheck = &schemas.InstanceCheck{Type: constants.HTTPCheck}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "port is needed for http check : warmup" {
+		t.Errorf("validation failed: warmup healthcheck")
+	}
+	b.Stacks[0].Warmup.Healthcheck.Port = 8080
+	b.Stacks[0].Warmup.Requests = []*schemas.WarmupRequest{{Method: "GET", Path: "/ping"}}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "port of warmup is needed for warmup requests" {
+		t.Errorf("validation failed: warmup port")
+	}
+	b.Stacks[0].Warmup.Port = 8080
+	b.Stacks[0].Warmup.Requests[0].Method = "TRACE"
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "method of warmup request is not allowed : TRACE" {
+		t.Errorf("validation failed: warmup method")
+	}
+	b.Stacks[0].Warmup.Requests[0].Method = "get"
+	b.Stacks[0].Warmup.Duration = -1
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "duration of warmup cannot be negative" {
+		t.Errorf("validation failed: warmup duration")
+	}
+	b.Stacks[0].Warmup.Duration = 0
+	b.Stacks[0].Warmup.MinSuccessRatio = 1.5
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "min_success_ratio of warmup should be between 0 and 1" {
+		t.Errorf("validation failed: warmup min success ratio")
+	}
+	b.Stacks[0].Warmup.MinSuccessRatio = 0.95
+
+	if err := b.CheckValidation(); err != nil {
+		t.Errorf("validation failed: %s", err.Error())
+	}
+}
+
+func TestCheckValidationDrain(t *testing.T) {
+	b := Builder{
+		Config: schemas.Config{
+			Manifest:        "config/hello.yaml",
+			Timeout:         constants.DefaultDeploymentTimeout,
+			PollingInterval: constants.DefaultPollingInterval,
+			DisableMetrics:  true,
+		},
+		Stacks: []schemas.Stack{
+			{
+				Stack:           "artd",
+				Account:         "dev",
+				Env:             "dev",
+				ReplacementType: constants.RollingDeployment,
+				Drain:           &schemas.DrainConfig{},
+				Regions: []schemas.RegionConfig{
+					{
+						Region:                 "ap-northeast-2",
+						AmiID:                  "ami-test",
+						InstanceType:           "t3.small",
+						HealthcheckTargetGroup: "artd-dev-apne2",
+					},
+				},
+			},
+		},
+	}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "drain is not supported with rolling deployment" {
+		t.Errorf("validation failed: drain with rolling")
+	}
+	b.Stacks[0].ReplacementType = constants.BlueGreenDeployment
+	b.Stacks[0].Drain.StepSize = -1
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "step_size of drain cannot be negative" {
+		t.Errorf("validation failed: negative step size")
+	}
+	b.Stacks[0].Drain.StepSize = 2
+
+	if err := b.CheckValidation(); err != nil {
+		t.Errorf("validation failed: %s", err.Error())
+	}
+}
+
+func TestCheckValidationRollout(t *testing.T) {
+	b := Builder{
+		Config: schemas.Config{
+			Manifest:        "config/hello.yaml",
+			Timeout:         constants.DefaultDeploymentTimeout,
+			PollingInterval: constants.DefaultPollingInterval,
+			DisableMetrics:  true,
+		},
+		Stacks: []schemas.Stack{
+			{
+				Stack:           "artd",
+				Account:         "dev",
+				Env:             "dev",
+				ReplacementType: constants.BlueGreenDeployment,
+				Rollout:         &schemas.RolloutConfig{},
+				Regions: []schemas.RegionConfig{
+					{
+						Region:       "us-east-1",
+						AmiID:        "ami-test",
+						InstanceType: "t3.small",
+					},
+					{
+						Region:       "ap-northeast-2",
+						AmiID:        "ami-test",
+						InstanceType: "t3.small",
+					},
+				},
+			},
+		},
+	}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "you have to set at least one wave for rollout : artd" {
+		t.Errorf("validation failed: no rollout wave")
+	}
+
+	b.Stacks[0].Rollout.Waves = [][]string{{"us-east-1"}, {}}
+	if err := b.CheckValidation(); err == nil || err.Error() != "rollout wave cannot be empty : artd" {
+		t.Errorf("validation failed: empty rollout wave")
+	}
+
+	b.Stacks[0].Rollout.Waves = [][]string{{"us-east-1"}, {"eu-west-1"}}
+	if err := b.CheckValidation(); err == nil || err.Error() != "region of rollout wave does not exist in the stack : eu-west-1" {
+		t.Errorf("validation failed: unknown region in rollout wave")
+	}
+
+	b.Stacks[0].Rollout.Waves = [][]string{{"us-east-1"}, {"us-east-1", "ap-northeast-2"}}
+	if err := b.CheckValidation(); err == nil || err.Error() != "region is duplicated in rollout waves : us-east-1" {
+		t.Errorf("validation failed: duplicated region in rollout waves")
+	}
+
+	b.Stacks[0].Rollout.Waves = [][]string{{"us-east-1"}}
+	if err := b.CheckValidation(); err == nil || err.Error() != "region is not in any rollout wave : ap-northeast-2" {
+		t.Errorf("validation failed: region not in rollout waves")
+	}
+
+	b.Stacks[0].Rollout.Waves = [][]string{{"us-east-1"}, {"ap-northeast-2"}}
+	b.Stacks[0].Rollout.BakeTime = -1
+	if err := b.CheckValidation(); err == nil || err.Error() != "bake_time of rollout cannot be negative" {
+		t.Errorf("validation failed: negative bake time")
+	}
+	b.Stacks[0].Rollout.BakeTime = 0
+
+	b.Stacks[0].ManualPromotion = true
+	if err := b.CheckValidation(); err == nil || err.Error() != "manual_promotion cannot be used with multiple rollout waves" {
+		t.Errorf("validation failed: manual promotion with rollout waves")
+	}
+	b.Stacks[0].ManualPromotion = false
+
+	if err := b.CheckValidation(); err != nil {
+		t.Errorf("validation failed: no error: %s", err)
+	}
+}
+
+func TestCheckValidationBake(t *testing.T) {
+	b := Builder{
+		Config: schemas.Config{
+			Manifest:        "config/hello.yaml",
+			Timeout:         constants.DefaultDeploymentTimeout,
+			PollingInterval: constants.DefaultPollingInterval,
+			DisableMetrics:  true,
+		},
+		Stacks: []schemas.Stack{
+			{
+				Stack:           "artd",
+				Account:         "dev",
+				Env:             "dev",
+				ReplacementType: constants.BlueGreenDeployment,
+				Bake:            &schemas.BakeConfig{},
+				Regions: []schemas.RegionConfig{
+					{
+						Region:       "ap-northeast-2",
+						AmiID:        "ami-test",
+						InstanceType: "t3.small",
+					},
+				},
+			},
+		},
+	}
+
+	if err := b.CheckValidation(); err == nil || err.Error() != "duration of bake should be positive : artd" {
+		t.Errorf("validation failed: bake without duration")
+	}
+
+	b.Stacks[0].Bake.Duration = 10 * time.Minute
+	if err := b.CheckValidation(); err == nil || err.Error() != "you have to specify at least one alarm or metric for bake : artd" {
+		t.Errorf("validation failed: bake without alarms")
+	}
+
+	b.Stacks[0].Bake.Metrics = []schemas.BakeMetric{
+		{
+			Name:       "5xx",
+			Namespace:  "AWS/ApplicationELB",
+			Metric:     "HTTPCode_Target_5XX_Count",
+			Statistic:  "Sum",
+			Comparison: "GreaterThan",
+			Threshold:  10,
+		},
+	}
+	if err := b.CheckValidation(); err == nil || err.Error() != "comparison of bake metric should be one of GreaterThanThreshold, GreaterThanOrEqualToThreshold, LessThanThreshold, LessThanOrEqualToThreshold : 5xx : artd" {
+		t.Errorf("validation failed: bake metric comparison")
+	}
+
+	b.Stacks[0].Bake.Metrics[0].Comparison = "GreaterThanThreshold"
+	b.Stacks[0].Bake.Metrics[0].Period = 30
+	if err := b.CheckValidation(); err == nil || err.Error() != "period of bake metric should be a multiple of 60 : 5xx : artd" {
+		t.Errorf("validation failed: bake metric period")
+	}
+
+	b.Stacks[0].Bake.Metrics[0].Period = 60
+	b.Stacks[0].Bake.Alarms = []string{"hello-latency"}
+	if err := b.CheckValidation(); err != nil {
+		t.Errorf("validation failed: no error: %s", err)
+	}
+}
+
+func TestSetStacks(t *testing.T) {
+	b := Builder{
+		Config: schemas.Config{
+			AssumeRole:        "arn:aws:iam::123456789012:role/deploy",
+			PollingInterval:   30 * time.Second,
+			RollbackOnFailure: true,
+			ManualPromotion:   true,
+		},
+	}
+
+	b = b.SetStacks([]schemas.Stack{{Stack: "artd"}, {Stack: "artp", RollbackOnFailure: true}})
+	for _, stack := range b.Stacks {
+		if stack.AssumeRole != b.Config.AssumeRole {
+			t.Errorf("assume role is not applied: %s", stack.Stack)
+		}
+
+		if stack.PollingInterval != b.Config.PollingInterval {
+			t.Errorf("polling interval is not applied: %s", stack.Stack)
+		}
+
+		if !stack.RollbackOnFailure {
+			t.Errorf("rollback on failure is not applied: %s", stack.Stack)
+		}
+
+		if !stack.ManualPromotion {
+			t.Errorf("manual promotion is not applied: %s", stack.Stack)
+		}
+	}
+}
+
 func TestRefineConfig(t *testing.T) {
 	type TestData struct {
 		input  schemas.Config
@@ -714,3 +1316,37 @@ func TestValidCronExpression(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckStackDependencies(t *testing.T) {
+	stacks := []schemas.Stack{
+		{Stack: "api"},
+		{Stack: "worker", DependsOn: []string{"api"}},
+		{Stack: "batch", DependsOn: []string{"api", "worker"}},
+	}
+
+	if err := checkStackDependencies(stacks); err != nil {
+		t.Errorf("validation failed: no error: %s", err)
+	}
+
+	stacks[0].ManualPromotion = true
+	if err := checkStackDependencies(stacks); err == nil || err.Error() != "manual_promotion cannot be used for the stack which other stacks depend on : api" {
+		t.Errorf("validation failed: manual promotion of upstream stack")
+	}
+	stacks[0].ManualPromotion = false
+
+	stacks[1].DependsOn = []string{"web"}
+	if err := checkStackDependencies(stacks); err == nil || err.Error() != "stack in depends_on does not exist : web" {
+		t.Errorf("validation failed: unknown stack in depends_on")
+	}
+
+	stacks[0].DependsOn = []string{"batch"}
+	stacks[1].DependsOn = []string{"api"}
+	if err := checkStackDependencies(stacks); err == nil || err.Error() != "circular dependency exists between stacks : api -> batch -> api" {
+		t.Errorf("validation failed: circular dependency: %v", err)
+	}
+
+	stacks[0].DependsOn = []string{"api"}
+	if err := checkStackDependencies(stacks); err == nil || err.Error() != "circular dependency exists between stacks : api -> api" {
+		t.Errorf("validation failed: self dependency: %v", err)
+	}
+}
@@ -48,6 +48,17 @@ type TargetMetrics struct {
 	MappingFunction func(*HelperStruct, *Logger.Logger, aws.MetricClient, string) (map[string]interface{}, error)
 }
 
+// DeploymentRecord is a deployment information stored by StampDeployment
+type DeploymentRecord struct {
+	AutoScalingGroup string
+	Status           string
+	Stack            schemas.Stack
+	Config           schemas.Config
+	Userdata         string
+	APITestSummary   *schemas.APITestSummary
+	AppliedCapacity  *schemas.Capacity
+}
+
 type HelperStruct struct {
 	BaseTimeDuration float64
 	StartDate        time.Time
@@ -149,6 +160,58 @@ func (c Collector) StampDeployment(stack schemas.Stack, config schemas.Config, t
 	return err
 }
 
+// GetDeploymentRecord retrieves deployment information of autoscaling group from storage
+func (c Collector) GetDeploymentRecord(asg string) (*DeploymentRecord, error) {
+	item, err := c.MetricClient.DynamoDBService.GetSingleItem(asg, c.MetricConfig.Storage.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(item) == 0 {
+		return nil, nil
+	}
+
+	record := DeploymentRecord{
+		AutoScalingGroup: asg,
+	}
+
+	if v, ok := item["deployment_status"]; ok && v.S != nil {
+		record.Status = *v.S
+	}
+
+	if v, ok := item["userdata"]; ok && v.S != nil {
+		record.Userdata = *v.S
+	}
+
+	if v, ok := item["stack"]; ok && v.S != nil {
+		if err := json.Unmarshal([]byte(*v.S), &record.Stack); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := item["config"]; ok && v.S != nil {
+		if err := json.Unmarshal([]byte(*v.S), &record.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := item[constants.AppliedCapacityKey]; ok && v.S != nil {
+		record.AppliedCapacity = &schemas.Capacity{}
+		if err := json.Unmarshal([]byte(*v.S), record.AppliedCapacity); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := item[constants.APITestSummaryKey]; ok && v.S != nil {
+		record.APITestSummary = &schemas.APITestSummary{}
+		if err := json.Unmarshal([]byte(*v.S), record.APITestSummary); err != nil {
+			return nil, err
+		}
+	}
+
+	return &record, nil
+}
+
 // UpdateStatus updates status of deployment on the table
 func (c Collector) UpdateStatus(asg string, status string, updateFields map[string]interface{}) error {
 	Logger.Debugf("deployment statuses of previous autoscaling groups are started")
@@ -160,6 +223,16 @@ func (c Collector) UpdateStatus(asg string, status string, updateFields map[stri
 	return nil
 }
 
+// UpdateAPITestSummary stores summary of API test with the deployment record
+func (c Collector) UpdateAPITestSummary(asg string, summary schemas.APITestSummary) error {
+	b, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	return c.MetricClient.DynamoDBService.UpdateAttribute(asg, constants.APITestSummaryKey, string(b), c.MetricConfig.Storage.Name)
+}
+
 // UpdateStatistics update value of metric table
 func (c Collector) UpdateStatistics(asg string, updateFields map[string]interface{}) error {
 	if err := c.MetricClient.DynamoDBService.UpdateStatistics(asg, c.MetricConfig.Storage.Name, c.MetricConfig.Metrics.BaseTimezone, updateFields); err != nil {
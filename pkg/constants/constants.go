@@ -66,6 +66,27 @@ const (
 	// MinPollingInterval is minimum polling interval
 	MinPollingInterval = 5 * time.Second
 
+	// DefaultLockLease is default lease of deployment lock which is renewed while goployer is running
+	DefaultLockLease = 5 * time.Minute
+
+	// MinLockLease is minimum lease of deployment lock
+	MinLockLease = 1 * time.Minute
+
+	// DefaultCallbackTimeout is default time to wait for a lifecycle callback to finish
+	DefaultCallbackTimeout = 10 * time.Minute
+
+	// CommandPollingInterval is polling interval for results of lifecycle callbacks
+	CommandPollingInterval = 5 * time.Second
+
+	// DefaultInstanceCheckTimeout is default time to wait for an instance to pass the check
+	DefaultInstanceCheckTimeout = 5 * time.Minute
+
+	// InstanceCheckInterval is interval between retries of http check
+	InstanceCheckInterval = 10 * time.Second
+
+	// CleanupTimeout is timeout for deleting resources after deployment is cancelled
+	CleanupTimeout = 5 * time.Minute
+
 	// MetricYamlPath is the path of metric manifest file
 	MetricYamlPath = "metrics.yaml"
 
@@ -84,6 +105,15 @@ const (
 	// S3Prefix is prefix of s3 URL
 	S3Prefix = "s3://"
 
+	// DynamoDBPrefix is prefix of dynamodb table location for state store
+	DynamoDBPrefix = "dynamodb://"
+
+	// DeploymentStateKeyPrefix is prefix of keys of deployment states in s3 or dynamodb
+	DeploymentStateKeyPrefix = "goployer-deployments/"
+
+	// DeploymentLockKeyPrefix is prefix of keys of deployment locks in dynamodb
+	DeploymentLockKeyPrefix = "goployer-locks/"
+
 	// HashKey is the default value of hash key for metric table
 	HashKey = "identifier"
 
@@ -120,6 +150,15 @@ const (
 	// StepCleanPreviousVersion = CleanPreviousVersion
 	StepCleanPreviousVersion = int64(5)
 
+	// StepPostDeployCallback = TriggerPostDeployCallbacks
+	StepPostDeployCallback = int64(6)
+
+	// StepBake = Bake
+	StepBake = int64(7)
+
+	// StepAPITest = RunPreCleanupAPITest
+	StepAPITest = int64(8)
+
 	// DefaultEnableStats is whether or not to enable gathering stats
 	DefaultEnableStats = true
 
@@ -137,6 +176,105 @@ const (
 
 	// MinAPITestDuration is minimum duration of API test
 	MinAPITestDuration = 1 * time.Second
+
+	// DefaultAPIStepTimeout is default time to wait for the response of scenario step
+	DefaultAPIStepTimeout = 10 * time.Second
+
+	// DefaultWarmupRequestPerSecond is default rate of warmup requests to each instance
+	DefaultWarmupRequestPerSecond = 10
+
+	// DefaultWarmupDuration is default duration of warmup requests
+	DefaultWarmupDuration = 30 * time.Second
+
+	// DefaultWarmupMinSuccessRatio is default minimum ratio of successful warmup requests to each instance
+	DefaultWarmupMinSuccessRatio = 0.9
+
+	// DefaultDrainStepInterval is default waiting time after capacity of previous version is stepped down
+	DefaultDrainStepInterval = 30 * time.Second
+
+	// DeregistrationDelayAttribute is the attribute key of deregistration delay of target group
+	DeregistrationDelayAttribute = "deregistration_delay.timeout_seconds"
+
+	// APITestSummaryKey is the attribute of deployment record where summary of API test is stored
+	APITestSummaryKey = "api_test_summary"
+
+	// AppliedCapacityKey is the attribute of deployment record where capacity applied to the new version is stored
+	AppliedCapacityKey = "applied_capacity"
+
+	// DefaultRegressionLatencyIncrease is default tolerance of p99 latency increase against the previous release
+	DefaultRegressionLatencyIncrease = 0.2
+
+	// DefaultRegressionSuccessDecrease is default tolerance of success ratio decrease against the previous release
+	DefaultRegressionSuccessDecrease = 0.01
+
+	// BlueGreenDeployment is a replacement type of blue/green deployment
+	BlueGreenDeployment = "BlueGreen"
+
+	// CanaryDeployment is a replacement type of canary deployment
+	CanaryDeployment = "Canary"
+
+	// RollingDeployment is a replacement type of rolling deployment
+	RollingDeployment = "Rolling"
+
+	// ListenerTrafficSwitch switches traffic by changing forward action of listener between blue and green target group
+	ListenerTrafficSwitch = "listener"
+
+	// DefaultBakeMetricPeriod is default period of metric which is watched during bake
+	DefaultBakeMetricPeriod = int64(60)
+
+	// HTTPCheck is a type of instance check with http request
+	HTTPCheck = "http"
+
+	// TCPCheck is a type of instance check with tcp connection
+	TCPCheck = "tcp"
+
+	// SSMCheck is a type of instance check with ssm command
+	SSMCheck = "ssm-command"
+
+	// LifecycleActionContinue is a result of lifecycle action to put instance in service
+	LifecycleActionContinue = "CONTINUE"
+
+	// LifecycleActionAbandon is a result of lifecycle action to terminate instance
+	LifecycleActionAbandon = "ABANDON"
+
+	// DefaultMinHealthyPercentage is default minimum percentage of healthy instances during rolling deployment
+	DefaultMinHealthyPercentage = int64(90)
+
+	// DefaultUnhealthyTimeout is default time to wait for healthy instances to meet minimum healthy percentage during rolling deployment
+	DefaultUnhealthyTimeout = 10 * time.Minute
+
+	// DefaultRollbackRetry is the number of polling to wait for cancellation of instance refresh
+	DefaultRollbackRetry = 10
+
+	// MaxRollbackCandidates is the number of previous versions to look for when rolling back
+	MaxRollbackCandidates = 20
+
+	// DefaultCanaryBakeTime is default waiting time between canary traffic steps
+	DefaultCanaryBakeTime = 5 * time.Minute
+
+	// PausedStatus is the status of deployment waiting for manual promotion
+	PausedStatus = "paused"
+
+	// RunningStatus is the status of deployment in progress
+	RunningStatus = "running"
+
+	// FailedStatus is the status of deployment which failed and cannot be resumed
+	FailedStatus = "failed"
+
+	// LocalStateStore is the type of state store saving states as local files
+	LocalStateStore = "local"
+
+	// S3StateStore is the type of state store saving states in s3 bucket
+	S3StateStore = "s3"
+
+	// DynamoDBStateStore is the type of state store saving states in dynamodb table
+	DynamoDBStateStore = "dynamodb"
+
+	// DefaultPlanOutput is default output format of deployment plan
+	DefaultPlanOutput = "table"
+
+	// StandbyTagKey is the tag key of previous autoscaling groups retained as warm standby
+	StandbyTagKey = "goployer-standby"
 )
 
 var (
@@ -156,27 +294,49 @@ var (
 	// AWSConfigPath is the file path of aws config
 	AWSConfigPath = HomeDir() + "/.aws/config"
 
+	// DeploymentStatePath is the directory of deployment states in local state store
+	DeploymentStatePath = HomeDir() + "/.goployer/deployments"
+
+	// AvailablePlanOutputs is a list of output formats of deployment plan
+	AvailablePlanOutputs = []string{"table", "json"}
+
 	// AvailableBlockTypes is a list of available ebs block types
 	AvailableBlockTypes = []string{"io1", "io2", "gp2", "st1", "sc1"}
 
 	// IopsRequiredBlockType is a list of ebs type which requires iops
 	IopsRequiredBlockType = []string{"io1", "io2"}
 
+	// AvailableReplacementTypes is a list of available replacement types
+	AvailableReplacementTypes = []string{BlueGreenDeployment, CanaryDeployment, RollingDeployment}
+
+	// AvailableTrafficSwitches is a list of available traffic switch types
+	AvailableTrafficSwitches = []string{ListenerTrafficSwitch}
+
 	// AllowedRequestMethod is a list of request method
-	AllowedRequestMethod = []string{"GET", "POST", "PUT"}
+	AllowedRequestMethod = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
 
 	// TimeFields is a list of time.Time field
-	TimeFields = []string{"timeout", "polling-interval"}
+	TimeFields = []string{"timeout", "polling-interval", "lock-lease"}
 
 	// ProhibitedTags is a list of prohibited tags which are going to be attached by goployer
 	ProhibitedTags = []string{"Name", "stack"}
 
 	// StatusTimeStampKey is a map of timestamp keys with deployment status
 	StatusTimeStampKey = map[string]string{
-		"deployed":   "deployed_date",
-		"terminated": "terminated_date",
+		"deployed":         "deployed_date",
+		"rolling":          "rolling_date",
+		"terminated":       "terminated_date",
+		"rolled_back":      "rolled_back_date",
+		"standby":          "standby_date",
+		"fast_rolled_back": "fast_rolled_back_date",
 	}
 
+	// RollbackableStatus is a list of deployment status which can be a target of rollback
+	RollbackableStatus = []string{"deployed", "terminated", "standby"}
+
+	// StandbySuspendedProcesses is a list of scaling processes suspended while autoscaling group is in standby
+	StandbySuspendedProcesses = []string{"AlarmNotification", "ScheduledActions"}
+
 	// AllowedAnswerYes is a list of allowed answers with yes
 	AllowedAnswerYes = []string{"y", "yes"}
 
@@ -17,11 +17,13 @@ limitations under the license.
 package deployer
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go/service/autoscaling"
 	Logger "github.com/sirupsen/logrus"
 
 	"github.com/DevopsArtFactory/goployer/pkg/aws"
@@ -57,6 +59,8 @@ func NewBlueGrean(mode string, logger *Logger.Logger, awsConfig schemas.AWSConfi
 			PrevInstances:     map[string][]string{},
 			PrevInstanceCount: map[string]schemas.Capacity{},
 			PrevVersions:      map[string][]int{},
+			CompletedHooks:    map[string]bool{},
+			CheckSuccesses:    map[string]int64{},
 			Stack:             stack,
 			StepStatus: map[int64]bool{
 				constants.StepCheckPrevious:            false,
@@ -64,13 +68,18 @@ func NewBlueGrean(mode string, logger *Logger.Logger, awsConfig schemas.AWSConfi
 				constants.StepAdditionalWork:           false,
 				constants.StepTriggerLifecycleCallback: false,
 				constants.StepCleanPreviousVersion:     false,
+				constants.StepPostDeployCallback:       false,
+				constants.StepBake:                     false,
+				constants.StepAPITest:                  false,
 			},
+			ActiveTargetGroups: map[string]string{},
+			WarmupAttached:     map[string]bool{},
 		},
 	}
 }
 
 // Deploy function
-func (b BlueGreen) Deploy(config schemas.Config) error {
+func (b BlueGreen) Deploy(ctx context.Context, config schemas.Config) error {
 	if !b.StepStatus[constants.StepCheckPrevious] {
 		return nil
 	}
@@ -80,8 +89,6 @@ func (b BlueGreen) Deploy(config schemas.Config) error {
 	//Get LocalFileProvider
 	b.LocalProvider = builder.SetUserdataProvider(b.Stack.Userdata, b.AwsConfig.Userdata)
 
-	// Make Frigga
-	frigga := tool.Frigga{}
 	for _, region := range b.Stack.Regions {
 		//Region check
 		//If region id is passed from command line, then deployer will deploy in that region only.
@@ -96,150 +103,31 @@ func (b BlueGreen) Deploy(config schemas.Config) error {
 			return err
 		}
 
-		//Setup frigga with prefix
-		frigga.Prefix = tool.BuildPrefixName(b.AwsConfig.Name, b.Stack.Env, region.Region)
-
-		// Get Current Version
-		curVersion := getCurrentVersion(b.PrevVersions[region.Region])
-		b.Logger.Info("Current Version :", curVersion)
-
-		//Get AMI
-		var ami string
-		if len(config.Ami) > 0 {
-			ami = config.Ami
-		} else {
-			ami = region.AmiID
-		}
-
-		// Generate new name for autoscaling group and launch configuration
-		newAsgName := tool.GenerateAsgName(frigga.Prefix, curVersion)
-		launchTemplateName := tool.GenerateLcName(newAsgName)
-
-		userdata, err := (b.LocalProvider).Provide()
-		if err != nil {
-			return err
-		}
-
-		//Stack check
-		securityGroups, err := client.EC2Service.GetSecurityGroupList(region.VPC, region.SecurityGroups)
-		if err != nil {
-			return err
+		appliedCapacity := b.Deployer.GetAppliedCapacity(config.ForceManifestCapacity, region.Region)
+		if appliedCapacity != b.Deployer.GetManifestCapacity(region.Region) {
+			b.Logger.Infof("Current desired instance count is larger than the number of instances in manifest file")
 		}
-		blockDevices := client.EC2Service.MakeLaunchTemplateBlockDeviceMappings(b.Stack.BlockDevices)
-		ebsOptimized := b.Stack.EbsOptimized
-
-		// Instance Type Override
-		instanceType := region.InstanceType
-		if len(config.OverrideInstanceType) > 0 {
-			instanceType = config.OverrideInstanceType
+		b.Logger.Infof("Applied instance capacity - Min: %d, Desired: %d, Max: %d", appliedCapacity.Min, appliedCapacity.Desired, appliedCapacity.Max)
 
-			if b.Stack.MixedInstancesPolicy.Enabled {
-				Logger.Warnf("--override-instance-type won't be applied because mixed_instances_policy is enabled")
+		// With listener traffic switch, new version is attached to the idle target group which does not receive traffic
+		if IsListenerSwitch(b.Stack) {
+			region, err = b.Deployer.prepareListenerSwitch(ctx, client, region)
+			if err != nil {
+				return err
 			}
 		}
 
-		// LaunchTemplate
-		err = client.EC2Service.CreateNewLaunchTemplate(
-			launchTemplateName,
-			ami,
-			instanceType,
-			region.SSHKey,
-			b.Stack.IamInstanceProfile,
-			userdata,
-			ebsOptimized,
-			b.Stack.MixedInstancesPolicy.Enabled,
-			securityGroups,
-			blockDevices,
-			b.Stack.InstanceMarketOptions,
-			region.DetailedMonitoringEnabled,
-		)
-
-		if err != nil {
-			return errors.New("unknown error happened creating new launch template")
-		}
-
-		healthElb := region.HealthcheckLB
-		loadbalancers := region.LoadBalancers
-		if healthElb != "" && !tool.IsStringInArray(healthElb, loadbalancers) {
-			loadbalancers = append(loadbalancers, healthElb)
-		}
-
-		healthcheckTargetGroup := region.HealthcheckTargetGroup
-		targetGroups := region.TargetGroups
-		if healthcheckTargetGroup != "" && !tool.IsStringInArray(healthcheckTargetGroup, targetGroups) {
-			targetGroups = append(targetGroups, healthcheckTargetGroup)
-		}
-
-		terminationPolicies := []*string{}
-		usePublicSubnets := region.UsePublicSubnets
-		healthcheckType := constants.DefaultHealthcheckType
-		healthcheckGracePeriod := int64(constants.DefaultHealthcheckGracePeriod)
-		availabilityZones, err := client.EC2Service.GetAvailabilityZones(region.VPC, region.AvailabilityZones)
-		if err != nil {
-			return err
-		}
-		tags := client.EC2Service.GenerateTags(b.AwsConfig.Tags, newAsgName, b.AwsConfig.Name, config.Stack, b.Stack.AnsibleTags, b.Stack.Tags, config.ExtraTags, config.AnsibleExtraVars, region.Region)
-		subnets, err := client.EC2Service.GetSubnets(region.VPC, usePublicSubnets, availabilityZones)
-		if err != nil {
-			return err
-		}
-		targetGroupArns, err := client.ELBV2Service.GetTargetGroupARNs(targetGroups)
-		if err != nil {
-			return err
-		}
-
-		var appliedCapacity schemas.Capacity
-		if !config.ForceManifestCapacity && b.PrevInstanceCount[region.Region].Desired > b.Stack.Capacity.Desired {
-			appliedCapacity = b.PrevInstanceCount[region.Region]
-			b.Logger.Infof("Current desired instance count is larger than the number of instances in manifest file")
-		} else {
-			appliedCapacity = b.Stack.Capacity
+		// With warmup, load balancing targets are attached after instances are warmed up
+		loadbalancers, targetGroups := GetLoadBalancingTargets(region)
+		if IsWarmupEnabled(b.Stack) {
+			loadbalancers, targetGroups = nil, nil
 		}
 
-		b.Logger.Infof("Applied instance capacity - Min: %d, Desired: %d, Max: %d", appliedCapacity.Min, appliedCapacity.Desired, appliedCapacity.Max)
-
-		var lifecycleHooksSpecificationList []*autoscaling.LifecycleHookSpecification
-		if b.Stack.LifecycleHooks != nil {
-			lifecycleHooksSpecificationList = client.EC2Service.GenerateLifecycleHooks(*b.Stack.LifecycleHooks)
-		}
-
-		_, err = client.EC2Service.CreateAutoScalingGroup(
-			newAsgName,
-			launchTemplateName,
-			healthcheckType,
-			healthcheckGracePeriod,
-			appliedCapacity,
-			loadbalancers,
-			availabilityZones,
-			targetGroupArns,
-			terminationPolicies,
-			tags,
-			subnets,
-			b.Stack.MixedInstancesPolicy,
-			lifecycleHooksSpecificationList,
-		)
-
+		newAsgName, err := b.Deployer.CreateNewVersion(ctx, config, region, client, appliedCapacity, loadbalancers, targetGroups)
 		if err != nil {
 			return err
 		}
 
-		if b.Collector.MetricConfig.Enabled {
-			additionalFields := map[string]string{}
-			if len(config.ReleaseNotes) > 0 {
-				additionalFields["release-notes"] = config.ReleaseNotes
-			}
-
-			if len(config.ReleaseNotesBase64) > 0 {
-				additionalFields["release-notes-base64"] = config.ReleaseNotesBase64
-			}
-
-			if len(userdata) > 0 {
-				additionalFields["userdata"] = userdata
-			}
-
-			b.Collector.StampDeployment(b.Stack, config, tags, newAsgName, "creating", additionalFields)
-		}
-
 		b.AsgNames[region.Region] = newAsgName
 		b.Stack.Capacity.Desired = appliedCapacity.Desired
 	}
@@ -249,7 +137,7 @@ func (b BlueGreen) Deploy(config schemas.Config) error {
 }
 
 // Healthchecking
-func (b BlueGreen) HealthChecking(config schemas.Config) map[string]bool {
+func (b BlueGreen) HealthChecking(ctx context.Context, config schemas.Config) map[string]bool {
 	isUpdate := len(config.TargetAutoscalingGroup) > 0
 	stackName := b.GetStackName()
 	if !b.StepStatus[constants.StepDeploy] && !isUpdate {
@@ -292,12 +180,30 @@ func (b BlueGreen) HealthChecking(config schemas.Config) map[string]bool {
 		} else {
 			targetAsgName = b.AsgNames[region.Region]
 		}
-		asg, err := client.EC2Service.GetMatchingAutoscalingGroup(targetAsgName)
+		asg, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, targetAsgName)
 		if err != nil {
 			return map[string]bool{stackName: false, "error": true}
 		}
 
-		isHealthy, err := b.Deployer.polling(region, asg, client, config.ForceManifestCapacity, isUpdate, config.DownSizingUpdate)
+		// Health of new version is checked in the idle target group before listener is switched
+		active, isSwitching := b.ActiveTargetGroups[region.Region]
+		isSwitching = isSwitching && !isUpdate
+		if isSwitching {
+			region = GetListenerRegion(region, GetIdleTargetGroup(region, active))
+		}
+
+		threshold := b.Deployer.GetAppliedCapacity(config.ForceManifestCapacity, region.Region).Desired
+
+		// New version is attached to load balancing targets after warmup and then checked in target groups
+		if IsWarmupEnabled(b.Stack) && !isUpdate && !b.WarmupAttached[region.Region] {
+			if err := b.Deployer.warmupAndAttach(ctx, region, asg, client, threshold); err != nil {
+				b.Logger.Errorf(err.Error())
+				return map[string]bool{stackName: false, "error": true}
+			}
+			continue
+		}
+
+		isHealthy, err := b.Deployer.polling(ctx, region, asg, client, threshold, isUpdate, config.DownSizingUpdate)
 		if err != nil {
 			return map[string]bool{stackName: false, "error": true}
 		}
@@ -325,7 +231,7 @@ func (b BlueGreen) GetStackName() string {
 }
 
 // FinishAdditionalWork processes final work
-func (b BlueGreen) FinishAdditionalWork(config schemas.Config) error {
+func (b BlueGreen) FinishAdditionalWork(ctx context.Context, config schemas.Config) error {
 	if !b.StepStatus[constants.StepDeploy] {
 		return nil
 	}
@@ -344,21 +250,28 @@ func (b BlueGreen) FinishAdditionalWork(config schemas.Config) error {
 				continue
 			}
 
-			b.Logger.Info("Attaching autoscaling policies : " + region.Region)
-
 			//select client
 			client, err := selectClientFromList(b.AWSClients, region.Region)
 			if err != nil {
 				return err
 			}
 
+			// Listener is switched after new version passed all checks and promotion
+			if active, ok := b.ActiveTargetGroups[region.Region]; ok {
+				if err := b.Deployer.switchListener(ctx, client, region, GetIdleTargetGroup(region, active)); err != nil {
+					return err
+				}
+			}
+
+			b.Logger.Info("Attaching autoscaling policies : " + region.Region)
+
 			if len(b.Stack.Autoscaling) == 0 {
 				b.Logger.Debug("no scaling policy exists")
 			} else {
 				//putting autoscaling group policies
 				policyArns := map[string]string{}
 				for _, policy := range b.Stack.Autoscaling {
-					policyArn, err := client.EC2Service.CreateScalingPolicy(policy, b.AsgNames[region.Region])
+					policyArn, err := client.EC2Service.CreateScalingPolicy(ctx, policy, b.AsgNames[region.Region])
 					if err != nil {
 						return err
 					}
@@ -366,11 +279,11 @@ func (b BlueGreen) FinishAdditionalWork(config schemas.Config) error {
 					policyArns[policy.Name] = *policyArn
 				}
 
-				if err := client.EC2Service.EnableMetrics(b.AsgNames[region.Region]); err != nil {
+				if err := client.EC2Service.EnableMetrics(ctx, b.AsgNames[region.Region]); err != nil {
 					return err
 				}
 
-				if err := client.CloudWatchService.CreateScalingAlarms(b.AsgNames[region.Region], b.Stack.Alarms, policyArns); err != nil {
+				if err := client.CloudWatchService.CreateScalingAlarms(ctx, b.AsgNames[region.Region], b.Stack.Alarms, policyArns); err != nil {
 					return err
 				}
 			}
@@ -385,7 +298,7 @@ func (b BlueGreen) FinishAdditionalWork(config schemas.Config) error {
 				}
 
 				b.Logger.Debugf("selected actions [ %s ]", strings.Join(region.ScheduledActions, ","))
-				if err := client.EC2Service.CreateScheduledActions(b.AsgNames[region.Region], selectedActions); err != nil {
+				if err := client.EC2Service.CreateScheduledActions(ctx, b.AsgNames[region.Region], selectedActions); err != nil {
 					return err
 				}
 				b.Logger.Debugf("finished adding scheduled actions")
@@ -399,7 +312,7 @@ func (b BlueGreen) FinishAdditionalWork(config schemas.Config) error {
 }
 
 // TriggerLifecycleCallbacks runs lifecycle callbacks before cleaning.
-func (b BlueGreen) TriggerLifecycleCallbacks(config schemas.Config) error {
+func (b BlueGreen) TriggerLifecycleCallbacks(ctx context.Context, config schemas.Config) error {
 	if !b.StepStatus[constants.StepAdditionalWork] {
 		return nil
 	}
@@ -431,7 +344,14 @@ func (b BlueGreen) TriggerLifecycleCallbacks(config schemas.Config) error {
 			}
 
 			if len(b.PrevInstances[region.Region]) > 0 {
-				b.Deployer.RunLifecycleCallbacks(client, b.PrevInstances[region.Region])
+				if err := b.Deployer.RunLifecycleCallbacks(ctx, client, b.PrevInstances[region.Region]); err != nil {
+					if !b.Stack.LifecycleCallbacks.FailOnError {
+						b.Logger.Warnf("%s : %s", err.Error(), region.Region)
+						continue
+					}
+					b.Slack.SendSimpleMessage(fmt.Sprintf(":x: Previous versions are not terminated because lifecycle callback failed : %s", region.Region))
+					return fmt.Errorf("previous versions are not terminated because lifecycle callback failed in %s : %s", region.Region, err.Error())
+				}
 			} else {
 				b.Logger.Infof("No previous versions to be deleted : %s\n", region.Region)
 				b.Slack.SendSimpleMessage(fmt.Sprintf("No previous versions to be deleted : %s\n", region.Region))
@@ -442,8 +362,98 @@ func (b BlueGreen) TriggerLifecycleCallbacks(config schemas.Config) error {
 	return nil
 }
 
+// TriggerPostDeployCallbacks runs lifecycle callbacks on new versions which are healthy
+func (b BlueGreen) TriggerPostDeployCallbacks(ctx context.Context, config schemas.Config) error {
+	if !b.StepStatus[constants.StepDeploy] {
+		return nil
+	}
+
+	if !hasPostDeployCallbacks(b.Stack.LifecycleCallbacks) {
+		b.Logger.Debugf("no post deploy callbacks in %s", b.Stack.Stack)
+		b.StepStatus[constants.StepPostDeployCallback] = true
+		return nil
+	}
+
+	for _, region := range b.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			b.Logger.Debug("This region is skipped by user : " + region.Region)
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(b.AWSClients, region.Region)
+		if err != nil {
+			return err
+		}
+
+		if err := b.Deployer.RunPostDeployCallbacks(ctx, client, region.Region); err != nil {
+			if b.Stack.LifecycleCallbacks.AbortOnFailure {
+				b.Slack.SendSimpleMessage(fmt.Sprintf(":x: Post deploy callback failed : %s", err.Error()))
+				return err
+			}
+			b.Logger.Warnf("post deploy callback failed but deployment continues: %s", err.Error())
+		}
+	}
+
+	b.StepStatus[constants.StepPostDeployCallback] = true
+	return nil
+}
+
+// Bake watches alarms and metrics of new versions for the bake duration before cleaning previous versions
+func (b BlueGreen) Bake(ctx context.Context, config schemas.Config) error {
+	if !b.StepStatus[constants.StepDeploy] {
+		return nil
+	}
+
+	if b.Stack.Bake == nil {
+		b.StepStatus[constants.StepBake] = true
+		return nil
+	}
+
+	b.Logger.Infof("Bake new versions of %s for %s", b.Stack.Stack, b.Stack.Bake.Duration)
+	b.Slack.SendSimpleMessage(fmt.Sprintf("Bake new versions of %s for %s", b.Stack.Stack, b.Stack.Bake.Duration))
+
+	deadline := time.Now().Add(b.Stack.Bake.Duration)
+	for {
+		for _, region := range b.Stack.Regions {
+			if config.Region != "" && config.Region != region.Region {
+				b.Logger.Debug("This region is skipped by user : " + region.Region)
+				continue
+			}
+
+			//select client
+			client, err := selectClientFromList(b.AWSClients, region.Region)
+			if err != nil {
+				return err
+			}
+
+			if err := b.Deployer.CheckBake(ctx, client, region.Region); err != nil {
+				b.Slack.SendSimpleMessage(fmt.Sprintf(":x: Bake failed in %s : %s", b.AsgNames[region.Region], err.Error()))
+				return fmt.Errorf("bake failed in %s : %s", region.Region, err.Error())
+			}
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+
+		if remaining > config.PollingInterval {
+			remaining = config.PollingInterval
+		}
+
+		if err := tool.Sleep(ctx, remaining); err != nil {
+			return err
+		}
+	}
+
+	b.Logger.Infof("Bake is finished without any alarm : %s", b.Stack.Stack)
+	b.StepStatus[constants.StepBake] = true
+	return nil
+}
+
 //Clean Previous Version
-func (b BlueGreen) CleanPreviousVersion(config schemas.Config) error {
+func (b BlueGreen) CleanPreviousVersion(ctx context.Context, config schemas.Config) error {
 	if !b.StepStatus[constants.StepTriggerLifecycleCallback] {
 		return nil
 	}
@@ -457,13 +467,21 @@ func (b BlueGreen) CleanPreviousVersion(config schemas.Config) error {
 	}
 
 	if !skipped {
+		// Connections to previous versions are drained before they are resized to zero
+		if IsDrainEnabled(b.Stack) {
+			if err := b.Deployer.DrainPreviousVersions(ctx, config); err != nil {
+				return err
+			}
+		}
+
 		for _, region := range b.Stack.Regions {
 			if config.Region != "" && config.Region != region.Region {
 				b.Logger.Debug("This region is skipped by user : " + region.Region)
 				continue
 			}
 
-			b.Logger.Infof("[%s]The number of previous versions to delete is %d", region.Region, len(b.PrevAsgs[region.Region]))
+			standby, expired := SelectStandbyAsgs(b.PrevAsgs[region.Region], b.Stack.RetainPreviousVersions)
+			b.Logger.Infof("[%s]The number of previous versions to delete is %d", region.Region, len(expired))
 
 			//select client
 			client, err := selectClientFromList(b.AWSClients, region.Region)
@@ -471,11 +489,19 @@ func (b BlueGreen) CleanPreviousVersion(config schemas.Config) error {
 				return err
 			}
 
+			// Retained versions are kept with zero capacity as warm standby
+			for _, asg := range standby {
+				b.Logger.Debugf("[Standby] target autoscaling group : %s", asg)
+				if err := b.Deployer.MarkStandby(ctx, client, asg); err != nil {
+					b.Logger.Errorf(err.Error())
+				}
+			}
+
 			// First make autoscaling group size to 0
 			if len(b.PrevAsgs[region.Region]) > 0 {
 				for _, asg := range b.PrevAsgs[region.Region] {
 					b.Logger.Debugf("[Resizing to 0] target autoscaling group : %s", asg)
-					if err := b.ResizingAutoScalingGroupToZero(client, b.Stack.Stack, asg); err != nil {
+					if err := b.ResizingAutoScalingGroupToZero(ctx, client, b.Stack.Stack, asg); err != nil {
 						b.Logger.Errorf(err.Error())
 					}
 				}
@@ -490,7 +516,7 @@ func (b BlueGreen) CleanPreviousVersion(config schemas.Config) error {
 }
 
 // TerminateChecking checks Termination status
-func (b BlueGreen) TerminateChecking(config schemas.Config) map[string]bool {
+func (b BlueGreen) TerminateChecking(ctx context.Context, config schemas.Config) map[string]bool {
 	stackName := b.GetStackName()
 	if !b.StepStatus[constants.StepCleanPreviousVersion] {
 		return map[string]bool{stackName: true}
@@ -535,9 +561,16 @@ func (b BlueGreen) TerminateChecking(config schemas.Config) map[string]bool {
 			continue
 		}
 
+		standby, _ := SelectStandbyAsgs(targets, b.Stack.RetainPreviousVersions)
+
 		okCount := 0
 		for _, target := range targets {
-			ok := b.Deployer.CheckTerminating(client, target, config.DisableMetrics)
+			var ok bool
+			if tool.IsStringInArray(target, standby) {
+				ok = b.Deployer.CheckStandby(ctx, client, target, config.DisableMetrics)
+			} else {
+				ok = b.Deployer.CheckTerminating(ctx, client, target, config.DisableMetrics)
+			}
 			if ok {
 				b.Logger.Info("finished : ", target)
 				okCount++
@@ -570,7 +603,7 @@ func CheckRegionExist(target string, regions []schemas.RegionConfig) bool {
 }
 
 // Gather the whole metrics from deployer
-func (b BlueGreen) GatherMetrics(config schemas.Config) error {
+func (b BlueGreen) GatherMetrics(ctx context.Context, config schemas.Config) error {
 	if config.DisableMetrics {
 		return nil
 	}
@@ -599,7 +632,7 @@ func (b BlueGreen) GatherMetrics(config schemas.Config) error {
 			var errorList []error
 			for _, asg := range b.PrevAsgs[region.Region] {
 				b.Logger.Debugf("Start gathering metrics about autoscaling group : %s", asg)
-				err := b.Deployer.GatherMetrics(client, asg)
+				err := b.Deployer.GatherMetrics(ctx, client, asg)
 				if err != nil {
 					errorList = append(errorList, err)
 				}
@@ -621,7 +654,7 @@ func (b BlueGreen) GatherMetrics(config schemas.Config) error {
 }
 
 // CheckPrevious checks if there is any previous version of autoscaling group
-func (b BlueGreen) CheckPrevious(config schemas.Config) error {
+func (b BlueGreen) CheckPrevious(ctx context.Context, config schemas.Config) error {
 	// Make Frigga
 	frigga := tool.Frigga{}
 	for _, region := range b.Stack.Regions {
@@ -642,7 +675,7 @@ func (b BlueGreen) CheckPrevious(config schemas.Config) error {
 		}
 
 		// Get All Autoscaling Groups
-		asgGroups := client.EC2Service.GetAllMatchingAutoscalingGroupsWithPrefix(frigga.Prefix)
+		asgGroups := client.EC2Service.GetAllMatchingAutoscalingGroupsWithPrefix(ctx, frigga.Prefix)
 
 		//Get All Previous Autoscaling Groups and versions
 		prevAsgs := []string{}
@@ -673,38 +706,338 @@ func (b BlueGreen) CheckPrevious(config schemas.Config) error {
 }
 
 // RunAPITest tries to run API Test
-func (b BlueGreen) RunAPITest(config schemas.Config) error {
+func (b BlueGreen) RunAPITest(ctx context.Context, config schemas.Config) error {
 	if !b.Stack.APITestEnabled {
 		b.Logger.Infof("API test is disabled for this stack: %s", b.Stack.Stack)
 		return nil
 	}
 
+	if b.Stack.APITestBeforeCleanup {
+		b.Logger.Debugf("API test already ran before cleanup : %s", b.Stack.Stack)
+		return nil
+	}
+
+	return b.runAPITest(ctx, config, nil, constants.EmptyString)
+}
+
+// RunPreCleanupAPITest runs API test against new versions before previous versions are cleaned
+// Previous versions keep serving traffic if the test fails
+func (b BlueGreen) RunPreCleanupAPITest(ctx context.Context, config schemas.Config) error {
+	if !b.StepStatus[constants.StepDeploy] {
+		return nil
+	}
+
+	if !b.Stack.APITestEnabled || !b.Stack.APITestBeforeCleanup {
+		b.StepStatus[constants.StepAPITest] = true
+		return nil
+	}
+
+	for _, region := range b.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			b.Logger.Debug("This region is skipped by user : " + region.Region)
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(b.AWSClients, region.Region)
+		if err != nil {
+			return err
+		}
+
+		b.Logger.Infof("Run API test against new version before cleanup : %s", b.AsgNames[region.Region])
+		hosts, err := b.Deployer.PrepareAPITest(ctx, client, region, config)
+		if err == nil {
+			err = b.runAPITest(ctx, config, hosts, region.Region)
+		}
+
+		if detachErr := b.Deployer.FinishAPITest(ctx, client, region); detachErr != nil {
+			b.Logger.Errorf("failed to detach API test target group : %s", detachErr.Error())
+		}
+
+		if err != nil {
+			return fmt.Errorf("API test failed before cleanup in %s : %s", region.Region, err.Error())
+		}
+	}
+
+	b.StepStatus[constants.StepAPITest] = true
+	return nil
+}
+
+// runAPITest runs scenarios and load test, and checks thresholds of the result
+// Templated urls are called for each host, and region is empty if the test is not for a specific region
+func (b BlueGreen) runAPITest(ctx context.Context, config schemas.Config, hosts []string, region string) error {
+	var scenarios []ScenarioResult
+	vars := b.APITestTemplate.Vars
+	if len(b.APITestTemplate.Scenarios) > 0 {
+		b.Logger.Debugf("Run API test scenarios")
+		vars, scenarios = b.Deployer.RunAPIScenarios(ctx, *b.APITestTemplate, hosts)
+		printScenarioResults(scenarios)
+	}
+
+	// load test is skipped because later requests may need variables captured in failed steps
+	var result []schemas.MetricResult
+	var regressions []RegressionResult
+	violations := CheckScenarioResults(scenarios)
+	if len(violations) == 0 {
+		var err error
+		result, err = b.runLoadTest(config, hosts, vars)
+		if err != nil {
+			return err
+		}
+		violations = CheckAPITestAssertions(result)
+
+		regressions = b.checkRegression(region, result)
+		if rv := CheckRegressionResults(regressions); len(rv) > 0 {
+			if b.APITestTemplate.Regression.FailOnRegression {
+				violations = append(violations, rv...)
+			} else {
+				for _, v := range rv {
+					b.Logger.Warnf("API test regression is detected %s", v)
+				}
+				b.Slack.SendSimpleMessage(fmt.Sprintf(":warning: API test regression is detected for %s :\n%s", b.Stack.Stack, strings.Join(rv, "\n")))
+			}
+		}
+	}
+
+	if err := b.reportAPITest(config, region, result, scenarios, regressions, len(violations) == 0); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return b.failAPITest(violations)
+	}
+
+	return nil
+}
+
+// runLoadTest calls apis with vegeta and prints the result
+func (b BlueGreen) runLoadTest(config schemas.Config, hosts []string, vars map[string]string) ([]schemas.MetricResult, error) {
 	b.Logger.Debugf("Create API attacker")
-	attacker, err := b.Deployer.GenerateAPIAttacker(*b.APITestTemplate)
+	attacker, err := b.Deployer.GenerateAPIAttacker(*b.APITestTemplate, hosts, vars)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	attacker.KeepResults = len(config.APITestReportDir) > 0
 
 	b.Logger.Debugf("Run API attacker")
 	result, err := attacker.Run()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	b.Logger.Debugf("Print API test result")
 	_, err = attacker.Print(result)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := b.Slack.SendAPITestResultMessage(result); err != nil {
-		return err
+		return nil, err
 	}
 	b.Logger.Debugf("API test is done")
 
+	return result, nil
+}
+
+// checkRegression compares API test with the last successful deployment
+// Comparison is skipped if API test of previous versions cannot be found
+func (b BlueGreen) checkRegression(region string, result []schemas.MetricResult) []RegressionResult {
+	if b.APITestTemplate.Regression == nil || len(result) == 0 {
+		return nil
+	}
+
+	if !b.Collector.MetricConfig.Enabled {
+		b.Logger.Warnf("regression of API test cannot be checked without metrics : %s", b.Stack.Stack)
+		return nil
+	}
+
+	var previous *schemas.APITestSummary
+	for _, r := range b.Stack.Regions {
+		if len(region) > 0 && r.Region != region {
+			continue
+		}
+
+		summary, err := b.Deployer.GetPreviousAPITestSummary(r.Region)
+		if err != nil {
+			b.Logger.Warnf("failed to get API test of previous version : %s", err.Error())
+			continue
+		}
+
+		if summary != nil && (previous == nil || summary.Timestamp > previous.Timestamp) {
+			previous = summary
+		}
+	}
+
+	if previous == nil {
+		b.Logger.Infof("no API test of previous release to compare : %s", b.Stack.Stack)
+		return nil
+	}
+
+	regressions := CompareAPITestSummary(*previous, NewAPITestSummary(b.APITestTemplate.Name, result), *b.APITestTemplate.Regression)
+	printRegressionResults(regressions)
+
+	return regressions
+}
+
+// reportAPITest writes reports of API test and stores the summary with deployment records of new versions
+// The summary keeps whether API test passed so that failed one is not used for regression check later
+func (b BlueGreen) reportAPITest(config schemas.Config, region string, result []schemas.MetricResult, scenarios []ScenarioResult, regressions []RegressionResult, passed bool) error {
+	if len(config.APITestReportDir) > 0 {
+		name := fmt.Sprintf("%s-%s", b.Stack.Stack, b.APITestTemplate.Name)
+		if len(region) > 0 {
+			name = fmt.Sprintf("%s-%s", name, region)
+		}
+
+		if err := WriteAPITestReport(config.APITestReportDir, name, result, scenarios, regressions); err != nil {
+			return fmt.Errorf("failed to write API test report : %s", err.Error())
+		}
+		b.Logger.Infof("API test report is written : %s", filepath.Join(config.APITestReportDir, name))
+	}
+
+	if !b.Collector.MetricConfig.Enabled || len(result) == 0 {
+		return nil
+	}
+
+	summary := NewAPITestSummary(b.APITestTemplate.Name, result)
+	summary.Passed = passed
+	for r, asg := range b.AsgNames {
+		if len(region) > 0 && r != region {
+			continue
+		}
+
+		if err := b.Collector.UpdateAPITestSummary(asg, summary); err != nil {
+			b.Logger.Warnf("failed to store summary of API test : %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// failAPITest reports violations of API test and returns error
+func (b BlueGreen) failAPITest(violations []string) error {
+	for _, v := range violations {
+		b.Logger.Errorf("API test assertion failed %s", v)
+	}
+	b.Slack.SendSimpleMessage(fmt.Sprintf(":x: API test failed for %s :\n%s", b.Stack.Stack, strings.Join(violations, "\n")))
+	return fmt.Errorf("API test failed with %d violated assertions : %s", len(violations), b.Stack.Stack)
+}
+
+// Rollback deletes the new version and keeps previous versions when deployment fails
+func (b BlueGreen) Rollback(ctx context.Context, config schemas.Config) error {
+	if !b.Stack.RollbackOnFailure {
+		b.Logger.Debugf("rollback on failure is disabled : %s", b.Stack.Stack)
+		return nil
+	}
+
+	for _, region := range b.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			b.Logger.Debug("This region is skipped by user : " + region.Region)
+			continue
+		}
+
+		asg := b.AsgNames[region.Region]
+		if len(asg) == 0 {
+			b.Logger.Debugf("no new version to roll back : %s", region.Region)
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(b.AWSClients, region.Region)
+		if err != nil {
+			return err
+		}
+
+		// Listener is switched back to the target group of previous version
+		if active, ok := b.ActiveTargetGroups[region.Region]; ok {
+			if err := b.Deployer.switchListener(ctx, client, region, active); err != nil {
+				return err
+			}
+		}
+
+		if err := b.Deployer.DeleteNewVersion(ctx, client, asg); err != nil {
+			return err
+		}
+		b.Deployer.MarkRolledBack(asg)
+	}
+
+	return nil
+}
+
+// Cleanup deletes the new version created in this run regardless of rollback_on_failure
+func (b BlueGreen) Cleanup(ctx context.Context, config schemas.Config) error {
+	b.Stack.RollbackOnFailure = true
+	if err := b.Rollback(ctx, config); err != nil {
+		return err
+	}
+
+	b.Deployer.ResetDeployStep()
 	return nil
 }
 
+// Plan returns changes of deployment in each region without creating or deleting anything
+func (b BlueGreen) Plan(ctx context.Context, config schemas.Config) ([]schemas.RegionPlan, error) {
+	if err := b.CheckPrevious(ctx, config); err != nil {
+		return nil, err
+	}
+
+	b.LocalProvider = builder.SetUserdataProvider(b.Stack.Userdata, b.AwsConfig.Userdata)
+
+	plans := []schemas.RegionPlan{}
+	for _, region := range b.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(b.AWSClients, region.Region)
+		if err != nil {
+			return nil, err
+		}
+
+		if IsListenerSwitch(b.Stack) {
+			region, err = b.Deployer.prepareListenerSwitch(ctx, client, region)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		appliedCapacity := b.Deployer.GetAppliedCapacity(config.ForceManifestCapacity, region.Region)
+		loadbalancers, targetGroups := GetLoadBalancingTargets(region)
+		plan, err := b.Deployer.PlanNewVersion(ctx, config, region, client, appliedCapacity, loadbalancers, targetGroups)
+		if err != nil {
+			return nil, err
+		}
+
+		plan.AttachAfterWarmup = IsWarmupEnabled(b.Stack)
+		plan.StandbyAutoScalingGroups, plan.RemovedAutoScalingGroups = SelectStandbyAsgs(b.PrevAsgs[region.Region], b.Stack.RetainPreviousVersions)
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+// PlanDelete returns autoscaling groups which would be deleted in each region
+func (b BlueGreen) PlanDelete(ctx context.Context, config schemas.Config) ([]schemas.RegionPlan, error) {
+	if err := b.CheckPrevious(ctx, config); err != nil {
+		return nil, err
+	}
+
+	plans := []schemas.RegionPlan{}
+	for _, region := range b.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			continue
+		}
+
+		plans = append(plans, schemas.RegionPlan{
+			Stack:                    b.Stack.Stack,
+			Region:                   region.Region,
+			RemovedAutoScalingGroups: b.PrevAsgs[region.Region],
+		})
+	}
+
+	return plans, nil
+}
+
 // SkipDeployStep
 func (b BlueGreen) SkipDeployStep() {
 	b.StepStatus[constants.StepDeploy] = true
@@ -17,8 +17,19 @@ limitations under the license.
 package deployer
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	vegeta "github.com/tsenart/vegeta/lib"
+
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
 	"github.com/DevopsArtFactory/goployer/pkg/schemas"
 )
 
@@ -58,3 +69,373 @@ func TestCheckRegionExist(t *testing.T) {
 		t.Error(regionList, target)
 	}
 }
+
+func TestSelectStandbyAsgs(t *testing.T) {
+	prevAsgs := []string{"hello-dev_apne2-v001", "hello-dev_apne2-v003", "hello-dev_apne2-v002"}
+	testData := []struct {
+		retain  int64
+		standby []string
+		expired []string
+	}{
+		{
+			retain:  0,
+			standby: []string{},
+			expired: []string{"hello-dev_apne2-v003", "hello-dev_apne2-v002", "hello-dev_apne2-v001"},
+		},
+		{
+			retain:  2,
+			standby: []string{"hello-dev_apne2-v003", "hello-dev_apne2-v002"},
+			expired: []string{"hello-dev_apne2-v001"},
+		},
+		{
+			retain:  5,
+			standby: []string{"hello-dev_apne2-v003", "hello-dev_apne2-v002", "hello-dev_apne2-v001"},
+			expired: []string{},
+		},
+	}
+
+	for _, td := range testData {
+		standby, expired := SelectStandbyAsgs(prevAsgs, td.retain)
+		if !reflect.DeepEqual(standby, td.standby) || !reflect.DeepEqual(expired, td.expired) {
+			t.Errorf("expected: %v/%v, output: %v/%v", td.standby, td.expired, standby, expired)
+		}
+	}
+}
+
+func TestSetFastRollbackTarget(t *testing.T) {
+	b := NewBlueGrean(constants.BlueGreenDeployment, nil, schemas.AWSConfig{}, nil, schemas.Stack{}, "")
+	current := &autoscaling.Group{
+		AutoScalingGroupName: aws.String("hello-dev_apne2-v003"),
+		Instances: []*autoscaling.Instance{
+			{InstanceId: aws.String("i-1")},
+			{InstanceId: aws.String("i-2")},
+		},
+	}
+
+	b.SetFastRollbackTarget("ap-northeast-2", current, "hello-dev_apne2-v002")
+	if b.AsgNames["ap-northeast-2"] != "hello-dev_apne2-v002" {
+		t.Errorf("wrong standby version: %s", b.AsgNames["ap-northeast-2"])
+	}
+
+	if !reflect.DeepEqual(b.PrevAsgs["ap-northeast-2"], []string{"hello-dev_apne2-v003"}) || !reflect.DeepEqual(b.PrevInstances["ap-northeast-2"], []string{"i-1", "i-2"}) {
+		t.Errorf("wrong previous version: %v, %v", b.PrevAsgs["ap-northeast-2"], b.PrevInstances["ap-northeast-2"])
+	}
+
+	if !b.StepStatus[constants.StepAdditionalWork] {
+		t.Errorf("previous version should be cleaned up after fast rollback")
+	}
+}
+
+func TestGetInServiceInstances(t *testing.T) {
+	group := &autoscaling.Group{
+		Instances: []*autoscaling.Instance{
+			{InstanceId: aws.String("i-0002"), LifecycleState: aws.String(autoscaling.LifecycleStateInService)},
+			{InstanceId: aws.String("i-0003"), LifecycleState: aws.String(autoscaling.LifecycleStatePending)},
+			{InstanceId: aws.String("i-0001"), LifecycleState: aws.String(autoscaling.LifecycleStateInService)},
+		},
+	}
+
+	expected := []string{"i-0001", "i-0002"}
+	if output := getInServiceInstances(group); !reflect.DeepEqual(output, expected) {
+		t.Errorf("expected: %v, output: %v", expected, output)
+	}
+}
+
+func TestHasPostDeployCallbacks(t *testing.T) {
+	testData := []struct {
+		callbacks *schemas.LifecycleCallbacks
+		expected  bool
+	}{
+		{callbacks: nil, expected: false},
+		{callbacks: &schemas.LifecycleCallbacks{PreTerminatePastClusters: []string{"service hello stop"}}, expected: false},
+		{callbacks: &schemas.LifecycleCallbacks{RunOnce: []string{"make migrate"}}, expected: true},
+		{callbacks: &schemas.LifecycleCallbacks{Local: []string{"./smoke.sh"}}, expected: true},
+	}
+
+	for _, td := range testData {
+		if output := hasPostDeployCallbacks(td.callbacks); output != td.expected {
+			t.Errorf("expected: %t, output: %t", td.expected, output)
+		}
+	}
+}
+
+func TestRunLocalCommand(t *testing.T) {
+	out, err := runLocalCommand(context.Background(), "echo $GOPLOYER_ASG", []string{"GOPLOYER_ASG=hello-dev_apne2-v001"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if out != "hello-dev_apne2-v001" {
+		t.Errorf("expected: hello-dev_apne2-v001, output: %s", out)
+	}
+
+	if _, err := runLocalCommand(context.Background(), "exit 1", nil); err == nil {
+		t.Errorf("error expected for failed command")
+	}
+}
+
+func TestIsCommandFinished(t *testing.T) {
+	invocation := func(status string) *ssm.CommandInvocation {
+		return &ssm.CommandInvocation{Status: aws.String(status)}
+	}
+
+	testData := []struct {
+		invocations []*ssm.CommandInvocation
+		targets     int
+		expected    bool
+	}{
+		{invocations: nil, targets: 1, expected: false},
+		{invocations: []*ssm.CommandInvocation{invocation(ssm.CommandInvocationStatusSuccess)}, targets: 2, expected: false},
+		{invocations: []*ssm.CommandInvocation{invocation(ssm.CommandInvocationStatusSuccess), invocation(ssm.CommandInvocationStatusInProgress)}, targets: 2, expected: false},
+		{invocations: []*ssm.CommandInvocation{invocation(ssm.CommandInvocationStatusSuccess), invocation(ssm.CommandInvocationStatusFailed)}, targets: 2, expected: true},
+		{invocations: []*ssm.CommandInvocation{invocation(ssm.CommandInvocationStatusTimedOut)}, targets: 1, expected: true},
+	}
+
+	for _, td := range testData {
+		if output := isCommandFinished(td.invocations, td.targets); output != td.expected {
+			t.Errorf("expected: %t, output: %t", td.expected, output)
+		}
+	}
+}
+
+func TestGetCallbackTimeout(t *testing.T) {
+	testData := []struct {
+		callbacks *schemas.LifecycleCallbacks
+		expected  time.Duration
+	}{
+		{callbacks: nil, expected: constants.DefaultCallbackTimeout},
+		{callbacks: &schemas.LifecycleCallbacks{}, expected: constants.DefaultCallbackTimeout},
+		{callbacks: &schemas.LifecycleCallbacks{Timeout: 3 * time.Minute}, expected: 3 * time.Minute},
+	}
+
+	for _, td := range testData {
+		if output := getCallbackTimeout(td.callbacks); output != td.expected {
+			t.Errorf("expected: %s, output: %s", td.expected, output)
+		}
+	}
+}
+
+func TestGetValidatedLaunchHooks(t *testing.T) {
+	hooks := &schemas.LifecycleHooks{
+		LaunchTransition: []schemas.LifecycleHookSpecification{
+			{LifecycleHookName: "notify"},
+			{LifecycleHookName: "validate", Validation: &schemas.InstanceCheck{Type: constants.HTTPCheck, Port: 8080}},
+		},
+		TerminateTransition: []schemas.LifecycleHookSpecification{
+			{LifecycleHookName: "drain"},
+		},
+	}
+
+	output := getValidatedLaunchHooks(hooks)
+	if len(output) != 1 || output[0].LifecycleHookName != "validate" {
+		t.Errorf("expected: [validate], output: %v", output)
+	}
+
+	if output := getValidatedLaunchHooks(nil); len(output) != 0 {
+		t.Errorf("expected: [], output: %v", output)
+	}
+}
+
+func TestGetWaitingInstances(t *testing.T) {
+	group := &autoscaling.Group{
+		Instances: []*autoscaling.Instance{
+			{InstanceId: aws.String("i-0002"), LifecycleState: aws.String(autoscaling.LifecycleStatePendingWait)},
+			{InstanceId: aws.String("i-0003"), LifecycleState: aws.String(autoscaling.LifecycleStateInService)},
+			{InstanceId: aws.String("i-0001"), LifecycleState: aws.String(autoscaling.LifecycleStatePendingWait)},
+		},
+	}
+
+	expected := []string{"i-0001", "i-0002"}
+	if output := getWaitingInstances(group); !reflect.DeepEqual(output, expected) {
+		t.Errorf("expected: %v, output: %v", expected, output)
+	}
+}
+
+func TestGetCheckURL(t *testing.T) {
+	testData := []struct {
+		check    schemas.InstanceCheck
+		expected string
+	}{
+		{check: schemas.InstanceCheck{Port: 8080}, expected: "http://10.0.0.1:8080/"},
+		{check: schemas.InstanceCheck{Port: 80, Path: "health"}, expected: "http://10.0.0.1:80/health"},
+		{check: schemas.InstanceCheck{Port: 80, Path: "/ready?deep=true"}, expected: "http://10.0.0.1:80/ready?deep=true"},
+	}
+
+	for _, td := range testData {
+		if output := getCheckURL(td.check, "10.0.0.1"); output != td.expected {
+			t.Errorf("expected: %s, output: %s", td.expected, output)
+		}
+	}
+}
+
+func TestIsExpectedStatus(t *testing.T) {
+	testData := []struct {
+		status   int
+		expected int
+		output   bool
+	}{
+		{status: 200, expected: 0, output: true},
+		{status: 302, expected: 0, output: true},
+		{status: 503, expected: 0, output: false},
+		{status: 204, expected: 204, output: true},
+		{status: 200, expected: 204, output: false},
+	}
+
+	for _, td := range testData {
+		if output := isExpectedStatus(td.status, td.expected); output != td.output {
+			t.Errorf("status: %d, expected: %d, output: %t", td.status, td.expected, output)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	if err := probeHTTP(context.Background(), server.URL+"/health", 0); err != nil {
+		t.Error(err)
+	}
+
+	if err := probeHTTP(context.Background(), server.URL+"/unknown", 0); err == nil {
+		t.Errorf("error expected for unexpected status code")
+	}
+
+	if err := probeHTTP(context.Background(), server.URL+"/unknown", http.StatusNotFound); err != nil {
+		t.Error(err)
+	}
+
+	if err := probeTCP(context.Background(), server.Listener.Addr().String()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIsBreaching(t *testing.T) {
+	testData := []struct {
+		value      float64
+		comparison string
+		expected   bool
+	}{
+		{value: 11, comparison: "GreaterThanThreshold", expected: true},
+		{value: 10, comparison: "GreaterThanThreshold", expected: false},
+		{value: 10, comparison: "GreaterThanOrEqualToThreshold", expected: true},
+		{value: 9, comparison: "LessThanThreshold", expected: true},
+		{value: 10, comparison: "LessThanOrEqualToThreshold", expected: true},
+		{value: 11, comparison: "LessThanOrEqualToThreshold", expected: false},
+		{value: 100, comparison: "Unknown", expected: false},
+	}
+
+	for _, td := range testData {
+		if output := isBreaching(td.value, 10, td.comparison); output != td.expected {
+			t.Errorf("value: %f, comparison: %s, expected: %t, output: %t", td.value, td.comparison, td.expected, output)
+		}
+	}
+}
+
+func TestCheckAPITestAssertions(t *testing.T) {
+	maxErrors := 1
+	results := []schemas.MetricResult{
+		{
+			Method: "GET",
+			URL:    "https://example.com",
+			Assertions: &schemas.APITestAssertions{
+				MinSuccessRatio: 0.99,
+				MaxLatencyP99:   500 * time.Millisecond,
+			},
+			Data: vegeta.Metrics{
+				Success:     0.98,
+				Latencies:   vegeta.LatencyMetrics{P99: time.Second},
+				StatusCodes: map[string]int{"200": 98, "500": 2},
+			},
+		},
+		{
+			Method: "POST",
+			URL:    "https://example.com/post",
+			Assertions: &schemas.APITestAssertions{
+				AllowedStatusCodes: []int{200, 201},
+				MaxErrors:          &maxErrors,
+			},
+			Data: vegeta.Metrics{
+				Success:     0.97,
+				Latencies:   vegeta.LatencyMetrics{P99: time.Second},
+				StatusCodes: map[string]int{"201": 97, "0": 1, "503": 2},
+			},
+		},
+	}
+
+	expected := []string{
+		"[GET https://example.com] success ratio 0.9800 is lower than 0.9900",
+		"[GET https://example.com] p99 latency 1000.00ms exceeds 500ms",
+		"[POST https://example.com/post] status code 0 is not allowed : 1 responses",
+		"[POST https://example.com/post] status code 503 is not allowed : 2 responses",
+		"[POST https://example.com/post] 3 requests failed, more than 1",
+	}
+
+	if output := CheckAPITestAssertions(results); !reflect.DeepEqual(output, expected) {
+		t.Errorf("expected: %v, output: %v", expected, output)
+	}
+
+	results[0].Data = vegeta.Metrics{Success: 1, Latencies: vegeta.LatencyMetrics{P99: 100 * time.Millisecond}}
+	results[1].Data = vegeta.Metrics{Success: 1, StatusCodes: map[string]int{"200": 100}}
+	if output := CheckAPITestAssertions(results); len(output) != 0 {
+		t.Errorf("expected no violation, output: %v", output)
+	}
+}
+
+func TestGenerateAPIAttacker(t *testing.T) {
+	template := schemas.APITestTemplate{
+		Name:             "api-test",
+		Duration:         time.Second,
+		RequestPerSecond: 1,
+		Assertions:       &schemas.APITestAssertions{MinSuccessRatio: 0.99},
+		APIs: []*schemas.APIManifest{
+			{Method: "get", URL: "https://example.com"},
+			{
+				Method:     "get",
+				URL:        "http://{{ .PrivateIP }}:8080/health",
+				Assertions: &schemas.APITestAssertions{MaxLatencyP99: time.Second},
+			},
+			{
+				Method: "delete",
+				URL:    "https://example.com/items/{{ .Vars.id }}",
+				Header: []string{"Authorization=Bearer {{ .Vars.token }}"},
+			},
+		},
+	}
+
+	vars := map[string]string{"id": "1", "token": "abc=="}
+	attacker, err := Deployer{}.GenerateAPIAttacker(template, []string{"10.0.0.1", "10.0.0.2"}, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urls := []string{}
+	for _, target := range attacker.Targets {
+		urls = append(urls, target.URL)
+	}
+
+	expected := []string{"https://example.com", "http://10.0.0.1:8080/health", "http://10.0.0.2:8080/health", "https://example.com/items/1"}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("expected: %v, output: %v", expected, urls)
+	}
+
+	if header := attacker.Targets[3].Header.Get("Authorization"); header != "Bearer abc==" {
+		t.Errorf("expected: Bearer abc==, output: %s", header)
+	}
+
+	if attacker.Assertions[0] != template.Assertions || attacker.Assertions[2] != template.APIs[1].Assertions {
+		t.Errorf("assertions are not matched with targets")
+	}
+
+	if _, err := (Deployer{}).GenerateAPIAttacker(template, nil, vars); err == nil {
+		t.Errorf("error expected for templated url without host")
+	}
+
+	template.APIs = template.APIs[2:]
+	if _, err := (Deployer{}).GenerateAPIAttacker(template, nil, nil); err == nil {
+		t.Errorf("error expected for undefined variable")
+	}
+}
@@ -0,0 +1,396 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	Logger "github.com/sirupsen/logrus"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/builder"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+	"github.com/DevopsArtFactory/goployer/pkg/tool"
+)
+
+const (
+	// canaryLaunching means canary instances are not healthy yet
+	canaryLaunching = -1
+
+	// canaryPromoting means the new autoscaling group is scaled out and attached to stable target groups
+	canaryPromoting = -2
+
+	// canaryFinished means canary deployment is done in the region
+	canaryFinished = -3
+)
+
+type Canary struct {
+	BlueGreen
+	TrafficStep   map[string]int
+	StepStartedAt map[string]time.Time
+}
+
+// NewCanary creates new canary deployment deployer
+func NewCanary(mode string, logger *Logger.Logger, awsConfig schemas.AWSConfig, apiTestTemplate *schemas.APITestTemplate, stack schemas.Stack, regionSelected string) Canary {
+	return Canary{
+		BlueGreen:     NewBlueGrean(mode, logger, awsConfig, apiTestTemplate, stack, regionSelected),
+		TrafficStep:   map[string]int{},
+		StepStartedAt: map[string]time.Time{},
+	}
+}
+
+// GetCanaryCapacity returns capacity of canary instances from applied capacity
+func GetCanaryCapacity(applied schemas.Capacity, percentage int64) schemas.Capacity {
+	count := (applied.Desired*percentage + 99) / 100
+	if count < 1 {
+		count = 1
+	}
+
+	max := applied.Max
+	if max < count {
+		max = count
+	}
+
+	return schemas.Capacity{
+		Min:     count,
+		Max:     max,
+		Desired: count,
+	}
+}
+
+// Deploy launches canary instances of new version
+func (c Canary) Deploy(ctx context.Context, config schemas.Config) error {
+	if !c.StepStatus[constants.StepCheckPrevious] {
+		return nil
+	}
+
+	c.Logger.Info("Deploy Mode is " + c.Mode)
+
+	//Get LocalFileProvider
+	c.LocalProvider = builder.SetUserdataProvider(c.Stack.Userdata, c.AwsConfig.Userdata)
+
+	for _, region := range c.Stack.Regions {
+		//Region check
+		//If region id is passed from command line, then deployer will deploy in that region only.
+		if config.Region != "" && config.Region != region.Region {
+			c.Logger.Debug("This region is skipped by user : " + region.Region)
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(c.AWSClients, region.Region)
+		if err != nil {
+			return err
+		}
+
+		appliedCapacity := c.Deployer.GetAppliedCapacity(config.ForceManifestCapacity, region.Region)
+		canaryCapacity := GetCanaryCapacity(appliedCapacity, c.Stack.Canary.CapacityPercentage)
+		c.Logger.Infof("Canary instance capacity - Min: %d, Desired: %d, Max: %d", canaryCapacity.Min, canaryCapacity.Desired, canaryCapacity.Max)
+
+		// canary instances only receive traffic from canary target group until promotion
+		newAsgName, err := c.Deployer.CreateNewVersion(ctx, config, region, client, canaryCapacity, nil, []string{region.CanaryTargetGroup})
+		if err != nil {
+			return err
+		}
+
+		c.AsgNames[region.Region] = newAsgName
+		c.TrafficStep[region.Region] = canaryLaunching
+	}
+
+	c.StepStatus[constants.StepDeploy] = true
+	return nil
+}
+
+// Plan returns changes of canary deployment in each region without creating anything
+func (c Canary) Plan(ctx context.Context, config schemas.Config) ([]schemas.RegionPlan, error) {
+	if err := c.CheckPrevious(ctx, config); err != nil {
+		return nil, err
+	}
+
+	c.LocalProvider = builder.SetUserdataProvider(c.Stack.Userdata, c.AwsConfig.Userdata)
+
+	plans := []schemas.RegionPlan{}
+	for _, region := range c.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(c.AWSClients, region.Region)
+		if err != nil {
+			return nil, err
+		}
+
+		appliedCapacity := c.Deployer.GetAppliedCapacity(config.ForceManifestCapacity, region.Region)
+		canaryCapacity := GetCanaryCapacity(appliedCapacity, c.Stack.Canary.CapacityPercentage)
+		plan, err := c.Deployer.PlanNewVersion(ctx, config, region, client, canaryCapacity, nil, []string{region.CanaryTargetGroup})
+		if err != nil {
+			return nil, err
+		}
+
+		plan.StandbyAutoScalingGroups, plan.RemovedAutoScalingGroups = SelectStandbyAsgs(c.PrevAsgs[region.Region], c.Stack.RetainPreviousVersions)
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+// HealthChecking shifts traffic to canary step by step and promotes new version after the last step
+func (c Canary) HealthChecking(ctx context.Context, config schemas.Config) map[string]bool {
+	stackName := c.GetStackName()
+	if !c.StepStatus[constants.StepDeploy] {
+		return map[string]bool{stackName: true}
+	}
+	c.Logger.Debugf("Canary healthchecking for stack starts : %s", stackName)
+	finished := []string{}
+
+	//Valid Count
+	validCount := 1
+	if config.Region == "" {
+		validCount = len(c.Stack.Regions)
+	}
+
+	if len(config.Region) > 0 {
+		if !CheckRegionExist(config.Region, c.Stack.Regions) {
+			validCount = 0
+		}
+	}
+
+	steps := c.Stack.Canary.TrafficSteps
+	bakeTime := c.Stack.Canary.BakeTime
+	if bakeTime == 0 {
+		bakeTime = constants.DefaultCanaryBakeTime
+	}
+
+	for _, region := range c.Stack.Regions {
+		//Region check
+		//If region id is passed from command line, then deployer will deploy in that region only.
+		if config.Region != "" && config.Region != region.Region {
+			c.Logger.Debugf("This region is skipped by user: %s", region.Region)
+			continue
+		}
+
+		if c.TrafficStep[region.Region] == canaryFinished {
+			finished = append(finished, region.Region)
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(c.AWSClients, region.Region)
+		if err != nil {
+			return map[string]bool{stackName: false, "error": true}
+		}
+
+		asg, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, c.AsgNames[region.Region])
+		if err != nil {
+			return map[string]bool{stackName: false, "error": true}
+		}
+
+		appliedCapacity := c.Deployer.GetAppliedCapacity(config.ForceManifestCapacity, region.Region)
+		canaryCapacity := GetCanaryCapacity(appliedCapacity, c.Stack.Canary.CapacityPercentage)
+
+		canaryRegion := region
+		canaryRegion.HealthcheckTargetGroup = region.CanaryTargetGroup
+		canaryRegion.HealthcheckLB = constants.EmptyString
+
+		step := c.TrafficStep[region.Region]
+		switch {
+		case step == canaryLaunching:
+			isHealthy, err := c.Deployer.polling(ctx, canaryRegion, asg, client, canaryCapacity.Desired, false, false)
+			if err != nil {
+				return map[string]bool{stackName: false, "error": true}
+			}
+
+			if isHealthy {
+				if err := c.shiftTraffic(ctx, client, region, steps[0]); err != nil {
+					c.Logger.Errorf(err.Error())
+					return map[string]bool{stackName: false, "error": true}
+				}
+				c.TrafficStep[region.Region] = 0
+				c.StepStartedAt[region.Region] = time.Now()
+			}
+		case step >= 0:
+			if time.Since(c.StepStartedAt[region.Region]) < bakeTime {
+				c.Logger.Infof("[%s] Canary is baking with %d%% of traffic : %s", region.Region, steps[step], tool.RoundTime(time.Since(c.StepStartedAt[region.Region])))
+				continue
+			}
+
+			isHealthy, err := c.Deployer.polling(ctx, canaryRegion, asg, client, canaryCapacity.Desired, false, false)
+			if err != nil || !isHealthy {
+				c.Logger.Errorf("[%s] canary did not pass health gate with %d%% of traffic", region.Region, steps[step])
+				c.Slack.SendSimpleMessage(fmt.Sprintf(":x: Canary did not pass health gate with %d%% of traffic : %s", steps[step], c.AsgNames[region.Region]))
+				if err := c.shiftTraffic(ctx, client, region, 0); err != nil {
+					c.Logger.Errorf(err.Error())
+				}
+				return map[string]bool{stackName: false, "error": true}
+			}
+
+			if step+1 < len(steps) {
+				if err := c.shiftTraffic(ctx, client, region, steps[step+1]); err != nil {
+					c.Logger.Errorf(err.Error())
+					return map[string]bool{stackName: false, "error": true}
+				}
+				c.TrafficStep[region.Region] = step + 1
+				c.StepStartedAt[region.Region] = time.Now()
+				continue
+			}
+
+			if err := c.promote(ctx, client, region, appliedCapacity); err != nil {
+				c.Logger.Errorf(err.Error())
+				return map[string]bool{stackName: false, "error": true}
+			}
+			c.TrafficStep[region.Region] = canaryPromoting
+		case step == canaryPromoting:
+			isHealthy, err := c.Deployer.polling(ctx, region, asg, client, appliedCapacity.Desired, false, false)
+			if err != nil {
+				return map[string]bool{stackName: false, "error": true}
+			}
+
+			if isHealthy {
+				if err := c.shiftTraffic(ctx, client, region, 0); err != nil {
+					c.Logger.Errorf(err.Error())
+					return map[string]bool{stackName: false, "error": true}
+				}
+
+				canaryTargetGroupArn, err := GetTargetGroupArn(ctx, client, region.CanaryTargetGroup, region.Region)
+				if err != nil {
+					c.Logger.Errorf(err.Error())
+					return map[string]bool{stackName: false, "error": true}
+				}
+
+				if err := client.EC2Service.DetachTargetGroups(ctx, c.AsgNames[region.Region], []*string{canaryTargetGroupArn}); err != nil {
+					c.Logger.Errorf(err.Error())
+					return map[string]bool{stackName: false, "error": true}
+				}
+
+				if c.Collector.MetricConfig.Enabled {
+					if err := c.Collector.UpdateStatus(*asg.AutoScalingGroupName, "deployed", nil); err != nil {
+						Logger.Errorf("Update status Error, %s : %s", err.Error(), *asg.AutoScalingGroupName)
+					}
+				}
+				c.TrafficStep[region.Region] = canaryFinished
+				finished = append(finished, region.Region)
+			}
+		}
+	}
+
+	if len(finished) == validCount {
+		return map[string]bool{stackName: true, "error": false}
+	}
+
+	return map[string]bool{stackName: false, "error": false}
+}
+
+// Rollback moves all traffic back to stable target group and deletes canary instances
+func (c Canary) Rollback(ctx context.Context, config schemas.Config) error {
+	if !c.Stack.RollbackOnFailure {
+		c.Logger.Debugf("rollback on failure is disabled : %s", c.Stack.Stack)
+		return nil
+	}
+
+	for _, region := range c.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			continue
+		}
+
+		if len(c.AsgNames[region.Region]) == 0 {
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(c.AWSClients, region.Region)
+		if err != nil {
+			return err
+		}
+
+		if err := c.shiftTraffic(ctx, client, region, 0); err != nil {
+			return err
+		}
+	}
+
+	return c.BlueGreen.Rollback(ctx, config)
+}
+
+// Cleanup moves all traffic back to stable target group and deletes canary instances created in this run
+func (c Canary) Cleanup(ctx context.Context, config schemas.Config) error {
+	c.Stack.RollbackOnFailure = true
+	if err := c.Rollback(ctx, config); err != nil {
+		return err
+	}
+
+	c.Deployer.ResetDeployStep()
+	return nil
+}
+
+// GetState returns state of deployer including canary traffic steps
+func (c Canary) GetState() schemas.DeployerState {
+	state := c.Deployer.GetState()
+	state.TrafficSteps = c.TrafficStep
+	return state
+}
+
+// SetState restores state of deployer including canary traffic steps
+// bake time of the current traffic step starts again
+func (c Canary) SetState(state schemas.DeployerState) {
+	c.Deployer.SetState(state)
+	for region, step := range state.TrafficSteps {
+		c.TrafficStep[region] = step
+		c.StepStartedAt[region] = time.Now()
+	}
+}
+
+// shiftTraffic changes weight of canary target group
+func (c Canary) shiftTraffic(ctx context.Context, client aws.Client, region schemas.RegionConfig, weight int64) error {
+	stableTargetGroupArn, err := GetTargetGroupArn(ctx, client, region.HealthcheckTargetGroup, region.Region)
+	if err != nil {
+		return err
+	}
+
+	canaryTargetGroupArn, err := GetTargetGroupArn(ctx, client, region.CanaryTargetGroup, region.Region)
+	if err != nil {
+		return err
+	}
+
+	weights := map[string]int64{
+		*stableTargetGroupArn: 100 - weight,
+		*canaryTargetGroupArn: weight,
+	}
+
+	if err := client.ELBV2Service.UpdateForwardWeights(ctx, region.ListenerArn, region.ListenerRuleArn, weights); err != nil {
+		return err
+	}
+
+	c.Logger.Infof("[%s] Traffic weight is changed - stable: %d%%, canary: %d%%", region.Region, 100-weight, weight)
+	c.Slack.SendSimpleMessage(fmt.Sprintf("Traffic weight is changed in %s - stable: %d%%, canary: %d%%", c.AsgNames[region.Region], 100-weight, weight))
+
+	return nil
+}
+
+// promote scales out new autoscaling group and attaches it to stable load balancing targets
+func (c Canary) promote(ctx context.Context, client aws.Client, region schemas.RegionConfig, capacity schemas.Capacity) error {
+	asgName := c.AsgNames[region.Region]
+	c.Logger.Infof("[%s] Promote canary to full capacity : %s", region.Region, asgName)
+
+	if err := client.EC2Service.UpdateAutoScalingGroup(ctx, asgName, capacity); err != nil {
+		return err
+	}
+
+	return AttachLoadBalancingTargets(ctx, client, asgName, region)
+}
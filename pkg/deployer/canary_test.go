@@ -0,0 +1,53 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"testing"
+
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+func TestGetCanaryCapacity(t *testing.T) {
+	testData := []struct {
+		applied    schemas.Capacity
+		percentage int64
+		expected   schemas.Capacity
+	}{
+		{
+			applied:    schemas.Capacity{Min: 10, Max: 20, Desired: 10},
+			percentage: 10,
+			expected:   schemas.Capacity{Min: 1, Max: 20, Desired: 1},
+		},
+		{
+			applied:    schemas.Capacity{Min: 3, Max: 6, Desired: 4},
+			percentage: 30,
+			expected:   schemas.Capacity{Min: 2, Max: 6, Desired: 2},
+		},
+		{
+			applied:    schemas.Capacity{Min: 0, Max: 0, Desired: 0},
+			percentage: 50,
+			expected:   schemas.Capacity{Min: 1, Max: 1, Desired: 1},
+		},
+	}
+
+	for _, td := range testData {
+		if output := GetCanaryCapacity(td.applied, td.percentage); output != td.expected {
+			t.Errorf("expected: %v, output: %v", td.expected, output)
+		}
+	}
+}
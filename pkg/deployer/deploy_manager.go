@@ -17,19 +17,29 @@ limitations under the license.
 package deployer
 
 import (
+	"context"
+
 	"github.com/DevopsArtFactory/goployer/pkg/schemas"
 )
 
 type DeployManager interface {
 	GetStackName() string
-	Deploy(config schemas.Config) error
-	CheckPrevious(config schemas.Config) error
-	HealthChecking(config schemas.Config) map[string]bool
-	FinishAdditionalWork(config schemas.Config) error
-	CleanPreviousVersion(config schemas.Config) error
-	TriggerLifecycleCallbacks(config schemas.Config) error
-	TerminateChecking(config schemas.Config) map[string]bool
-	GatherMetrics(config schemas.Config) error
-	RunAPITest(config schemas.Config) error
+	Deploy(ctx context.Context, config schemas.Config) error
+	CheckPrevious(ctx context.Context, config schemas.Config) error
+	HealthChecking(ctx context.Context, config schemas.Config) map[string]bool
+	FinishAdditionalWork(ctx context.Context, config schemas.Config) error
+	CleanPreviousVersion(ctx context.Context, config schemas.Config) error
+	TriggerLifecycleCallbacks(ctx context.Context, config schemas.Config) error
+	TriggerPostDeployCallbacks(ctx context.Context, config schemas.Config) error
+	Bake(ctx context.Context, config schemas.Config) error
+	TerminateChecking(ctx context.Context, config schemas.Config) map[string]bool
+	GatherMetrics(ctx context.Context, config schemas.Config) error
+	RunAPITest(ctx context.Context, config schemas.Config) error
+	RunPreCleanupAPITest(ctx context.Context, config schemas.Config) error
+	Rollback(ctx context.Context, config schemas.Config) error
+	Cleanup(ctx context.Context, config schemas.Config) error
 	SkipDeployStep()
+	GetState() schemas.DeployerState
+	SetState(state schemas.DeployerState)
+	Plan(ctx context.Context, config schemas.Config) ([]schemas.RegionPlan, error)
 }
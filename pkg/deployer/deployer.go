@@ -18,10 +18,17 @@ package deployer
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
+	"net"
+	"net/http"
 	"os"
+	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"text/tabwriter"
@@ -29,6 +36,9 @@ import (
 
 	eaws "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/olekukonko/tablewriter"
 	Logger "github.com/sirupsen/logrus"
 	vegeta "github.com/tsenart/vegeta/lib"
@@ -60,191 +70,1263 @@ type Deployer struct {
 	Slack             slack.Slack
 	Collector         collector.Collector
 	StepStatus        map[int64]bool
+	CompletedHooks    map[string]bool
+	CheckSuccesses    map[string]int64
+
+	// Target group which listener forwarded traffic to before deployment
+	ActiveTargetGroups map[string]string
+
+	// Whether new autoscaling group is attached to load balancing targets after warmup
+	WarmupAttached map[string]bool
+}
+
+type APIAttacker struct {
+	Name       string
+	Attacker   *vegeta.Attacker
+	Rate       vegeta.Rate
+	Duration   time.Duration
+	Targets    []vegeta.Target
+	Assertions []*schemas.APITestAssertions
+	APIs       []string
+
+	// Raw results of requests are kept only when they are written to API test reports
+	KeepResults bool
+}
+
+// getCurrentVersion returns current version for current deployment step
+func getCurrentVersion(prevVersions []int) int {
+	if len(prevVersions) == 0 {
+		return 0
+	}
+	return (prevVersions[len(prevVersions)-1] + 1) % 1000
+}
+
+// GetAppliedCapacity returns the capacity which is applied to the new autoscaling group
+func (d Deployer) GetAppliedCapacity(forceManifestCapacity bool, region string) schemas.Capacity {
+	capacity := d.GetManifestCapacity(region)
+	if !forceManifestCapacity && d.PrevInstanceCount[region].Desired > capacity.Desired {
+		return d.PrevInstanceCount[region]
+	}
+	return capacity
+}
+
+// GetManifestCapacity returns the capacity of the region if specified, or the capacity of the stack
+func (d Deployer) GetManifestCapacity(region string) schemas.Capacity {
+	for _, rc := range d.Stack.Regions {
+		if rc.Region == region && rc.Capacity != nil {
+			return *rc.Capacity
+		}
+	}
+	return d.Stack.Capacity
+}
+
+// GetLoadBalancingTargets returns load balancers and target groups for the new autoscaling group
+func GetLoadBalancingTargets(region schemas.RegionConfig) ([]string, []string) {
+	healthElb := region.HealthcheckLB
+	loadbalancers := region.LoadBalancers
+	if healthElb != "" && !tool.IsStringInArray(healthElb, loadbalancers) {
+		loadbalancers = append(loadbalancers, healthElb)
+	}
+
+	healthcheckTargetGroup := region.HealthcheckTargetGroup
+	targetGroups := region.TargetGroups
+	if healthcheckTargetGroup != "" && !tool.IsStringInArray(healthcheckTargetGroup, targetGroups) {
+		targetGroups = append(targetGroups, healthcheckTargetGroup)
+	}
+
+	return loadbalancers, targetGroups
+}
+
+// AttachLoadBalancingTargets attaches load balancers and target groups of the region to autoscaling group
+func AttachLoadBalancingTargets(ctx context.Context, client aws.Client, asgName string, region schemas.RegionConfig) error {
+	loadbalancers, targetGroups := GetLoadBalancingTargets(region)
+	if len(targetGroups) > 0 {
+		targetGroupArns, err := client.ELBV2Service.GetTargetGroupARNs(ctx, targetGroups)
+		if err != nil {
+			return err
+		}
+
+		if err := client.EC2Service.AttachTargetGroups(ctx, asgName, targetGroupArns); err != nil {
+			return err
+		}
+	}
+
+	if len(loadbalancers) > 0 {
+		if err := client.EC2Service.AttachLoadBalancers(ctx, asgName, loadbalancers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateNewVersion creates launch template and autoscaling group of the new version in the region
+func (d Deployer) CreateNewVersion(ctx context.Context, config schemas.Config, region schemas.RegionConfig, client aws.Client, capacity schemas.Capacity, loadbalancers, targetGroups []string) (string, error) {
+	// Make Frigga
+	frigga := tool.Frigga{}
+
+	//Setup frigga with prefix
+	frigga.Prefix = tool.BuildPrefixName(d.AwsConfig.Name, d.Stack.Env, region.Region)
+
+	// Get Current Version
+	curVersion := getCurrentVersion(d.PrevVersions[region.Region])
+	d.Logger.Info("Current Version :", curVersion)
+
+	// Generate new name for autoscaling group and launch configuration
+	newAsgName := tool.GenerateAsgName(frigga.Prefix, curVersion)
+	launchTemplateName := tool.GenerateLcName(newAsgName)
+
+	spec, err := d.getLaunchTemplateSpec(ctx, config, region, client)
+	if err != nil {
+		return constants.EmptyString, err
+	}
+
+	// LaunchTemplate
+	err = client.EC2Service.CreateNewLaunchTemplate(
+		ctx,
+		launchTemplateName,
+		spec.ami,
+		spec.instanceType,
+		region.SSHKey,
+		d.Stack.IamInstanceProfile,
+		spec.userdata,
+		d.Stack.EbsOptimized,
+		d.Stack.MixedInstancesPolicy.Enabled,
+		spec.securityGroups,
+		spec.blockDevices,
+		d.Stack.InstanceMarketOptions,
+		region.DetailedMonitoringEnabled,
+	)
+
+	if err != nil {
+		return constants.EmptyString, errors.New("unknown error happened creating new launch template")
+	}
+
+	// launch template should not be left if autoscaling group is not created
+	created := false
+	defer func() {
+		if !created {
+			d.cleanupLaunchTemplate(ctx, client, newAsgName)
+		}
+	}()
+
+	terminationPolicies := []*string{}
+	usePublicSubnets := region.UsePublicSubnets
+	healthcheckType := constants.DefaultHealthcheckType
+	healthcheckGracePeriod := int64(constants.DefaultHealthcheckGracePeriod)
+	availabilityZones, err := client.EC2Service.GetAvailabilityZones(ctx, region.VPC, region.AvailabilityZones)
+	if err != nil {
+		return constants.EmptyString, err
+	}
+	tags := client.EC2Service.GenerateTags(d.AwsConfig.Tags, newAsgName, d.AwsConfig.Name, config.Stack, d.Stack.AnsibleTags, d.Stack.Tags, config.ExtraTags, config.AnsibleExtraVars, region.Region)
+	subnets, err := client.EC2Service.GetSubnets(ctx, region.VPC, usePublicSubnets, availabilityZones)
+	if err != nil {
+		return constants.EmptyString, err
+	}
+	targetGroupArns, err := client.ELBV2Service.GetTargetGroupARNs(ctx, targetGroups)
+	if err != nil {
+		return constants.EmptyString, err
+	}
+
+	var lifecycleHooksSpecificationList []*autoscaling.LifecycleHookSpecification
+	if d.Stack.LifecycleHooks != nil {
+		lifecycleHooksSpecificationList = client.EC2Service.GenerateLifecycleHooks(*d.Stack.LifecycleHooks)
+	}
+
+	_, err = client.EC2Service.CreateAutoScalingGroup(
+		ctx,
+		newAsgName,
+		launchTemplateName,
+		healthcheckType,
+		healthcheckGracePeriod,
+		capacity,
+		loadbalancers,
+		availabilityZones,
+		targetGroupArns,
+		terminationPolicies,
+		tags,
+		subnets,
+		d.Stack.MixedInstancesPolicy,
+		lifecycleHooksSpecificationList,
+	)
+
+	if err != nil {
+		return constants.EmptyString, err
+	}
+	created = true
+
+	if d.Collector.MetricConfig.Enabled {
+		additionalFields := map[string]string{}
+		if len(config.ReleaseNotes) > 0 {
+			additionalFields["release-notes"] = config.ReleaseNotes
+		}
+
+		if len(config.ReleaseNotesBase64) > 0 {
+			additionalFields["release-notes-base64"] = config.ReleaseNotesBase64
+		}
+
+		if len(spec.userdata) > 0 {
+			additionalFields["userdata"] = spec.userdata
+		}
+
+		if appliedCapacity, err := json.Marshal(capacity); err == nil {
+			additionalFields[constants.AppliedCapacityKey] = string(appliedCapacity)
+		} else {
+			d.Logger.Warnf("cannot record applied capacity : %s", err.Error())
+		}
+
+		d.Collector.StampDeployment(d.Stack, config, tags, newAsgName, "creating", additionalFields)
+	}
+
+	return newAsgName, nil
+}
+
+// PlanNewVersion resolves configurations of new autoscaling group only with read-only calls
+func (d Deployer) PlanNewVersion(ctx context.Context, config schemas.Config, region schemas.RegionConfig, client aws.Client, capacity schemas.Capacity, loadbalancers, targetGroups []string) (schemas.RegionPlan, error) {
+	prefix := tool.BuildPrefixName(d.AwsConfig.Name, d.Stack.Env, region.Region)
+	newAsgName := tool.GenerateAsgName(prefix, getCurrentVersion(d.PrevVersions[region.Region]))
+
+	spec, err := d.getLaunchTemplateSpec(ctx, config, region, client)
+	if err != nil {
+		return schemas.RegionPlan{}, err
+	}
+
+	availabilityZones, err := client.EC2Service.GetAvailabilityZones(ctx, region.VPC, region.AvailabilityZones)
+	if err != nil {
+		return schemas.RegionPlan{}, err
+	}
+
+	subnets, err := client.EC2Service.GetSubnets(ctx, region.VPC, region.UsePublicSubnets, availabilityZones)
+	if err != nil {
+		return schemas.RegionPlan{}, err
+	}
+
+	targetGroupArns, err := client.ELBV2Service.GetTargetGroupARNs(ctx, targetGroups)
+	if err != nil {
+		return schemas.RegionPlan{}, err
+	}
+
+	tags := []string{}
+	for _, tag := range client.EC2Service.GenerateTags(d.AwsConfig.Tags, newAsgName, d.AwsConfig.Name, config.Stack, d.Stack.AnsibleTags, d.Stack.Tags, config.ExtraTags, config.AnsibleExtraVars, region.Region) {
+		tags = append(tags, fmt.Sprintf("%s=%s", *tag.Key, *tag.Value))
+	}
+
+	return schemas.RegionPlan{
+		Stack:              d.Stack.Stack,
+		Region:             region.Region,
+		ReplacementType:    d.Stack.ReplacementType,
+		AutoScalingGroup:   newAsgName,
+		LaunchTemplate:     tool.GenerateLcName(newAsgName),
+		Ami:                spec.ami,
+		InstanceType:       spec.instanceType,
+		SSHKey:             region.SSHKey,
+		IamInstanceProfile: d.Stack.IamInstanceProfile,
+		SecurityGroups:     eaws.StringValueSlice(spec.securityGroups),
+		AvailabilityZones:  availabilityZones,
+		Subnets:            subnets,
+		LoadBalancers:      loadbalancers,
+		TargetGroups:       eaws.StringValueSlice(targetGroupArns),
+		Tags:               tags,
+		Capacity:           &capacity,
+	}, nil
+}
+
+// launchTemplateSpec is a set of values which differ between deployments in launch template
+type launchTemplateSpec struct {
+	ami            string
+	instanceType   string
+	userdata       string
+	securityGroups []*string
+	blockDevices   []*ec2.LaunchTemplateBlockDeviceMappingRequest
+}
+
+// getLaunchTemplateSpec makes launch template specification of the region
+func (d Deployer) getLaunchTemplateSpec(ctx context.Context, config schemas.Config, region schemas.RegionConfig, client aws.Client) (launchTemplateSpec, error) {
+	//Get AMI
+	var ami string
+	if len(config.Ami) > 0 {
+		ami = config.Ami
+	} else {
+		ami = region.AmiID
+	}
+
+	// userdata of previous deployment is reused when rolling back
+	userdata := config.OverrideUserdata
+	if len(userdata) == 0 {
+		var err error
+		userdata, err = (d.LocalProvider).Provide()
+		if err != nil {
+			return launchTemplateSpec{}, err
+		}
+	}
+
+	//Stack check
+	securityGroups, err := client.EC2Service.GetSecurityGroupList(ctx, region.VPC, region.SecurityGroups)
+	if err != nil {
+		return launchTemplateSpec{}, err
+	}
+	blockDevices := client.EC2Service.MakeLaunchTemplateBlockDeviceMappings(d.Stack.BlockDevices)
+
+	// Instance Type Override
+	instanceType := region.InstanceType
+	if len(config.OverrideInstanceType) > 0 {
+		instanceType = config.OverrideInstanceType
+
+		if d.Stack.MixedInstancesPolicy.Enabled {
+			Logger.Warnf("--override-instance-type won't be applied because mixed_instances_policy is enabled")
+		}
+	}
+
+	return launchTemplateSpec{
+		ami:            ami,
+		instanceType:   instanceType,
+		userdata:       userdata,
+		securityGroups: securityGroups,
+		blockDevices:   blockDevices,
+	}, nil
+}
+
+// GetTargetGroupArn returns ARN of the target group whether a name or an ARN is specified
+func GetTargetGroupArn(ctx context.Context, client aws.Client, targetGroup, region string) (*string, error) {
+	if tool.IsTargetGroupArn(targetGroup, region) {
+		return &targetGroup, nil
+	}
+
+	tgArns, err := client.ELBV2Service.GetTargetGroupARNs(ctx, []string{targetGroup})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tgArns) == 0 {
+		return nil, fmt.Errorf("no target group exists: %s", targetGroup)
+	}
+
+	return tgArns[0], nil
+}
+
+// polling is polling healthy information from instance/target group
+func (d Deployer) polling(ctx context.Context, region schemas.RegionConfig, asg *autoscaling.Group, client aws.Client, threshold int64, isUpdate, downsizingUpdate bool) (bool, error) {
+	if *asg.AutoScalingGroupName == "" {
+		return false, fmt.Errorf("no autoscaling found for %s", d.AsgNames[region.Region])
+	}
+
+	if err := d.completeLaunchHooks(ctx, client, asg); err != nil {
+		return false, err
+	}
+
+	if region.HealthcheckTargetGroup == "" && region.HealthcheckLB == "" && region.Healthcheck == nil {
+		d.Logger.Infof("healthcheck skipped because of neither target group nor classic load balancer specified")
+		return true, nil
+	}
+
+	var targetHosts []aws.HealthcheckHost
+	var err error
+	validHostCount := int64(0)
+
+	d.Logger.Debugf("[Checking healthy host count] Autoscaling Group: %s", *asg.AutoScalingGroupName)
+	if region.HealthcheckTargetGroup != "" {
+		healthcheckTargetGroupArn, err := GetTargetGroupArn(ctx, client, region.HealthcheckTargetGroup, region.Region)
+		if err != nil {
+			return false, err
+		}
+
+		targetHosts, err = client.ELBV2Service.GetHostInTarget(ctx, asg, healthcheckTargetGroupArn, isUpdate, downsizingUpdate)
+		if err != nil {
+			return false, err
+		}
+	} else if region.HealthcheckLB != "" {
+		targetHosts, err = client.ELBService.GetHealthyHostInELB(ctx, asg, region.HealthcheckLB)
+		if err != nil {
+			return false, err
+		}
+	} else if region.Healthcheck != nil {
+		targetHosts, err = d.getCheckedHosts(ctx, client, asg, *region.Healthcheck)
+		if err != nil {
+			return false, err
+		}
+	}
+	validHostCount = d.GetValidHostCount(targetHosts)
+
+	if isUpdate {
+		if validHostCount == threshold {
+			d.Logger.Infof("[Update completed] current / desired : %d/%d", validHostCount, threshold)
+			return true, nil
+		}
+		d.Logger.Infof("Desired count does not meet the requirement: %d/%d", validHostCount, threshold)
+	} else {
+		if validHostCount >= threshold {
+			d.Logger.Infof("Healthy Count for %s : %d/%d", d.AsgNames[region.Region], validHostCount, threshold)
+			d.Slack.SendSimpleMessage(fmt.Sprintf("All instances are healthy in %s  :  %d/%d", d.AsgNames[region.Region], validHostCount, threshold))
+			return true, nil
+		}
+
+		d.Logger.Infof("Healthy count does not meet the requirement(%s) : %d/%d", d.AsgNames[region.Region], validHostCount, threshold)
+		d.Slack.SendSimpleMessage(fmt.Sprintf("Waiting for healthy instances %s  :  %d/%d", d.AsgNames[region.Region], validHostCount, threshold))
+	}
+	return false, nil
+}
+
+// CheckTerminating checks if all of instances are terminated well
+func (d Deployer) CheckTerminating(ctx context.Context, client aws.Client, target string, disableMetrics bool) bool {
+	asgInfo, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, target)
+	if err != nil {
+		d.Logger.Errorf(err.Error())
+		return true
+	}
+
+	if asgInfo == nil {
+		d.Logger.Info("Already deleted autoscaling group : ", target)
+		return true
+	}
+
+	d.Logger.Info(fmt.Sprintf("Waiting for instance termination in asg %s", target))
+	if len(asgInfo.Instances) > 0 {
+		d.Logger.Infof("%d instance found : %s", len(asgInfo.Instances), target)
+		d.Slack.SendSimpleMessage(fmt.Sprintf("Still %d instance found : %s", len(asgInfo.Instances), target))
+
+		return false
+	}
+	d.Slack.SendSimpleMessage(fmt.Sprintf(":+1: All instances are deleted : %s", target))
+
+	if err := d.CleanAutoscalingSet(ctx, client, target); err != nil {
+		d.Logger.Errorf(err.Error())
+		return false
+	}
+
+	if !disableMetrics {
+		d.Logger.Debugf("update status of autoscaling group to teminated : %s", target)
+		if err := d.Collector.UpdateStatus(target, "terminated", nil); err != nil {
+			d.Logger.Errorf(err.Error())
+			return false
+		}
+		d.Logger.Debugf("update status of %s is finished", target)
+	}
+
+	d.Logger.Debugf("Start deleting launch templates in %s", target)
+	if err := client.EC2Service.DeleteLaunchTemplates(ctx, target); err != nil {
+		d.Logger.Errorln(err.Error())
+		return false
+	}
+	d.Logger.Debugf("Launch templates are deleted in %s\n", target)
+
+	return true
+}
+
+// CleanAutoscalingSet cleans autoscaling group itself
+func (d Deployer) CleanAutoscalingSet(ctx context.Context, client aws.Client, target string) error {
+	d.Logger.Debugf("Start deleting autoscaling group : %s", target)
+	if err := client.EC2Service.DeleteAutoscalingSet(ctx, target); err != nil {
+		return err
+	}
+	d.Logger.Debugf("Autoscaling group is deleted : %s", target)
+
+	return nil
+}
+
+// GetState returns state of deployer to be persisted
+func (d Deployer) GetState() schemas.DeployerState {
+	return schemas.DeployerState{
+		Stack:             d.Stack.Stack,
+		AsgNames:          d.AsgNames,
+		PrevAsgs:          d.PrevAsgs,
+		PrevInstances:     d.PrevInstances,
+		PrevVersions:      d.PrevVersions,
+		PrevInstanceCount: d.PrevInstanceCount,
+		StepStatus:        d.StepStatus,
+
+		ActiveTargetGroups: d.ActiveTargetGroups,
+		WarmupAttached:     d.WarmupAttached,
+	}
+}
+
+// SetState restores state of deployer from persisted one
+func (d Deployer) SetState(state schemas.DeployerState) {
+	for region, asg := range state.AsgNames {
+		d.AsgNames[region] = asg
+	}
+
+	for region, asgs := range state.PrevAsgs {
+		d.PrevAsgs[region] = asgs
+	}
+
+	for region, instances := range state.PrevInstances {
+		d.PrevInstances[region] = instances
+	}
+
+	for region, versions := range state.PrevVersions {
+		d.PrevVersions[region] = versions
+	}
+
+	for region, capacity := range state.PrevInstanceCount {
+		d.PrevInstanceCount[region] = capacity
+	}
+
+	for step, done := range state.StepStatus {
+		d.StepStatus[step] = done
+	}
+
+	for region, tg := range state.ActiveTargetGroups {
+		d.ActiveTargetGroups[region] = tg
+	}
+
+	for region, attached := range state.WarmupAttached {
+		d.WarmupAttached[region] = attached
+	}
+}
+
+// SelectStandbyAsgs splits previous autoscaling groups into the newest ones retained as standby and the others to be deleted
+func SelectStandbyAsgs(prevAsgs []string, retain int64) ([]string, []string) {
+	sorted := make([]string, len(prevAsgs))
+	copy(sorted, prevAsgs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return tool.ParseVersion(sorted[i]) > tool.ParseVersion(sorted[j])
+	})
+
+	if retain > int64(len(sorted)) {
+		retain = int64(len(sorted))
+	}
+
+	if retain < 0 {
+		retain = 0
+	}
+
+	return sorted[:retain], sorted[retain:]
+}
+
+// MarkStandby tags autoscaling group as standby and suspends processes which can scale it out
+func (d Deployer) MarkStandby(ctx context.Context, client aws.Client, asg string) error {
+	d.Logger.Infof("Retain autoscaling group as standby : %s", asg)
+	if err := client.EC2Service.SuspendProcesses(ctx, asg, constants.StandbySuspendedProcesses); err != nil {
+		return err
+	}
+
+	return client.EC2Service.UpdateStandbyTag(ctx, asg, true)
+}
+
+// CheckStandby checks if all instances of standby autoscaling group are terminated
+func (d Deployer) CheckStandby(ctx context.Context, client aws.Client, target string, disableMetrics bool) bool {
+	asgInfo, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, target)
+	if err != nil {
+		d.Logger.Errorf(err.Error())
+		return true
+	}
+
+	if asgInfo == nil {
+		d.Logger.Warnf("Standby autoscaling group does not exist : %s", target)
+		return true
+	}
+
+	if len(asgInfo.Instances) > 0 {
+		d.Logger.Infof("%d instance found : %s", len(asgInfo.Instances), target)
+		return false
+	}
+
+	if !disableMetrics {
+		d.Logger.Debugf("update status of autoscaling group to standby : %s", target)
+		if err := d.Collector.UpdateStatus(target, "standby", nil); err != nil {
+			d.Logger.Errorf(err.Error())
+			return false
+		}
+	}
+
+	return true
+}
+
+// ResizingAutoScalingGroupToZero set autoscaling group instance count to 0
+func (d Deployer) ResizingAutoScalingGroupToZero(ctx context.Context, client aws.Client, stack, asg string) error {
+	d.Logger.Info(fmt.Sprintf("Modifying the size of autoscaling group to 0 : %s(%s)", asg, stack))
+	d.Slack.SendSimpleMessage(fmt.Sprintf("Modifying the size of autoscaling group to 0 : %s/%s", asg, stack))
+
+	retry := int64(3)
+	var err error
+	for {
+		retry, err = client.EC2Service.UpdateAutoScalingGroupSize(ctx, asg, 0, 0, 0, retry)
+		if err != nil {
+			if retry > 0 {
+				d.Logger.Debugf("error occurred and remained retry count is %d", retry)
+				time.Sleep(time.Duration(1+(2-retry)) * time.Second)
+			} else {
+				return err
+			}
+		}
+
+		if err == nil {
+			break
+		}
+	}
+
+	return nil
+}
+
+// DeleteNewVersion deletes autoscaling group and launch template of the new version
+func (d Deployer) DeleteNewVersion(ctx context.Context, client aws.Client, asg string) error {
+	d.Logger.Infof("Delete autoscaling group of the new version : %s", asg)
+	if err := client.EC2Service.ForceDeleteAutoscalingGroup(ctx, asg); err != nil {
+		return err
+	}
+
+	if err := client.EC2Service.DeleteLaunchTemplates(ctx, asg); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ResetDeployStep clears the new version so that it is launched again when deployment is resumed
+func (d Deployer) ResetDeployStep() {
+	for region := range d.AsgNames {
+		delete(d.AsgNames, region)
+	}
+	d.StepStatus[constants.StepDeploy] = false
+}
+
+// cleanupLaunchTemplate deletes launch templates of autoscaling group which is not created
+func (d Deployer) cleanupLaunchTemplate(ctx context.Context, client aws.Client, asg string) {
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), constants.CleanupTimeout)
+		defer cancel()
+	}
+
+	if err := client.EC2Service.DeleteLaunchTemplates(ctx, asg); err != nil {
+		d.Logger.Errorf("failed to delete launch template of %s: %s", asg, err.Error())
+	}
+}
+
+// MarkRolledBack records rollback of the new version and sends failure notification
+func (d Deployer) MarkRolledBack(asg string) {
+	if d.Collector.MetricConfig.Enabled {
+		if err := d.Collector.UpdateStatus(asg, "rolled_back", nil); err != nil {
+			Logger.Errorf("Update status Error, %s : %s", err.Error(), asg)
+		}
+	}
+
+	d.Logger.Errorf("Deployment failed and the new version is rolled back : %s", asg)
+	d.Slack.SendSimpleMessage(fmt.Sprintf(":x: Deployment failed and the new version is rolled back : %s", asg))
+}
+
+// SetFastRollbackTarget makes the current version a previous version of the standby version
+// so that it is cleaned up in the same way as previous versions after fast rollback
+func (d Deployer) SetFastRollbackTarget(region string, current *autoscaling.Group, target string) {
+	instances := []string{}
+	for _, instance := range current.Instances {
+		instances = append(instances, *instance.InstanceId)
+	}
+
+	d.AsgNames[region] = target
+	d.PrevAsgs[region] = []string{*current.AutoScalingGroupName}
+	d.PrevInstances[region] = instances
+	d.StepStatus[constants.StepAdditionalWork] = true
+}
+
+// MarkFastRolledBack records that the version is replaced with the standby version by fast rollback
+func (d Deployer) MarkFastRolledBack(asg, target string) {
+	if d.Collector.MetricConfig.Enabled {
+		if err := d.Collector.UpdateStatus(asg, "fast_rolled_back", nil); err != nil {
+			Logger.Errorf("Update status Error, %s : %s", err.Error(), asg)
+		}
+	}
+
+	d.Logger.Infof("The version is replaced with standby version by fast rollback : %s -> %s", asg, target)
+	d.Slack.SendSimpleMessage(fmt.Sprintf("The version is replaced with standby version by fast rollback : %s -> %s", asg, target))
+}
+
+// RunLifecycleCallbacks runs commands before terminating.
+func (d Deployer) RunLifecycleCallbacks(ctx context.Context, client aws.Client, target []string) error {
+	if len(target) == 0 {
+		d.Logger.Debugf("no target instance exists\n")
+		return nil
+	}
+
+	commands := d.Stack.LifecycleCallbacks.PreTerminatePastClusters
+
+	d.Logger.Debugf("run lifecycle callbacks before termination : %s", target)
+	if err := d.runCommands(ctx, client, target, commands, getCallbackTimeout(d.Stack.LifecycleCallbacks)); err != nil {
+		return fmt.Errorf("failed to run pre_terminate_past_cluster callbacks : %s", err.Error())
+	}
+
+	return nil
+}
+
+// RunPostDeployCallbacks runs commands on new autoscaling group and local machine after it becomes healthy
+func (d Deployer) RunPostDeployCallbacks(ctx context.Context, client aws.Client, region string) error {
+	callbacks := d.Stack.LifecycleCallbacks
+	asg := d.AsgNames[region]
+
+	group, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, asg)
+	if err != nil {
+		return err
+	}
+	instances := getInServiceInstances(group)
+
+	if len(callbacks.PostDeployNewClusters) > 0 || len(callbacks.RunOnce) > 0 {
+		if len(instances) == 0 {
+			return fmt.Errorf("no instance in service to run callbacks : %s", asg)
+		}
+	}
+
+	if len(callbacks.PostDeployNewClusters) > 0 {
+		d.Logger.Infof("run lifecycle callbacks on new version : %s", asg)
+		if err := d.runCommands(ctx, client, instances, callbacks.PostDeployNewClusters, getCallbackTimeout(callbacks)); err != nil {
+			return fmt.Errorf("failed to run post_deploy_new_cluster callbacks of %s : %s", asg, err.Error())
+		}
+	}
+
+	if len(callbacks.RunOnce) > 0 {
+		d.Logger.Infof("run lifecycle callbacks on a single instance of new version : %s(%s)", asg, instances[0])
+		if err := d.runCommands(ctx, client, instances[:1], callbacks.RunOnce, getCallbackTimeout(callbacks)); err != nil {
+			return fmt.Errorf("failed to run run_once callbacks of %s : %s", asg, err.Error())
+		}
+	}
+
+	if len(callbacks.Local) > 0 {
+		env := []string{
+			fmt.Sprintf("GOPLOYER_STACK=%s", d.Stack.Stack),
+			fmt.Sprintf("GOPLOYER_REGION=%s", region),
+			fmt.Sprintf("GOPLOYER_ASG=%s", asg),
+			fmt.Sprintf("GOPLOYER_INSTANCE_IDS=%s", strings.Join(instances, ",")),
+		}
+
+		for _, command := range callbacks.Local {
+			d.Logger.Infof("run local callback : %s", command)
+			out, err := runLocalCommand(ctx, command, env)
+			if len(out) > 0 {
+				d.Logger.Info(out)
+			}
+
+			if err != nil {
+				return fmt.Errorf("failed to run local callback %q : %s", command, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// runCommands sends commands to instances via SSM and waits until all of them finish or timeout expires
+// Output and exit status of the commands are printed for each instance
+func (d Deployer) runCommands(ctx context.Context, client aws.Client, instances []string, commands []string, timeout time.Duration) error {
+	commandID, err := client.SSMService.SendCommand(ctx, eaws.StringSlice(instances), eaws.StringSlice(commands), timeout)
+	if err != nil {
+		return err
+	}
+	d.Logger.Debugf("command is sent to %d instances : %s", len(instances), commandID)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		invocations, err := client.SSMService.ListCommandInvocations(ctx, commandID)
+		if err != nil {
+			return err
+		}
+
+		if isCommandFinished(invocations, len(instances)) {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for the command after %s : %s", timeout, commandID)
+		}
+
+		d.Logger.Debugf("waiting for the command to finish : %s", commandID)
+		if err := tool.Sleep(ctx, constants.CommandPollingInterval); err != nil {
+			return err
+		}
+	}
+
+	failed := []string{}
+	for _, instance := range instances {
+		result, err := client.SSMService.GetCommandInvocation(ctx, commandID, instance)
+		if err != nil {
+			return err
+		}
+
+		d.Logger.Infof("[%s] command %s with exit code %d", instance, eaws.StringValue(result.Status), eaws.Int64Value(result.ResponseCode))
+		if out := strings.TrimSpace(eaws.StringValue(result.StandardOutputContent)); len(out) > 0 {
+			d.Logger.Infof("[%s] stdout:\n%s", instance, out)
+		}
+		if out := strings.TrimSpace(eaws.StringValue(result.StandardErrorContent)); len(out) > 0 {
+			d.Logger.Infof("[%s] stderr:\n%s", instance, out)
+		}
+
+		if eaws.StringValue(result.Status) != ssm.CommandInvocationStatusSuccess {
+			failed = append(failed, instance)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("command failed on instances : %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// isCommandFinished checks if the command is done on all target instances
+func isCommandFinished(invocations []*ssm.CommandInvocation, targets int) bool {
+	if len(invocations) < targets {
+		return false
+	}
+
+	for _, invocation := range invocations {
+		switch eaws.StringValue(invocation.Status) {
+		case ssm.CommandInvocationStatusPending, ssm.CommandInvocationStatusInProgress, ssm.CommandInvocationStatusDelayed, ssm.CommandInvocationStatusCancelling:
+			return false
+		}
+	}
+
+	return true
+}
+
+// getCallbackTimeout returns time to wait for each lifecycle callback
+func getCallbackTimeout(callbacks *schemas.LifecycleCallbacks) time.Duration {
+	if callbacks == nil || callbacks.Timeout == 0 {
+		return constants.DefaultCallbackTimeout
+	}
+
+	return callbacks.Timeout
+}
+
+// completeLaunchHooks validates instances waiting for launch lifecycle hooks and completes the hooks with the result
+// Instances which fail validation are abandoned so that they never go in service
+func (d Deployer) completeLaunchHooks(ctx context.Context, client aws.Client, asg *autoscaling.Group) error {
+	hooks := getValidatedLaunchHooks(d.Stack.LifecycleHooks)
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	instances := getWaitingInstances(asg)
+	if len(instances) == 0 {
+		return nil
+	}
+
+	ips, err := client.EC2Service.GetPrivateIPAddresses(ctx, instances)
+	if err != nil {
+		return err
+	}
+
+	asgName := *asg.AutoScalingGroupName
+	for _, hook := range hooks {
+		targets := []string{}
+		for _, instance := range instances {
+			if !d.CompletedHooks[hookKey(instance, hook.LifecycleHookName)] {
+				targets = append(targets, instance)
+			}
+		}
+
+		// instances are validated at the same time not to wait for timeout of each instance
+		results := make([]error, len(targets))
+		wg := sync.WaitGroup{}
+		for i, instance := range targets {
+			wg.Add(1)
+			go func(i int, instance string) {
+				defer wg.Done()
+				d.Logger.Infof("validate instance for lifecycle hook %s : %s", hook.LifecycleHookName, instance)
+				results[i] = d.checkInstance(ctx, client, *hook.Validation, instance, ips[instance])
+			}(i, instance)
+		}
+		wg.Wait()
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		for i, instance := range targets {
+			result := constants.LifecycleActionContinue
+			if results[i] != nil {
+				result = constants.LifecycleActionAbandon
+				d.Logger.Errorf("instance failed validation of lifecycle hook %s : %s, %s", hook.LifecycleHookName, instance, results[i].Error())
+				d.Slack.SendSimpleMessage(fmt.Sprintf(":x: Instance is abandoned because of failed validation : %s(%s)", instance, asgName))
+			}
+
+			if err := client.EC2Service.CompleteLifecycleAction(ctx, asgName, hook.LifecycleHookName, instance, result); err != nil {
+				return err
+			}
+			d.CompletedHooks[hookKey(instance, hook.LifecycleHookName)] = true
+			d.Logger.Infof("lifecycle action of %s is completed with %s : %s", hook.LifecycleHookName, result, instance)
+		}
+	}
+
+	return nil
+}
+
+// checkInstance retries the check against an instance until it passes or timeout expires
+func (d Deployer) checkInstance(ctx context.Context, client aws.Client, check schemas.InstanceCheck, instance, ip string) error {
+	deadline := time.Now().Add(getCheckTimeout(check))
+	for {
+		err := d.probeInstance(ctx, client, check, instance, ip)
+
+		// ssm command already waits for the timeout
+		if err == nil || check.Type == constants.SSMCheck || time.Now().After(deadline) {
+			return err
+		}
+
+		d.Logger.Debugf("instance is not ready yet : %s, %s", instance, err.Error())
+		if err := tool.Sleep(ctx, constants.InstanceCheckInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// probeInstance runs the check against an instance once
+func (d Deployer) probeInstance(ctx context.Context, client aws.Client, check schemas.InstanceCheck, instance, ip string) error {
+	if check.Type == constants.SSMCheck {
+		return d.runCommands(ctx, client, []string{instance}, check.Commands, getCheckTimeout(check))
+	}
+
+	if len(ip) == 0 {
+		return fmt.Errorf("no private ip address of instance : %s", instance)
+	}
+
+	switch check.Type {
+	case constants.HTTPCheck:
+		return probeHTTP(ctx, getCheckURL(check, ip), check.ExpectedStatus)
+	case constants.TCPCheck:
+		return probeTCP(ctx, fmt.Sprintf("%s:%d", ip, check.Port))
+	}
+
+	return fmt.Errorf("unsupported type of instance check : %s", check.Type)
+}
+
+// getCheckedHosts checks instances in service directly and returns the status of them
+// Instance is valid after it passes the check as many times in a row as success count
+func (d Deployer) getCheckedHosts(ctx context.Context, client aws.Client, asg *autoscaling.Group, check schemas.InstanceCheck) ([]aws.HealthcheckHost, error) {
+	instances := getInServiceInstances(asg)
+	ips := map[string]string{}
+	if len(instances) > 0 && check.Type != constants.SSMCheck {
+		var err error
+		ips, err = client.EC2Service.GetPrivateIPAddresses(ctx, instances)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]error, len(instances))
+	wg := sync.WaitGroup{}
+	for i, instance := range instances {
+		wg.Add(1)
+		go func(i int, instance string) {
+			defer wg.Done()
+			results[i] = d.probeInstance(ctx, client, check, instance, ips[instance])
+		}(i, instance)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, instance := range instances {
+		if results[i] != nil {
+			d.Logger.Debugf("instance failed healthcheck : %s, %s", instance, results[i].Error())
+			d.CheckSuccesses[instance] = 0
+			continue
+		}
+		d.CheckSuccesses[instance]++
+	}
+
+	successCount := check.SuccessCount
+	if successCount == 0 {
+		successCount = 1
+	}
+
+	ret := []aws.HealthcheckHost{}
+	for _, instance := range asg.Instances {
+		count := d.CheckSuccesses[*instance.InstanceId]
+		if *instance.LifecycleState != constants.InServiceStatus {
+			count = 0
+		}
+
+		ret = append(ret, aws.HealthcheckHost{
+			InstanceID:     *instance.InstanceId,
+			LifecycleState: *instance.LifecycleState,
+			TargetStatus:   fmt.Sprintf("%s %d/%d", check.Type, count, successCount),
+			HealthStatus:   *instance.HealthStatus,
+			Valid:          *instance.LifecycleState == constants.InServiceStatus && *instance.HealthStatus == "Healthy" && count >= successCount,
+		})
+	}
+
+	return ret, nil
 }
 
-type APIAttacker struct {
-	Name     string
-	Attacker *vegeta.Attacker
-	Rate     vegeta.Rate
-	Duration time.Duration
-	Targets  []vegeta.Target
+// getCheckTimeout returns time to wait for an instance to pass the check
+func getCheckTimeout(check schemas.InstanceCheck) time.Duration {
+	if check.Timeout == 0 {
+		return constants.DefaultInstanceCheckTimeout
+	}
+
+	return check.Timeout
 }
 
-// getCurrentVersion returns current version for current deployment step
-func getCurrentVersion(prevVersions []int) int {
-	if len(prevVersions) == 0 {
-		return 0
+// probeTCP checks if tcp connection to the address is established
+func probeTCP(ctx context.Context, address string) error {
+	dialer := net.Dialer{Timeout: constants.InstanceCheckInterval}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
 	}
-	return (prevVersions[len(prevVersions)-1] + 1) % 1000
+
+	return conn.Close()
 }
 
-// polling is polling healthy information from instance/target group
-func (d Deployer) polling(region schemas.RegionConfig, asg *autoscaling.Group, client aws.Client, forceManifestCapacity, isUpdate, downsizingUpdate bool) (bool, error) {
-	if *asg.AutoScalingGroupName == "" {
-		return false, fmt.Errorf("no autoscaling found for %s", d.AsgNames[region.Region])
+// probeHTTP sends GET request and checks if the response has the expected status
+// Any 2xx or 3xx status is expected if expected status is not specified
+func probeHTTP(ctx context.Context, url string, expectedStatus int) error {
+	reqCtx, cancel := context.WithTimeout(ctx, constants.InstanceCheckInterval)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
 	}
 
-	var threshold int64
-	if !forceManifestCapacity && d.PrevInstanceCount[region.Region].Desired > d.Stack.Capacity.Desired {
-		threshold = d.PrevInstanceCount[region.Region].Desired
-	} else {
-		threshold = d.Stack.Capacity.Desired
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
 	}
+	defer resp.Body.Close()
 
-	if region.HealthcheckTargetGroup == "" && region.HealthcheckLB == "" {
-		d.Logger.Infof("healthcheck skipped because of neither target group nor classic load balancer specified")
-		return true, nil
+	if !isExpectedStatus(resp.StatusCode, expectedStatus) {
+		return fmt.Errorf("unexpected status code from %s : %d", url, resp.StatusCode)
 	}
 
-	var targetHosts []aws.HealthcheckHost
-	var err error
-	validHostCount := int64(0)
+	return nil
+}
 
-	d.Logger.Debugf("[Checking healthy host count] Autoscaling Group: %s", *asg.AutoScalingGroupName)
-	if region.HealthcheckTargetGroup != "" {
-		var healthcheckTargetGroupArn *string
-		if tool.IsTargetGroupArn(region.HealthcheckTargetGroup, region.Region) {
-			healthcheckTargetGroupArn = &region.HealthcheckTargetGroup
-		} else {
-			tgs := []string{region.HealthcheckTargetGroup}
-			tgArns, err := client.ELBV2Service.GetTargetGroupARNs(tgs)
-			if err != nil {
-				fmt.Println(err)
-				return false, err
-			}
-			healthcheckTargetGroupArn = tgArns[0]
-		}
+// isExpectedStatus checks if the status code of http response is expected
+func isExpectedStatus(status, expected int) bool {
+	if expected > 0 {
+		return status == expected
+	}
 
-		targetHosts, err = client.ELBV2Service.GetHostInTarget(asg, healthcheckTargetGroupArn, isUpdate, downsizingUpdate)
-		if err != nil {
-			return false, err
+	return status >= http.StatusOK && status < http.StatusBadRequest
+}
+
+// getCheckURL returns url of http check for the instance
+func getCheckURL(check schemas.InstanceCheck, ip string) string {
+	path := check.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return fmt.Sprintf("http://%s:%d%s", ip, check.Port, path)
+}
+
+// getValidatedLaunchHooks returns launch lifecycle hooks which goployer completes after validation
+func getValidatedLaunchHooks(hooks *schemas.LifecycleHooks) []schemas.LifecycleHookSpecification {
+	ret := []schemas.LifecycleHookSpecification{}
+	if hooks == nil {
+		return ret
+	}
+
+	for _, hook := range hooks.LaunchTransition {
+		if hook.Validation != nil {
+			ret = append(ret, hook)
 		}
-	} else if region.HealthcheckLB != "" {
-		targetHosts, err = client.ELBService.GetHealthyHostInELB(asg, region.HealthcheckLB)
-		if err != nil {
-			return false, err
+	}
+
+	return ret
+}
+
+// getWaitingInstances returns sorted ids of instances waiting for lifecycle action to launch
+func getWaitingInstances(group *autoscaling.Group) []string {
+	instances := []string{}
+	for _, instance := range group.Instances {
+		if *instance.LifecycleState == autoscaling.LifecycleStatePendingWait {
+			instances = append(instances, *instance.InstanceId)
 		}
 	}
-	validHostCount = d.GetValidHostCount(targetHosts)
+	sort.Strings(instances)
 
-	if isUpdate {
-		if validHostCount == threshold {
-			d.Logger.Infof("[Update completed] current / desired : %d/%d", validHostCount, threshold)
-			return true, nil
+	return instances
+}
+
+// hookKey returns key of lifecycle action for the instance
+func hookKey(instance, hook string) string {
+	return fmt.Sprintf("%s/%s", instance, hook)
+}
+
+// PrepareAPITest attaches new version to the API test target group and waits for it to be healthy
+// It returns private ip addresses of instances in service
+func (d Deployer) PrepareAPITest(ctx context.Context, client aws.Client, region schemas.RegionConfig, config schemas.Config) ([]string, error) {
+	asgName := d.AsgNames[region.Region]
+	if len(region.APITestTargetGroup) > 0 {
+		tgArn, err := GetTargetGroupArn(ctx, client, region.APITestTargetGroup, region.Region)
+		if err != nil {
+			return nil, err
 		}
-		d.Logger.Infof("Desired count does not meet the requirement: %d/%d", validHostCount, threshold)
-	} else {
-		if validHostCount >= threshold {
-			d.Logger.Infof("Healthy Count for %s : %d/%d", d.AsgNames[region.Region], validHostCount, threshold)
-			d.Slack.SendSimpleMessage(fmt.Sprintf("All instances are healthy in %s  :  %d/%d", d.AsgNames[region.Region], validHostCount, threshold))
-			return true, nil
+
+		if err := client.EC2Service.AttachTargetGroups(ctx, asgName, []*string{tgArn}); err != nil {
+			return nil, err
 		}
+		d.Logger.Infof("new version is attached to API test target group : %s", region.APITestTargetGroup)
 
-		d.Logger.Infof("Healthy count does not meet the requirement(%s) : %d/%d", d.AsgNames[region.Region], validHostCount, threshold)
-		d.Slack.SendSimpleMessage(fmt.Sprintf("Waiting for healthy instances %s  :  %d/%d", d.AsgNames[region.Region], validHostCount, threshold))
+		// healthcheck is done with the test target group only
+		testRegion := region
+		testRegion.HealthcheckTargetGroup = region.APITestTargetGroup
+		testRegion.HealthcheckLB = constants.EmptyString
+		testRegion.Healthcheck = nil
+
+		threshold := d.GetAppliedCapacity(config.ForceManifestCapacity, region.Region).Desired
+		for {
+			asg, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, asgName)
+			if err != nil {
+				return nil, err
+			}
+
+			healthy, err := d.polling(ctx, testRegion, asg, client, threshold, false, false)
+			if err != nil {
+				return nil, err
+			}
+
+			if healthy {
+				break
+			}
+
+			if isTimeout, _ := tool.CheckTimeout(config.StartTimestamp, config.Timeout); isTimeout {
+				return nil, fmt.Errorf("timeout has been exceeded while waiting for API test target group : %s", region.APITestTargetGroup)
+			}
+
+			if err := tool.Sleep(ctx, config.PollingInterval); err != nil {
+				return nil, err
+			}
+		}
 	}
-	return false, nil
-}
 
-// CheckTerminating checks if all of instances are terminated well
-func (d Deployer) CheckTerminating(client aws.Client, target string, disableMetrics bool) bool {
-	asgInfo, err := client.EC2Service.GetMatchingAutoscalingGroup(target)
+	asg, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, asgName)
 	if err != nil {
-		d.Logger.Errorf(err.Error())
-		return true
+		return nil, err
 	}
 
-	if asgInfo == nil {
-		d.Logger.Info("Already deleted autoscaling group : ", target)
-		return true
+	instances := getInServiceInstances(asg)
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no instance in service to run API test : %s", asgName)
 	}
 
-	d.Logger.Info(fmt.Sprintf("Waiting for instance termination in asg %s", target))
-	if len(asgInfo.Instances) > 0 {
-		d.Logger.Infof("%d instance found : %s", len(asgInfo.Instances), target)
-		d.Slack.SendSimpleMessage(fmt.Sprintf("Still %d instance found : %s", len(asgInfo.Instances), target))
-
-		return false
+	ips, err := client.EC2Service.GetPrivateIPAddresses(ctx, instances)
+	if err != nil {
+		return nil, err
 	}
-	d.Slack.SendSimpleMessage(fmt.Sprintf(":+1: All instances are deleted : %s", target))
 
-	if err := d.CleanAutoscalingSet(client, target); err != nil {
-		d.Logger.Errorf(err.Error())
-		return false
+	hosts := []string{}
+	for _, instance := range instances {
+		if ip, ok := ips[instance]; ok {
+			hosts = append(hosts, ip)
+		}
 	}
 
-	if !disableMetrics {
-		d.Logger.Debugf("update status of autoscaling group to teminated : %s", target)
-		if err := d.Collector.UpdateStatus(target, "terminated", nil); err != nil {
-			d.Logger.Errorf(err.Error())
-			return false
-		}
-		d.Logger.Debugf("update status of %s is finished", target)
+	return hosts, nil
+}
+
+// FinishAPITest detaches new version from the API test target group
+func (d Deployer) FinishAPITest(ctx context.Context, client aws.Client, region schemas.RegionConfig) error {
+	if len(region.APITestTargetGroup) == 0 {
+		return nil
 	}
 
-	d.Logger.Debugf("Start deleting launch templates in %s", target)
-	if err := client.EC2Service.DeleteLaunchTemplates(target); err != nil {
-		d.Logger.Errorln(err.Error())
-		return false
+	tgArn, err := GetTargetGroupArn(ctx, client, region.APITestTargetGroup, region.Region)
+	if err != nil {
+		return err
 	}
-	d.Logger.Debugf("Launch templates are deleted in %s\n", target)
 
-	return true
+	return client.EC2Service.DetachTargetGroups(ctx, d.AsgNames[region.Region], []*string{tgArn})
 }
 
-// CleanAutoscalingSet cleans autoscaling group itself
-func (d Deployer) CleanAutoscalingSet(client aws.Client, target string) error {
-	d.Logger.Debugf("Start deleting autoscaling group : %s", target)
-	if err := client.EC2Service.DeleteAutoscalingSet(target); err != nil {
-		return err
+// CheckBake returns error if any alarm is in ALARM state or any metric breaches the threshold during bake
+func (d Deployer) CheckBake(ctx context.Context, client aws.Client, region string) error {
+	bake := d.Stack.Bake
+	if len(bake.Alarms) > 0 {
+		states, err := client.CloudWatchService.GetAlarmStates(ctx, bake.Alarms)
+		if err != nil {
+			return err
+		}
+
+		for _, alarm := range bake.Alarms {
+			state, ok := states[alarm]
+			if !ok {
+				return fmt.Errorf("alarm does not exist : %s", alarm)
+			}
+
+			d.Logger.Debugf("state of alarm %s : %s", alarm, state)
+			if state == cloudwatch.StateValueAlarm {
+				return fmt.Errorf("alarm is in ALARM state : %s", alarm)
+			}
+		}
 	}
-	d.Logger.Debugf("Autoscaling group is deleted : %s", target)
 
-	return nil
-}
+	for _, metric := range bake.Metrics {
+		dimensions := metric.Dimensions
+		if len(dimensions) == 0 {
+			dimensions = map[string]string{"AutoScalingGroupName": d.AsgNames[region]}
+		}
 
-// ResizingAutoScalingGroupToZero set autoscaling group instance count to 0
-func (d Deployer) ResizingAutoScalingGroupToZero(client aws.Client, stack, asg string) error {
-	d.Logger.Info(fmt.Sprintf("Modifying the size of autoscaling group to 0 : %s(%s)", asg, stack))
-	d.Slack.SendSimpleMessage(fmt.Sprintf("Modifying the size of autoscaling group to 0 : %s/%s", asg, stack))
+		period := metric.Period
+		if period == 0 {
+			period = constants.DefaultBakeMetricPeriod
+		}
 
-	retry := int64(3)
-	var err error
-	for {
-		retry, err = client.EC2Service.UpdateAutoScalingGroupSize(asg, 0, 0, 0, retry)
+		value, err := client.CloudWatchService.GetLatestMetricValue(ctx, metric, dimensions, period)
 		if err != nil {
-			if retry > 0 {
-				d.Logger.Debugf("error occurred and remained retry count is %d", retry)
-				time.Sleep(time.Duration(1+(2-retry)) * time.Second)
-			} else {
-				return err
-			}
+			return err
 		}
 
-		if err == nil {
-			break
+		if value == nil {
+			d.Logger.Debugf("no data point of metric yet : %s", metric.Name)
+			continue
+		}
+
+		d.Logger.Debugf("value of metric %s : %f", metric.Name, *value)
+		if isBreaching(*value, metric.Threshold, metric.Comparison) {
+			return fmt.Errorf("metric %s breaches the threshold : %f %s %f", metric.Name, *value, metric.Comparison, metric.Threshold)
 		}
 	}
 
 	return nil
 }
 
-// RunLifecycleCallbacks runs commands before terminating.
-func (d Deployer) RunLifecycleCallbacks(client aws.Client, target []string) bool {
-	if len(target) == 0 {
-		d.Logger.Debugf("no target instance exists\n")
+// isBreaching checks if the value breaches the threshold with the comparison operator
+func isBreaching(value, threshold float64, comparison string) bool {
+	switch comparison {
+	case cloudwatch.ComparisonOperatorGreaterThanThreshold:
+		return value > threshold
+	case cloudwatch.ComparisonOperatorGreaterThanOrEqualToThreshold:
+		return value >= threshold
+	case cloudwatch.ComparisonOperatorLessThanThreshold:
+		return value < threshold
+	case cloudwatch.ComparisonOperatorLessThanOrEqualToThreshold:
+		return value <= threshold
+	}
+
+	return false
+}
+
+// hasPostDeployCallbacks checks if any callback runs after new version becomes healthy
+func hasPostDeployCallbacks(callbacks *schemas.LifecycleCallbacks) bool {
+	if callbacks == nil {
 		return false
 	}
 
-	commands := d.Stack.LifecycleCallbacks.PreTerminatePastClusters
+	return len(callbacks.PostDeployNewClusters) > 0 || len(callbacks.RunOnce) > 0 || len(callbacks.Local) > 0
+}
 
-	d.Logger.Debugf("run lifecycle callbacks before termination : %s", target)
-	client.SSMService.SendCommand(
-		eaws.StringSlice(target),
-		eaws.StringSlice(commands),
-	)
+// getInServiceInstances returns sorted ids of instances in service
+func getInServiceInstances(group *autoscaling.Group) []string {
+	instances := []string{}
+	for _, instance := range group.Instances {
+		if *instance.LifecycleState == autoscaling.LifecycleStateInService {
+			instances = append(instances, *instance.InstanceId)
+		}
+	}
+	sort.Strings(instances)
 
-	return false
+	return instances
+}
+
+// runLocalCommand runs shell command with additional environment variables and returns the output of it
+func runLocalCommand(ctx context.Context, command string, env []string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+
+	out, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(out)), err
 }
 
 // selectClientFromList get aws client.
@@ -258,8 +1340,8 @@ func selectClientFromList(awsClients []aws.Client, region string) (aws.Client, e
 }
 
 // CheckTerminating checks if all of instances are terminated well
-func (d Deployer) GatherMetrics(client aws.Client, asg string) error {
-	targetGroups, err := client.EC2Service.GetTargetGroups(asg)
+func (d Deployer) GatherMetrics(ctx context.Context, client aws.Client, asg string) error {
+	targetGroups, err := client.EC2Service.GetTargetGroups(ctx, asg)
 	if err != nil {
 		return err
 	}
@@ -269,7 +1351,7 @@ func (d Deployer) GatherMetrics(client aws.Client, asg string) error {
 		return nil
 	}
 
-	lbs, err := client.ELBV2Service.GetLoadBalancerFromTG(targetGroups)
+	lbs, err := client.ELBV2Service.GetLoadBalancerFromTG(ctx, targetGroups)
 	if err != nil {
 		return err
 	}
@@ -309,7 +1391,9 @@ func (d Deployer) GetValidHostCount(targetHosts []aws.HealthcheckHost) int64 {
 }
 
 // GenerateAPIAttacker create API Attacker
-func (d Deployer) GenerateAPIAttacker(template schemas.APITestTemplate) (*APIAttacker, error) {
+// If url of api is templated with {{ .PrivateIP }}, the api is called for each host
+// Url, body and header can be templated with variables of template and scenarios
+func (d Deployer) GenerateAPIAttacker(template schemas.APITestTemplate, hosts []string, vars map[string]string) (*APIAttacker, error) {
 	attacker := APIAttacker{
 		Name:     template.Name,
 		Rate:     vegeta.Rate{Freq: template.RequestPerSecond, Per: time.Second},
@@ -317,15 +1401,28 @@ func (d Deployer) GenerateAPIAttacker(template schemas.APITestTemplate) (*APIAtt
 		Attacker: vegeta.NewAttacker(),
 	}
 
+	data := newTemplateData(constants.EmptyString, vars)
+
 	var targets []vegeta.Target
+	var assertions []*schemas.APITestAssertions
+	var apis []string
 	for _, api := range template.APIs {
+		urls, err := expandURL(api.URL, hosts, vars)
+		if err != nil {
+			return nil, err
+		}
+
 		tempT := vegeta.Target{
 			Method: strings.ToUpper(api.Method),
-			URL:    api.URL,
 		}
 
 		if len(api.Body) > 0 {
-			b, err := tool.CreateBodyStruct(api.Body)
+			body, err := renderList(api.Body, data)
+			if err != nil {
+				return nil, err
+			}
+
+			b, err := tool.CreateBodyStruct(body)
 			if err != nil {
 				return nil, err
 			}
@@ -334,7 +1431,12 @@ func (d Deployer) GenerateAPIAttacker(template schemas.APITestTemplate) (*APIAtt
 		}
 
 		if len(api.Header) > 0 {
-			h, err := tool.CreateHeaderStruct(api.Header)
+			header, err := renderList(api.Header, data)
+			if err != nil {
+				return nil, err
+			}
+
+			h, err := tool.CreateHeaderStruct(header)
 			if err != nil {
 				return nil, err
 			}
@@ -344,34 +1446,87 @@ func (d Deployer) GenerateAPIAttacker(template schemas.APITestTemplate) (*APIAtt
 			tempT.Header = tool.SetCommonHeader()
 		}
 
-		targets = append(targets, tempT)
+		apiAssertions := template.Assertions
+		if api.Assertions != nil {
+			apiAssertions = api.Assertions
+		}
+
+		for _, url := range urls {
+			target := tempT
+			target.URL = url
+			targets = append(targets, target)
+			assertions = append(assertions, apiAssertions)
+			apis = append(apis, api.URL)
+		}
 	}
 	attacker.Targets = targets
+	attacker.Assertions = assertions
+	attacker.APIs = apis
 
 	return &attacker, nil
 }
 
+// expandURL renders url with private ip address of each host
+// Url without private ip address is rendered only once
+func expandURL(url string, hosts []string, vars map[string]string) ([]string, error) {
+	if !strings.Contains(url, ".PrivateIP") {
+		u, err := renderTemplate(url, newTemplateData(constants.EmptyString, vars))
+		if err != nil {
+			return nil, err
+		}
+		return []string{u}, nil
+	}
+
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no host to render url of API test : %s", url)
+	}
+
+	urls := []string{}
+	for _, host := range hosts {
+		u, err := renderTemplate(url, newTemplateData(host, vars))
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+
+	return urls, nil
+}
+
 // Run calls apis to check
+// Results are in the same order as targets
 func (a APIAttacker) Run() ([]schemas.MetricResult, error) {
-	var result []schemas.MetricResult
+	result := make([]schemas.MetricResult, len(a.Targets))
 	wg := sync.WaitGroup{}
-	for _, tgt := range a.Targets {
+	for i, tgt := range a.Targets {
 		wg.Add(1)
-		go func(tgt vegeta.Target) {
+		go func(i int, tgt vegeta.Target) {
 			defer wg.Done()
 			metrics := vegeta.Metrics{}
+			results := vegeta.Results{}
 			tgtr := vegeta.NewStaticTargeter(tgt)
 			for res := range a.Attacker.Attack(tgtr, a.Rate, a.Duration, a.Name) {
 				metrics.Add(res)
+				if a.KeepResults {
+					results.Add(res)
+				}
 			}
 			metrics.Close()
+			results.Close()
 
-			result = append(result, schemas.MetricResult{
-				URL:    tgt.URL,
-				Method: tgt.Method,
-				Data:   metrics,
-			})
-		}(tgt)
+			result[i] = schemas.MetricResult{
+				URL:     tgt.URL,
+				Method:  tgt.Method,
+				Data:    metrics,
+				Results: results,
+			}
+			if i < len(a.Assertions) {
+				result[i].Assertions = a.Assertions[i]
+			}
+			if i < len(a.APIs) {
+				result[i].API = a.APIs[i]
+			}
+		}(i, tgt)
 	}
 
 	wg.Wait()
@@ -410,6 +1565,110 @@ func (a APIAttacker) Print(metrics []schemas.MetricResult) (string, error) {
 	return str, nil
 }
 
+// CheckAPITestAssertions returns violated thresholds of API test results
+func CheckAPITestAssertions(results []schemas.MetricResult) []string {
+	var violations []string
+	for _, result := range results {
+		if result.Assertions == nil {
+			continue
+		}
+
+		for _, v := range checkMetricAssertions(result.Data, *result.Assertions) {
+			violations = append(violations, fmt.Sprintf("[%s %s] %s", result.Method, result.URL, v))
+		}
+	}
+
+	return violations
+}
+
+// assertionResult is the result of a threshold of API test
+type assertionResult struct {
+	Name       string
+	Violations []string
+}
+
+// checkMetricAssertions compares metrics of an API with thresholds
+func checkMetricAssertions(metrics vegeta.Metrics, assertions schemas.APITestAssertions) []string {
+	var violations []string
+	for _, r := range evaluateAssertions(metrics, assertions) {
+		violations = append(violations, r.Violations...)
+	}
+
+	return violations
+}
+
+// evaluateAssertions checks each threshold which is specified
+func evaluateAssertions(metrics vegeta.Metrics, assertions schemas.APITestAssertions) []assertionResult {
+	var results []assertionResult
+	if assertions.MinSuccessRatio > 0 {
+		r := assertionResult{Name: "min_success_ratio"}
+		if metrics.Success < assertions.MinSuccessRatio {
+			r.Violations = append(r.Violations, fmt.Sprintf("success ratio %.4f is lower than %.4f", metrics.Success, assertions.MinSuccessRatio))
+		}
+		results = append(results, r)
+	}
+
+	if assertions.MaxLatencyP95 > 0 {
+		r := assertionResult{Name: "max_latency_p95"}
+		if metrics.Latencies.P95 > assertions.MaxLatencyP95 {
+			r.Violations = append(r.Violations, fmt.Sprintf("p95 latency %s exceeds %s", tool.RoundTime(metrics.Latencies.P95), assertions.MaxLatencyP95))
+		}
+		results = append(results, r)
+	}
+
+	if assertions.MaxLatencyP99 > 0 {
+		r := assertionResult{Name: "max_latency_p99"}
+		if metrics.Latencies.P99 > assertions.MaxLatencyP99 {
+			r.Violations = append(r.Violations, fmt.Sprintf("p99 latency %s exceeds %s", tool.RoundTime(metrics.Latencies.P99), assertions.MaxLatencyP99))
+		}
+		results = append(results, r)
+	}
+
+	if len(assertions.AllowedStatusCodes) > 0 {
+		r := assertionResult{Name: "allowed_status_codes"}
+		allowed := map[string]bool{}
+		for _, code := range assertions.AllowedStatusCodes {
+			allowed[strconv.Itoa(code)] = true
+		}
+
+		codes := []string{}
+		for code := range metrics.StatusCodes {
+			if !allowed[code] {
+				codes = append(codes, code)
+			}
+		}
+		sort.Strings(codes)
+
+		for _, code := range codes {
+			r.Violations = append(r.Violations, fmt.Sprintf("status code %s is not allowed : %d responses", code, metrics.StatusCodes[code]))
+		}
+		results = append(results, r)
+	}
+
+	if assertions.MaxErrors != nil {
+		r := assertionResult{Name: "max_errors"}
+		if errs := countFailedRequests(metrics); errs > *assertions.MaxErrors {
+			r.Violations = append(r.Violations, fmt.Sprintf("%d requests failed, more than %d", errs, *assertions.MaxErrors))
+		}
+		results = append(results, r)
+	}
+
+	return results
+}
+
+// countFailedRequests returns the number of requests without successful status code
+func countFailedRequests(metrics vegeta.Metrics) int {
+	count := 0
+	for code, n := range metrics.StatusCodes {
+		status, err := strconv.Atoi(code)
+		if err != nil || status < http.StatusOK || status >= http.StatusBadRequest {
+			count += n
+		}
+	}
+
+	return count
+}
+
 // printCurrentHostStatus shows current instance status
 func printCurrentHostStatus(data [][]string) {
 	table := tablewriter.NewWriter(os.Stdout)
@@ -0,0 +1,226 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+	"github.com/DevopsArtFactory/goployer/pkg/tool"
+)
+
+// IsDrainEnabled checks if previous versions are drained before they are resized to zero
+func IsDrainEnabled(stack schemas.Stack) bool {
+	return stack.Drain != nil
+}
+
+// GetDrainSteps returns capacities to which previous version is stepped down before it is resized to zero
+func GetDrainSteps(desired, stepSize int64) []int64 {
+	steps := []int64{}
+	if stepSize <= 0 {
+		return steps
+	}
+
+	for capacity := desired - stepSize; capacity > 0; capacity -= stepSize {
+		steps = append(steps, capacity)
+	}
+
+	return steps
+}
+
+// DrainPreviousVersions steps down previous versions and detaches them from load balancing targets
+// and then waits until connections to previous versions are drained
+// Standby versions keep load balancing targets to be restored later
+func (d Deployer) DrainPreviousVersions(ctx context.Context, config schemas.Config) error {
+	var wait time.Duration
+	for _, region := range d.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			d.Logger.Debug("This region is skipped by user : " + region.Region)
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(d.AWSClients, region.Region)
+		if err != nil {
+			return err
+		}
+
+		standby, _ := SelectStandbyAsgs(d.PrevAsgs[region.Region], d.Stack.RetainPreviousVersions)
+		for _, asg := range d.PrevAsgs[region.Region] {
+			group, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, asg)
+			if err != nil {
+				return err
+			}
+
+			if group == nil {
+				d.Logger.Debugf("no autoscaling group to drain : %s", asg)
+				continue
+			}
+
+			if d.Stack.Drain.StepSize > 0 {
+				if err := d.stepDownPreviousVersion(ctx, config, region, client, group); err != nil {
+					return err
+				}
+			}
+
+			if tool.IsStringInArray(asg, standby) {
+				d.Logger.Debugf("standby autoscaling group is not detached : %s", asg)
+				continue
+			}
+
+			delay, err := d.detachPreviousVersion(ctx, client, group)
+			if err != nil {
+				return err
+			}
+
+			if delay > wait {
+				wait = delay
+			}
+		}
+	}
+
+	if d.Stack.Drain.Duration > 0 {
+		wait = d.Stack.Drain.Duration
+	}
+
+	if wait == 0 {
+		return nil
+	}
+
+	d.Logger.Infof("Waiting %s for connections to previous versions to be drained : %s", tool.RoundTime(wait), d.Stack.Stack)
+	d.Slack.SendSimpleMessage(fmt.Sprintf("Waiting %s for connections to previous versions to be drained : %s", tool.RoundTime(wait), d.Stack.Stack))
+
+	return tool.Sleep(ctx, wait)
+}
+
+// stepDownPreviousVersion reduces capacity of previous version step by step
+// Each step waits until new version is healthy
+func (d Deployer) stepDownPreviousVersion(ctx context.Context, config schemas.Config, region schemas.RegionConfig, client aws.Client, group *autoscaling.Group) error {
+	asg := *group.AutoScalingGroupName
+	interval := d.Stack.Drain.StepInterval
+	if interval == 0 {
+		interval = constants.DefaultDrainStepInterval
+	}
+
+	// health of new version is checked in the target group which receives traffic
+	checkRegion := region
+	if active, ok := d.ActiveTargetGroups[region.Region]; ok {
+		checkRegion = GetListenerRegion(region, GetIdleTargetGroup(region, active))
+	}
+	threshold := d.GetAppliedCapacity(config.ForceManifestCapacity, region.Region).Desired
+
+	for _, capacity := range GetDrainSteps(*group.DesiredCapacity, d.Stack.Drain.StepSize) {
+		if err := d.waitNewVersionHealthy(ctx, config, checkRegion, client, threshold); err != nil {
+			return err
+		}
+
+		if err := client.EC2Service.UpdateAutoScalingGroup(ctx, asg, schemas.Capacity{Min: capacity, Max: capacity, Desired: capacity}); err != nil {
+			return err
+		}
+
+		d.Logger.Infof("[%s] Capacity of previous version is stepped down to %d : %s", region.Region, capacity, asg)
+		d.Slack.SendSimpleMessage(fmt.Sprintf("Capacity of previous version is stepped down to %d : %s", capacity, asg))
+
+		if err := tool.Sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitNewVersionHealthy waits until healthy count of new version meets the desired capacity
+func (d Deployer) waitNewVersionHealthy(ctx context.Context, config schemas.Config, region schemas.RegionConfig, client aws.Client, threshold int64) error {
+	newAsgName := d.AsgNames[region.Region]
+	if len(newAsgName) == 0 {
+		return nil
+	}
+
+	for {
+		asg, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, newAsgName)
+		if err != nil {
+			return err
+		}
+
+		isHealthy, err := d.polling(ctx, region, asg, client, threshold, false, false)
+		if err != nil {
+			return err
+		}
+
+		if isHealthy {
+			return nil
+		}
+
+		d.Logger.Warnf("[%s] Stepping down previous version is paused until new version is healthy : %s", region.Region, newAsgName)
+		if err := tool.Sleep(ctx, config.PollingInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// detachPreviousVersion detaches previous version from target groups and load balancers
+// and returns the time to wait for connections to be drained
+func (d Deployer) detachPreviousVersion(ctx context.Context, client aws.Client, group *autoscaling.Group) (time.Duration, error) {
+	asg := *group.AutoScalingGroupName
+
+	var delay time.Duration
+	if len(group.TargetGroupARNs) > 0 {
+		if d.Stack.Drain.Duration == 0 {
+			deregistrationDelay, err := client.ELBV2Service.GetDeregistrationDelay(ctx, group.TargetGroupARNs)
+			if err != nil {
+				return 0, err
+			}
+			delay = deregistrationDelay
+		}
+
+		if err := client.EC2Service.DetachTargetGroups(ctx, asg, group.TargetGroupARNs); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(group.LoadBalancerNames) > 0 {
+		loadbalancers := []string{}
+		for _, lb := range group.LoadBalancerNames {
+			loadbalancers = append(loadbalancers, *lb)
+		}
+
+		if d.Stack.Drain.Duration == 0 {
+			timeout, err := client.ELBService.GetConnectionDrainingTimeout(ctx, loadbalancers)
+			if err != nil {
+				return 0, err
+			}
+
+			if timeout > delay {
+				delay = timeout
+			}
+		}
+
+		if err := client.EC2Service.DetachLoadBalancers(ctx, asg, loadbalancers); err != nil {
+			return 0, err
+		}
+	}
+
+	d.Logger.Infof("Previous version is detached from load balancing targets : %s", asg)
+
+	return delay, nil
+}
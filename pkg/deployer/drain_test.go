@@ -0,0 +1,42 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetDrainSteps(t *testing.T) {
+	testData := []struct {
+		desired  int64
+		stepSize int64
+		expected []int64
+	}{
+		{desired: 10, stepSize: 3, expected: []int64{7, 4, 1}},
+		{desired: 6, stepSize: 2, expected: []int64{4, 2}},
+		{desired: 2, stepSize: 5, expected: []int64{}},
+		{desired: 0, stepSize: 1, expected: []int64{}},
+		{desired: 4, stepSize: 0, expected: []int64{}},
+	}
+
+	for _, td := range testData {
+		if output := GetDrainSteps(td.desired, td.stepSize); !reflect.DeepEqual(output, td.expected) {
+			t.Errorf("desired: %d, step size: %d, expected: %v, output: %v", td.desired, td.stepSize, td.expected, output)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+// IsListenerSwitch checks if traffic is switched by changing forward action of listener
+func IsListenerSwitch(stack schemas.Stack) bool {
+	return stack.TrafficSwitch == constants.ListenerTrafficSwitch
+}
+
+// SelectActiveTargetGroup returns the target group which receives the most traffic from listener
+// targetGroups is a map of target group name to its arn
+func SelectActiveTargetGroup(weights map[string]int64, targetGroups map[string]string) (string, error) {
+	active := constants.EmptyString
+	maxWeight := int64(0)
+	tie := false
+	for name, arn := range targetGroups {
+		weight := weights[arn]
+		switch {
+		case weight > maxWeight:
+			active = name
+			maxWeight = weight
+			tie = false
+		case weight > 0 && weight == maxWeight:
+			tie = true
+		}
+	}
+
+	if len(active) == 0 {
+		return constants.EmptyString, fmt.Errorf("listener does not forward traffic to any of target groups : %v", sortedKeys(targetGroups))
+	}
+
+	if tie {
+		return constants.EmptyString, fmt.Errorf("listener forwards the same weight of traffic to target groups : %v", sortedKeys(targetGroups))
+	}
+
+	return active, nil
+}
+
+// GetIdleTargetGroup returns the target group of blue and green which is not active
+func GetIdleTargetGroup(region schemas.RegionConfig, active string) string {
+	if active == region.BlueTargetGroup {
+		return region.GreenTargetGroup
+	}
+
+	return region.BlueTargetGroup
+}
+
+// GetListenerRegion returns region configuration of which health check target group is the idle target group
+// The new version is attached to the idle target group in addition to target_groups
+func GetListenerRegion(region schemas.RegionConfig, idle string) schemas.RegionConfig {
+	listenerRegion := region
+	listenerRegion.HealthcheckTargetGroup = idle
+	listenerRegion.HealthcheckLB = constants.EmptyString
+
+	return listenerRegion
+}
+
+// getActiveTargetGroup returns the target group of blue and green to which listener forwards traffic now
+func (d Deployer) getActiveTargetGroup(ctx context.Context, client aws.Client, region schemas.RegionConfig) (string, error) {
+	targetGroups := map[string]string{}
+	for _, tg := range []string{region.BlueTargetGroup, region.GreenTargetGroup} {
+		arn, err := GetTargetGroupArn(ctx, client, tg, region.Region)
+		if err != nil {
+			return constants.EmptyString, err
+		}
+		targetGroups[tg] = *arn
+	}
+
+	weights, err := client.ELBV2Service.GetForwardWeights(ctx, region.ListenerArn, region.ListenerRuleArn)
+	if err != nil {
+		return constants.EmptyString, err
+	}
+
+	return SelectActiveTargetGroup(weights, targetGroups)
+}
+
+// prepareListenerSwitch stores the active target group and returns region configuration for the idle target group
+func (d Deployer) prepareListenerSwitch(ctx context.Context, client aws.Client, region schemas.RegionConfig) (schemas.RegionConfig, error) {
+	active, err := d.getActiveTargetGroup(ctx, client, region)
+	if err != nil {
+		return region, err
+	}
+
+	idle := GetIdleTargetGroup(region, active)
+	d.ActiveTargetGroups[region.Region] = active
+	d.Logger.Infof("[%s] Listener forwards traffic to %s, new version will be attached to %s", region.Region, active, idle)
+
+	return GetListenerRegion(region, idle), nil
+}
+
+// switchListener changes forward action of listener so that all traffic goes to the target group
+// Nothing is changed if listener already forwards traffic to the target group
+func (d Deployer) switchListener(ctx context.Context, client aws.Client, region schemas.RegionConfig, target string) error {
+	current, err := d.getActiveTargetGroup(ctx, client, region)
+	if err == nil && current == target {
+		d.Logger.Debugf("[%s] Listener already forwards traffic to %s", region.Region, target)
+		return nil
+	}
+
+	targetArn, err := GetTargetGroupArn(ctx, client, target, region.Region)
+	if err != nil {
+		return err
+	}
+
+	other := GetIdleTargetGroup(region, target)
+	otherArn, err := GetTargetGroupArn(ctx, client, other, region.Region)
+	if err != nil {
+		return err
+	}
+
+	weights := map[string]int64{
+		*targetArn: 100,
+		*otherArn:  0,
+	}
+
+	if err := client.ELBV2Service.UpdateForwardWeights(ctx, region.ListenerArn, region.ListenerRuleArn, weights); err != nil {
+		return err
+	}
+
+	d.Logger.Infof("[%s] Listener is switched to %s from %s", region.Region, target, other)
+	d.Slack.SendSimpleMessage(fmt.Sprintf("Listener is switched to %s from %s in %s", target, other, region.Region))
+
+	return nil
+}
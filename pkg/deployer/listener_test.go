@@ -0,0 +1,89 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+func TestSelectActiveTargetGroup(t *testing.T) {
+	targetGroups := map[string]string{
+		"artd-blue":  "arn:blue",
+		"artd-green": "arn:green",
+	}
+
+	testData := []struct {
+		weights  map[string]int64
+		expected string
+		err      bool
+	}{
+		{weights: map[string]int64{"arn:blue": 1}, expected: "artd-blue"},
+		{weights: map[string]int64{"arn:blue": 0, "arn:green": 100}, expected: "artd-green"},
+		{weights: map[string]int64{"arn:blue": 90, "arn:green": 10}, expected: "artd-blue"},
+		{weights: map[string]int64{"arn:blue": 50, "arn:green": 50}, err: true},
+		{weights: map[string]int64{"arn:other": 100}, err: true},
+		{weights: map[string]int64{}, err: true},
+	}
+
+	for _, td := range testData {
+		output, err := SelectActiveTargetGroup(td.weights, targetGroups)
+		if td.err {
+			if err == nil {
+				t.Errorf("error expected: %v", td.weights)
+			}
+			continue
+		}
+
+		if err != nil || output != td.expected {
+			t.Errorf("expected: %s, output: %s, error: %v", td.expected, output, err)
+		}
+	}
+}
+
+func TestGetListenerRegion(t *testing.T) {
+	region := schemas.RegionConfig{
+		Region:           "ap-northeast-2",
+		TargetGroups:     []string{"artd-internal"},
+		LoadBalancers:    []string{"artd-elb"},
+		BlueTargetGroup:  "artd-blue",
+		GreenTargetGroup: "artd-green",
+	}
+
+	if idle := GetIdleTargetGroup(region, "artd-blue"); idle != "artd-green" {
+		t.Errorf("expected: artd-green, output: %s", idle)
+	}
+
+	if idle := GetIdleTargetGroup(region, "artd-green"); idle != "artd-blue" {
+		t.Errorf("expected: artd-blue, output: %s", idle)
+	}
+
+	loadbalancers, targetGroups := GetLoadBalancingTargets(GetListenerRegion(region, "artd-green"))
+	if !reflect.DeepEqual(targetGroups, []string{"artd-internal", "artd-green"}) {
+		t.Errorf("wrong target groups: %v", targetGroups)
+	}
+
+	if !reflect.DeepEqual(loadbalancers, []string{"artd-elb"}) {
+		t.Errorf("wrong load balancers: %v", loadbalancers)
+	}
+
+	if len(region.HealthcheckTargetGroup) > 0 {
+		t.Errorf("region configuration is changed: %s", region.HealthcheckTargetGroup)
+	}
+}
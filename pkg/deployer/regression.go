@@ -0,0 +1,208 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+	"github.com/DevopsArtFactory/goployer/pkg/tool"
+)
+
+// RegressionResult is the comparison of an API with API test of the last successful deployment
+type RegressionResult struct {
+	Method          string        `json:"method"`
+	API             string        `json:"api"`
+	PreviousP95     time.Duration `json:"previous_latency_p95"`
+	CurrentP95      time.Duration `json:"current_latency_p95"`
+	PreviousP99     time.Duration `json:"previous_latency_p99"`
+	CurrentP99      time.Duration `json:"current_latency_p99"`
+	PreviousSuccess float64       `json:"previous_success"`
+	CurrentSuccess  float64       `json:"current_success"`
+	Violations      []string      `json:"violations,omitempty"`
+}
+
+// GetPreviousAPITestSummary returns summary of API test of the last successful deployment in the region
+// Previous versions are searched from the latest one, and API test which failed is not used
+func (d Deployer) GetPreviousAPITestSummary(region string) (*schemas.APITestSummary, error) {
+	asgs := make([]string, len(d.PrevAsgs[region]))
+	copy(asgs, d.PrevAsgs[region])
+	sort.SliceStable(asgs, func(i, j int) bool {
+		return tool.ParseVersion(asgs[i]) > tool.ParseVersion(asgs[j])
+	})
+
+	for _, asg := range asgs {
+		record, err := d.Collector.GetDeploymentRecord(asg)
+		if err != nil {
+			return nil, err
+		}
+
+		if record == nil || record.APITestSummary == nil || !tool.IsStringInArray(record.Status, constants.RollbackableStatus) {
+			continue
+		}
+
+		if !record.APITestSummary.Passed {
+			d.Logger.Debugf("API test of previous version failed : %s", asg)
+			continue
+		}
+
+		if record.APITestSummary.Name != d.APITestTemplate.Name {
+			d.Logger.Debugf("API test template of previous version is different : %s", asg)
+			continue
+		}
+
+		d.Logger.Debugf("API test of previous version is found : %s", asg)
+		return record.APITestSummary, nil
+	}
+
+	return nil, nil
+}
+
+// CompareAPITestSummary compares API test with the summary of the previous release
+// APIs which are called for each host are compared with the worst value among hosts
+func CompareAPITestSummary(previous, current schemas.APITestSummary, regression schemas.APITestRegression) []RegressionResult {
+	p99Tolerance := regression.MaxLatencyP99Increase
+	if p99Tolerance == 0 {
+		p99Tolerance = constants.DefaultRegressionLatencyIncrease
+	}
+
+	successTolerance := regression.MaxSuccessRatioDecrease
+	if successTolerance == 0 {
+		successTolerance = constants.DefaultRegressionSuccessDecrease
+	}
+
+	prevAPIs, _ := aggregateSummary(previous)
+	curAPIs, keys := aggregateSummary(current)
+
+	var results []RegressionResult
+	for _, key := range keys {
+		prev, ok := prevAPIs[key]
+		if !ok {
+			continue
+		}
+		cur := curAPIs[key]
+
+		r := RegressionResult{
+			Method:          cur.Method,
+			API:             cur.API,
+			PreviousP95:     prev.LatencyP95,
+			CurrentP95:      cur.LatencyP95,
+			PreviousP99:     prev.LatencyP99,
+			CurrentP99:      cur.LatencyP99,
+			PreviousSuccess: prev.Success,
+			CurrentSuccess:  cur.Success,
+		}
+
+		if isLatencyRegressed(prev.LatencyP99, cur.LatencyP99, p99Tolerance) {
+			r.Violations = append(r.Violations, fmt.Sprintf("p99 latency %s is higher than %s of previous release by more than %.0f%%", tool.RoundTime(cur.LatencyP99), tool.RoundTime(prev.LatencyP99), p99Tolerance*100))
+		}
+
+		if regression.MaxLatencyP95Increase > 0 && isLatencyRegressed(prev.LatencyP95, cur.LatencyP95, regression.MaxLatencyP95Increase) {
+			r.Violations = append(r.Violations, fmt.Sprintf("p95 latency %s is higher than %s of previous release by more than %.0f%%", tool.RoundTime(cur.LatencyP95), tool.RoundTime(prev.LatencyP95), regression.MaxLatencyP95Increase*100))
+		}
+
+		if prev.Success-cur.Success > successTolerance {
+			r.Violations = append(r.Violations, fmt.Sprintf("success ratio %.4f is lower than %.4f of previous release by more than %.4f", cur.Success, prev.Success, successTolerance))
+		}
+
+		results = append(results, r)
+	}
+
+	return results
+}
+
+// aggregateSummary merges metrics of the same API with the worst value and returns keys in order
+func aggregateSummary(summary schemas.APITestSummary) (map[string]schemas.APIMetricSummary, []string) {
+	apis := map[string]schemas.APIMetricSummary{}
+	var keys []string
+	for _, s := range summary.APIs {
+		if len(s.API) == 0 {
+			s.API = s.URL
+		}
+
+		key := fmt.Sprintf("%s %s", s.Method, s.API)
+		agg, ok := apis[key]
+		if !ok {
+			apis[key] = s
+			keys = append(keys, key)
+			continue
+		}
+
+		if s.LatencyP95 > agg.LatencyP95 {
+			agg.LatencyP95 = s.LatencyP95
+		}
+
+		if s.LatencyP99 > agg.LatencyP99 {
+			agg.LatencyP99 = s.LatencyP99
+		}
+
+		if s.Success < agg.Success {
+			agg.Success = s.Success
+		}
+		apis[key] = agg
+	}
+
+	return apis, keys
+}
+
+// isLatencyRegressed checks if latency is increased more than the tolerance
+func isLatencyRegressed(previous, current time.Duration, tolerance float64) bool {
+	if previous <= 0 {
+		return false
+	}
+
+	return float64(current) > float64(previous)*(1+tolerance)
+}
+
+// CheckRegressionResults returns regressions of API test against the previous release
+func CheckRegressionResults(results []RegressionResult) []string {
+	var violations []string
+	for _, r := range results {
+		for _, v := range r.Violations {
+			violations = append(violations, fmt.Sprintf("[%s %s] %s", r.Method, r.API, v))
+		}
+	}
+
+	return violations
+}
+
+// printRegressionResults shows comparison with API test of the previous release
+func printRegressionResults(results []RegressionResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Method", "API", "P99 (Previous)", "P99 (Current)", "Success (Previous)", "Success (Current)", "Result"})
+	table.SetCenterSeparator("|")
+	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+
+	for _, r := range results {
+		status := "ok"
+		if len(r.Violations) > 0 {
+			status = strings.Join(r.Violations, ", ")
+		}
+		table.Append([]string{r.Method, r.API, tool.RoundTime(r.PreviousP99), tool.RoundTime(r.CurrentP99), fmt.Sprintf("%.4f", r.PreviousSuccess), fmt.Sprintf("%.4f", r.CurrentSuccess), status})
+	}
+	table.Render()
+}
@@ -0,0 +1,134 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	eaws "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	Logger "github.com/sirupsen/logrus"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/collector"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+func TestCompareAPITestSummary(t *testing.T) {
+	health := "http://{{ .PrivateIP }}:8080/health"
+	previous := schemas.APITestSummary{
+		Name: "api-test",
+		APIs: []schemas.APIMetricSummary{
+			{Method: "GET", URL: "https://example.com", LatencyP99: 100 * time.Millisecond, Success: 1},
+			{Method: "GET", API: health, URL: "http://10.0.0.1:8080/health", LatencyP99: 10 * time.Millisecond, Success: 1},
+			{Method: "POST", URL: "https://example.com/removed", LatencyP99: 10 * time.Millisecond, Success: 1},
+		},
+	}
+
+	current := schemas.APITestSummary{
+		Name: "api-test",
+		APIs: []schemas.APIMetricSummary{
+			{Method: "GET", API: "https://example.com", URL: "https://example.com", LatencyP99: 130 * time.Millisecond, Success: 0.98},
+			{Method: "GET", API: health, URL: "http://10.0.0.2:8080/health", LatencyP99: 11 * time.Millisecond, Success: 1},
+			{Method: "GET", API: health, URL: "http://10.0.0.3:8080/health", LatencyP99: 9 * time.Millisecond, Success: 0.995},
+		},
+	}
+
+	results := CompareAPITestSummary(previous, current, schemas.APITestRegression{})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, output: %v", results)
+	}
+
+	if results[1].CurrentP99 != 11*time.Millisecond || results[1].CurrentSuccess != 0.995 {
+		t.Errorf("metrics of hosts are not aggregated: %+v", results[1])
+	}
+
+	expected := []string{
+		"[GET https://example.com] p99 latency 130.00ms is higher than 100.00ms of previous release by more than 20%",
+		"[GET https://example.com] success ratio 0.9800 is lower than 1.0000 of previous release by more than 0.0100",
+	}
+	if output := CheckRegressionResults(results); !reflect.DeepEqual(output, expected) {
+		t.Errorf("expected: %v, output: %v", expected, output)
+	}
+
+	results = CompareAPITestSummary(previous, current, schemas.APITestRegression{MaxLatencyP99Increase: 0.5, MaxSuccessRatioDecrease: 0.05})
+	if output := CheckRegressionResults(results); len(output) != 0 {
+		t.Errorf("expected no regression, output: %v", output)
+	}
+}
+
+func TestGetPreviousAPITestSummary(t *testing.T) {
+	// the latest previous version failed API test with slow responses
+	summaries := map[string]schemas.APITestSummary{
+		"hello-artd_apne2-v003": {Name: "api-test", Passed: false, APIs: []schemas.APIMetricSummary{{Method: "GET", URL: "https://example.com", LatencyP99: 3 * time.Second}}},
+		"hello-artd_apne2-v002": {Name: "api-test", Passed: true, APIs: []schemas.APIMetricSummary{{Method: "GET", URL: "https://example.com", LatencyP99: 100 * time.Millisecond}}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input dynamodb.GetItemInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		output := dynamodb.GetItemOutput{}
+		if summary, ok := summaries[*input.Key[constants.HashKey].S]; ok {
+			b, _ := json.Marshal(summary)
+			output.Item = map[string]*dynamodb.AttributeValue{
+				"deployment_status":         {S: eaws.String("deployed")},
+				constants.APITestSummaryKey: {S: eaws.String(string(b))},
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		json.NewEncoder(w).Encode(output)
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&eaws.Config{
+		Endpoint:    eaws.String(server.URL),
+		Region:      eaws.String("ap-northeast-2"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	d := Deployer{
+		Logger:          Logger.New(),
+		APITestTemplate: &schemas.APITestTemplate{Name: "api-test"},
+		PrevAsgs:        map[string][]string{"ap-northeast-2": {"hello-artd_apne2-v001", "hello-artd_apne2-v002", "hello-artd_apne2-v003"}},
+		Collector: collector.Collector{
+			MetricConfig: schemas.MetricConfig{Enabled: true, Storage: schemas.Storage{Name: "goployer"}},
+			MetricClient: aws.MetricClient{DynamoDBService: aws.DynamoDBClient{Client: dynamodb.New(sess)}},
+		},
+	}
+
+	summary, err := d.GetPreviousAPITestSummary("ap-northeast-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary == nil || !summary.Passed || summary.APIs[0].LatencyP99 != 100*time.Millisecond {
+		t.Errorf("API test which passed should be used for regression check: %+v", summary)
+	}
+}
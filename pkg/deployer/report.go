@@ -0,0 +1,334 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+	"github.com/tsenart/vegeta/lib/plot"
+
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+// plotThreshold is the number of data points above which series of HTML plot are downsampled
+const plotThreshold = 4000
+
+// reportBuckets are latency buckets of histogram in API test report
+var reportBuckets = vegeta.Buckets{
+	0,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// APITestReport is the report of API test written as JSON
+type APITestReport struct {
+	Name        string             `json:"name"`
+	APIs        []APIReport        `json:"apis"`
+	Scenarios   []ScenarioResult   `json:"scenarios,omitempty"`
+	Regressions []RegressionResult `json:"regressions,omitempty"`
+}
+
+// APIReport is the report of an API in load test
+type APIReport struct {
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Metrics    vegeta.Metrics    `json:"metrics"`
+	Histogram  []HistogramBucket `json:"histogram"`
+	Violations []string          `json:"violations"`
+}
+
+// HistogramBucket is the number of requests in the latency range
+type HistogramBucket struct {
+	Range string  `json:"range"`
+	Count uint64  `json:"count"`
+	Ratio float64 `json:"ratio"`
+}
+
+// junitTestSuites is the root element of JUnit XML report
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// NewAPITestSummary creates summary of API test to store with the deployment record
+func NewAPITestSummary(name string, results []schemas.MetricResult) schemas.APITestSummary {
+	summary := schemas.APITestSummary{
+		Name:      name,
+		Timestamp: time.Now().Unix(),
+	}
+
+	for _, r := range results {
+		summary.APIs = append(summary.APIs, schemas.APIMetricSummary{
+			API:         r.API,
+			Method:      r.Method,
+			URL:         r.URL,
+			Requests:    r.Data.Requests,
+			Throughput:  r.Data.Throughput,
+			Success:     r.Data.Success,
+			LatencyMean: r.Data.Latencies.Mean,
+			LatencyP50:  r.Data.Latencies.P50,
+			LatencyP95:  r.Data.Latencies.P95,
+			LatencyP99:  r.Data.Latencies.P99,
+			LatencyMax:  r.Data.Latencies.Max,
+			StatusCodes: r.Data.StatusCodes,
+		})
+	}
+
+	return summary
+}
+
+// NewAPITestReport creates report with results of load test, scenarios and comparison with the previous release
+func NewAPITestReport(name string, results []schemas.MetricResult, scenarios []ScenarioResult, regressions []RegressionResult) APITestReport {
+	report := APITestReport{
+		Name:        name,
+		Scenarios:   scenarios,
+		Regressions: regressions,
+	}
+
+	for _, r := range results {
+		api := APIReport{
+			Method:    r.Method,
+			URL:       r.URL,
+			Metrics:   r.Data,
+			Histogram: newHistogram(r.Results),
+		}
+
+		if r.Assertions != nil {
+			api.Violations = checkMetricAssertions(r.Data, *r.Assertions)
+		}
+		report.APIs = append(report.APIs, api)
+	}
+
+	return report
+}
+
+// newHistogram counts requests in each latency bucket
+func newHistogram(results vegeta.Results) []HistogramBucket {
+	h := vegeta.Histogram{Buckets: reportBuckets}
+	for i := range results {
+		h.Add(&results[i])
+	}
+
+	buckets := []HistogramBucket{}
+	for i := range h.Buckets {
+		left, right := h.Buckets.Nth(i)
+		b := HistogramBucket{Range: fmt.Sprintf("[%s, %s]", left, right)}
+		if h.Total > 0 {
+			b.Count = h.Counts[i]
+			b.Ratio = float64(b.Count) / float64(h.Total) * 100
+		}
+		buckets = append(buckets, b)
+	}
+
+	return buckets
+}
+
+// WriteAPITestReport writes JSON, JUnit XML, raw vegeta results and HTML plot to the directory
+// Raw results can be used with `vegeta report` and `vegeta plot`
+func WriteAPITestReport(dir, name string, results []schemas.MetricResult, scenarios []ScenarioResult, regressions []RegressionResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	name = strings.NewReplacer("/", "-", " ", "-").Replace(name)
+	report := NewAPITestReport(name, results, scenarios, regressions)
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%s.json", name)), b, 0644); err != nil {
+		return err
+	}
+
+	x, err := xml.MarshalIndent(newJUnitReport(name, results, scenarios, regressions), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%s-junit.xml", name)), append([]byte(xml.Header), x...), 0644); err != nil {
+		return err
+	}
+
+	for i, r := range results {
+		if err := writeRawResults(filepath.Join(dir, fmt.Sprintf("%s-%d.bin", name, i)), r.Results); err != nil {
+			return err
+		}
+	}
+
+	return writePlot(filepath.Join(dir, fmt.Sprintf("%s.html", name)), name, results)
+}
+
+// writeRawResults writes results of requests with the encoding of vegeta
+func writeRawResults(path string, results vegeta.Results) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := vegeta.NewEncoder(f)
+	for i := range results {
+		if err := enc.Encode(&results[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writePlot writes HTML plot of latencies over time in the same way as `vegeta plot`
+// Each API is drawn as a separate series
+func writePlot(path, title string, results []schemas.MetricResult) error {
+	p := plot.New(
+		plot.Title(title),
+		plot.Downsample(plotThreshold),
+		plot.Label(plot.ErrorLabeler),
+	)
+
+	for _, r := range results {
+		attack := fmt.Sprintf("%s %s", r.Method, r.URL)
+		for i := range r.Results {
+			res := r.Results[i]
+			res.Attack = attack
+			if err := p.Add(&res); err != nil {
+				return err
+			}
+		}
+	}
+	p.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = p.WriteTo(f)
+	return err
+}
+
+// newJUnitReport creates test cases for each API, threshold, step of scenarios and comparison with the previous release
+func newJUnitReport(name string, results []schemas.MetricResult, scenarios []ScenarioResult, regressions []RegressionResult) junitTestSuites {
+	apiSuite := junitTestSuite{Name: name}
+	for _, r := range results {
+		api := fmt.Sprintf("%s %s", r.Method, r.URL)
+		elapsed := fmt.Sprintf("%.3f", r.Data.Duration.Seconds())
+
+		var assertions []assertionResult
+		if r.Assertions != nil {
+			assertions = evaluateAssertions(r.Data, *r.Assertions)
+		}
+
+		var violations []string
+		for _, a := range assertions {
+			violations = append(violations, a.Violations...)
+		}
+		apiSuite.Cases = append(apiSuite.Cases, newJUnitTestCase(api, name, elapsed, violations))
+
+		for _, a := range assertions {
+			apiSuite.Cases = append(apiSuite.Cases, newJUnitTestCase(fmt.Sprintf("%s [%s]", api, a.Name), name, elapsed, a.Violations))
+		}
+	}
+
+	suites := junitTestSuites{}
+	suites.Suites = append(suites.Suites, countJUnitTestSuite(apiSuite))
+
+	if len(scenarios) > 0 {
+		scenarioSuite := junitTestSuite{Name: fmt.Sprintf("%s-scenarios", name)}
+		for _, s := range scenarios {
+			scenarioSuite.Cases = append(scenarioSuite.Cases, newJUnitTestCase(fmt.Sprintf("%s/%s", s.Scenario, s.Step), s.Scenario, fmt.Sprintf("%.3f", s.Latency.Seconds()), s.Failures))
+		}
+		suites.Suites = append(suites.Suites, countJUnitTestSuite(scenarioSuite))
+	}
+
+	if len(regressions) > 0 {
+		regressionSuite := junitTestSuite{Name: fmt.Sprintf("%s-regression", name)}
+		for _, r := range regressions {
+			regressionSuite.Cases = append(regressionSuite.Cases, newJUnitTestCase(fmt.Sprintf("%s %s [regression]", r.Method, r.API), name, "0.000", r.Violations))
+		}
+		suites.Suites = append(suites.Suites, countJUnitTestSuite(regressionSuite))
+	}
+
+	return suites
+}
+
+// newJUnitTestCase creates test case which fails if there is any violation
+func newJUnitTestCase(name, className, elapsed string, violations []string) junitTestCase {
+	tc := junitTestCase{
+		Name:      name,
+		ClassName: className,
+		Time:      elapsed,
+	}
+
+	if len(violations) > 0 {
+		tc.Failure = &junitFailure{
+			Message: violations[0],
+			Text:    strings.Join(violations, "\n"),
+		}
+	}
+
+	return tc
+}
+
+// countJUnitTestSuite sets the number of tests and failures of suite
+func countJUnitTestSuite(suite junitTestSuite) junitTestSuite {
+	suite.Tests = len(suite.Cases)
+	for _, tc := range suite.Cases {
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	return suite
+}
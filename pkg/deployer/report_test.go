@@ -0,0 +1,148 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+func testMetricResults() []schemas.MetricResult {
+	results := vegeta.Results{
+		{Seq: 0, Code: 200, Latency: 3 * time.Millisecond, Timestamp: time.Unix(1602830000, 0)},
+		{Seq: 1, Code: 200, Latency: 30 * time.Millisecond, Timestamp: time.Unix(1602830001, 0)},
+		{Seq: 2, Code: 500, Latency: 300 * time.Millisecond, Timestamp: time.Unix(1602830002, 0)},
+	}
+
+	metrics := vegeta.Metrics{}
+	for i := range results {
+		metrics.Add(&results[i])
+	}
+	metrics.Close()
+
+	return []schemas.MetricResult{
+		{
+			Method:  "GET",
+			URL:     "https://example.com",
+			Data:    metrics,
+			Results: results,
+			Assertions: &schemas.APITestAssertions{
+				MinSuccessRatio:    0.5,
+				AllowedStatusCodes: []int{200},
+			},
+		},
+	}
+}
+
+func TestNewJUnitReport(t *testing.T) {
+	scenarios := []ScenarioResult{
+		{Scenario: "login", Step: "token", Failures: []string{"status code 401 is not successful"}},
+	}
+
+	report := newJUnitReport("artd-api-test", testMetricResults(), scenarios, nil)
+	if len(report.Suites) != 2 {
+		t.Fatalf("expected 2 suites, output: %d", len(report.Suites))
+	}
+
+	names := []string{}
+	for _, tc := range report.Suites[0].Cases {
+		names = append(names, tc.Name)
+	}
+
+	expected := []string{
+		"GET https://example.com",
+		"GET https://example.com [min_success_ratio]",
+		"GET https://example.com [allowed_status_codes]",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected: %v, output: %v", expected, names)
+	}
+
+	if report.Suites[0].Tests != 3 || report.Suites[0].Failures != 2 || report.Suites[0].Cases[1].Failure != nil {
+		t.Errorf("wrong failures of suite: %+v", report.Suites[0])
+	}
+
+	if report.Suites[1].Failures != 1 || report.Suites[1].Cases[0].Name != "login/token" {
+		t.Errorf("wrong scenario suite: %+v", report.Suites[1])
+	}
+}
+
+func TestWriteAPITestReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goployer-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := WriteAPITestReport(dir, "artd-api-test", testMetricResults(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []string{"artd-api-test.json", "artd-api-test-junit.xml", "artd-api-test-0.bin", "artd-api-test.html"} {
+		if _, err := os.Stat(filepath.Join(dir, f)); err != nil {
+			t.Errorf("report is not written: %s", f)
+		}
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "artd-api-test.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var report APITestReport
+	if err := json.Unmarshal(b, &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.APIs) != 1 || report.APIs[0].Metrics.Requests != 3 || len(report.APIs[0].Violations) != 1 {
+		t.Errorf("wrong report: %+v", report)
+	}
+
+	var count uint64
+	for _, bucket := range report.APIs[0].Histogram {
+		count += bucket.Count
+	}
+	if count != 3 {
+		t.Errorf("expected 3 requests in histogram, output: %d", count)
+	}
+
+	html, err := ioutil.ReadFile(filepath.Join(dir, "artd-api-test.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(html), "GET https://example.com") {
+		t.Errorf("latencies of API are not drawn in plot")
+	}
+}
+
+func TestNewAPITestSummary(t *testing.T) {
+	summary := NewAPITestSummary("api-test", testMetricResults())
+	if len(summary.APIs) != 1 || summary.APIs[0].Requests != 3 || summary.APIs[0].StatusCodes["500"] != 1 {
+		t.Errorf("wrong summary: %+v", summary)
+	}
+}
@@ -0,0 +1,571 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	Logger "github.com/sirupsen/logrus"
+
+	"github.com/DevopsArtFactory/goployer/pkg/builder"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+	"github.com/DevopsArtFactory/goployer/pkg/tool"
+)
+
+type Rolling struct {
+	BlueGreen
+	RefreshIDs           map[string]string
+	PrevTemplateVersions map[string]int64
+	CreatedAsgs          map[string]bool
+	UnhealthySince       map[string]time.Time
+}
+
+// NewRolling creates new rolling deployment deployer
+func NewRolling(mode string, logger *Logger.Logger, awsConfig schemas.AWSConfig, apiTestTemplate *schemas.APITestTemplate, stack schemas.Stack, regionSelected string) Rolling {
+	return Rolling{
+		BlueGreen:            NewBlueGrean(mode, logger, awsConfig, apiTestTemplate, stack, regionSelected),
+		RefreshIDs:           map[string]string{},
+		PrevTemplateVersions: map[string]int64{},
+		CreatedAsgs:          map[string]bool{},
+		UnhealthySince:       map[string]time.Time{},
+	}
+}
+
+// GetMinHealthyPercentage returns minimum percentage of healthy instances while rolling
+func GetMinHealthyPercentage(rolling *schemas.RollingConfig) int64 {
+	if rolling == nil || rolling.MinHealthyPercentage == 0 {
+		return constants.DefaultMinHealthyPercentage
+	}
+	return rolling.MinHealthyPercentage
+}
+
+// GetUnhealthyTimeout returns time to wait for healthy instances to meet minimum healthy percentage while rolling
+func GetUnhealthyTimeout(rolling *schemas.RollingConfig) time.Duration {
+	if rolling == nil || rolling.UnhealthyTimeout == 0 {
+		return constants.DefaultUnhealthyTimeout
+	}
+	return rolling.UnhealthyTimeout
+}
+
+// GetLaunchTemplateName returns name of launch template which autoscaling group uses
+func GetLaunchTemplateName(group *autoscaling.Group) (string, error) {
+	if group == nil {
+		return constants.EmptyString, errors.New("autoscaling group does not exist")
+	}
+
+	if group.LaunchTemplate != nil && group.LaunchTemplate.LaunchTemplateName != nil {
+		return *group.LaunchTemplate.LaunchTemplateName, nil
+	}
+
+	if group.MixedInstancesPolicy != nil && group.MixedInstancesPolicy.LaunchTemplate != nil {
+		spec := group.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification
+		if spec != nil && spec.LaunchTemplateName != nil {
+			return *spec.LaunchTemplateName, nil
+		}
+	}
+
+	return constants.EmptyString, fmt.Errorf("autoscaling group does not use launch template : %s", *group.AutoScalingGroupName)
+}
+
+// GetRollbackTemplateVersion returns launch template version which was default before rolling in the region
+func GetRollbackTemplateVersion(versions map[string]int64, region string) (int64, error) {
+	version, ok := versions[region]
+	if !ok || version <= 0 {
+		return 0, fmt.Errorf("no launch template version to roll back to in %s", region)
+	}
+	return version, nil
+}
+
+// CheckPrevious finds the current autoscaling group which will be rolled
+func (r Rolling) CheckPrevious(ctx context.Context, config schemas.Config) error {
+	if err := r.BlueGreen.CheckPrevious(ctx, config); err != nil {
+		return err
+	}
+
+	for _, region := range r.Stack.Regions {
+		//Region check
+		//If region id is passed from command line, then deployer will deploy in that region only.
+		if config.Region != "" && config.Region != region.Region {
+			continue
+		}
+
+		prevAsgs := r.PrevAsgs[region.Region]
+		if len(prevAsgs) == 0 {
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(r.AWSClients, region.Region)
+		if err != nil {
+			return err
+		}
+
+		// the latest autoscaling group is kept and only older ones are cleaned
+		current := prevAsgs[len(prevAsgs)-1]
+		r.AsgNames[region.Region] = current
+		r.PrevAsgs[region.Region] = prevAsgs[:len(prevAsgs)-1]
+
+		prevInstanceIds := []string{}
+		for _, asg := range r.PrevAsgs[region.Region] {
+			asgInfo, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, asg)
+			if err != nil {
+				return err
+			}
+
+			for _, instance := range asgInfo.Instances {
+				prevInstanceIds = append(prevInstanceIds, *instance.InstanceId)
+			}
+		}
+		r.PrevInstances[region.Region] = prevInstanceIds
+
+		r.Logger.Infof("[%s] Autoscaling group to roll : %s", region.Region, current)
+	}
+
+	return nil
+}
+
+// Deploy creates new launch template version and starts rolling instances
+func (r Rolling) Deploy(ctx context.Context, config schemas.Config) error {
+	if !r.StepStatus[constants.StepCheckPrevious] {
+		return nil
+	}
+
+	r.Logger.Info("Deploy Mode is " + r.Mode)
+
+	//Get LocalFileProvider
+	r.LocalProvider = builder.SetUserdataProvider(r.Stack.Userdata, r.AwsConfig.Userdata)
+
+	for _, region := range r.Stack.Regions {
+		//Region check
+		//If region id is passed from command line, then deployer will deploy in that region only.
+		if config.Region != "" && config.Region != region.Region {
+			r.Logger.Debug("This region is skipped by user : " + region.Region)
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(r.AWSClients, region.Region)
+		if err != nil {
+			return err
+		}
+
+		appliedCapacity := r.Deployer.GetAppliedCapacity(config.ForceManifestCapacity, region.Region)
+		r.Logger.Infof("Applied instance capacity - Min: %d, Desired: %d, Max: %d", appliedCapacity.Min, appliedCapacity.Desired, appliedCapacity.Max)
+
+		// first deployment does not have any autoscaling group to roll
+		asgName := r.AsgNames[region.Region]
+		if len(asgName) == 0 {
+			r.Logger.Infof("[%s] No autoscaling group to roll exists, so new autoscaling group will be created", region.Region)
+			loadbalancers, targetGroups := GetLoadBalancingTargets(region)
+			newAsgName, err := r.Deployer.CreateNewVersion(ctx, config, region, client, appliedCapacity, loadbalancers, targetGroups)
+			if err != nil {
+				return err
+			}
+
+			r.AsgNames[region.Region] = newAsgName
+			r.CreatedAsgs[region.Region] = true
+			continue
+		}
+
+		spec, err := r.Deployer.getLaunchTemplateSpec(ctx, config, region, client)
+		if err != nil {
+			return err
+		}
+
+		group, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, asgName)
+		if err != nil {
+			return err
+		}
+
+		launchTemplateName, err := GetLaunchTemplateName(group)
+		if err != nil {
+			return err
+		}
+
+		prevVersion, err := client.EC2Service.GetDefaultLaunchTemplateVersion(ctx, launchTemplateName)
+		if err != nil {
+			return err
+		}
+		r.PrevTemplateVersions[region.Region] = prevVersion
+
+		version, err := client.EC2Service.CreateLaunchTemplateVersion(
+			ctx,
+			launchTemplateName,
+			spec.ami,
+			spec.instanceType,
+			region.SSHKey,
+			r.Stack.IamInstanceProfile,
+			spec.userdata,
+			r.Stack.EbsOptimized,
+			r.Stack.MixedInstancesPolicy.Enabled,
+			spec.securityGroups,
+			spec.blockDevices,
+			r.Stack.InstanceMarketOptions,
+			region.DetailedMonitoringEnabled,
+		)
+		if err != nil {
+			return err
+		}
+
+		if err := client.EC2Service.UpdateAutoScalingGroup(ctx, asgName, appliedCapacity); err != nil {
+			return err
+		}
+
+		var instanceWarmup int64
+		if r.Stack.Rolling != nil {
+			instanceWarmup = r.Stack.Rolling.InstanceWarmup
+		}
+
+		refreshID, err := client.EC2Service.StartInstanceRefresh(ctx, asgName, GetMinHealthyPercentage(r.Stack.Rolling), instanceWarmup)
+		if err != nil {
+			return err
+		}
+		r.RefreshIDs[region.Region] = *refreshID
+		r.Logger.Infof("[%s] Instance refresh started with launch template version %d : %s", region.Region, version, *refreshID)
+		r.Slack.SendSimpleMessage(fmt.Sprintf("Rolling instances started with launch template version %d : %s", version, asgName))
+
+		if r.Collector.MetricConfig.Enabled {
+			if err := r.Collector.UpdateStatus(asgName, "rolling", map[string]interface{}{"launchTemplateVersion": fmt.Sprintf("%d", version)}); err != nil {
+				Logger.Errorf("Update status Error, %s : %s", err.Error(), asgName)
+			}
+		}
+	}
+
+	r.StepStatus[constants.StepDeploy] = true
+	return nil
+}
+
+// HealthChecking checks the progress of instance refresh and health of instances between batches
+func (r Rolling) HealthChecking(ctx context.Context, config schemas.Config) map[string]bool {
+	stackName := r.GetStackName()
+	if !r.StepStatus[constants.StepDeploy] {
+		return map[string]bool{stackName: true}
+	}
+	r.Logger.Debugf("Rolling healthchecking for stack starts : %s", stackName)
+	finished := []string{}
+
+	//Valid Count
+	validCount := 1
+	if config.Region == "" {
+		validCount = len(r.Stack.Regions)
+	}
+
+	if len(config.Region) > 0 {
+		if !CheckRegionExist(config.Region, r.Stack.Regions) {
+			validCount = 0
+		}
+	}
+
+	for _, region := range r.Stack.Regions {
+		//Region check
+		//If region id is passed from command line, then deployer will deploy in that region only.
+		if config.Region != "" && config.Region != region.Region {
+			r.Logger.Debugf("This region is skipped by user: %s", region.Region)
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(r.AWSClients, region.Region)
+		if err != nil {
+			return map[string]bool{stackName: false, "error": true}
+		}
+
+		asgName := r.AsgNames[region.Region]
+		asg, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, asgName)
+		if err != nil {
+			return map[string]bool{stackName: false, "error": true}
+		}
+
+		threshold := r.Deployer.GetAppliedCapacity(config.ForceManifestCapacity, region.Region).Desired
+		if refreshID := r.RefreshIDs[region.Region]; len(refreshID) > 0 {
+			refresh, err := client.EC2Service.GetInstanceRefresh(ctx, asgName, refreshID)
+			if err != nil {
+				r.Logger.Errorf(err.Error())
+				return map[string]bool{stackName: false, "error": true}
+			}
+
+			switch *refresh.Status {
+			case autoscaling.InstanceRefreshStatusSuccessful:
+				r.Logger.Infof("[%s] Instance refresh is finished : %s", region.Region, asgName)
+			case autoscaling.InstanceRefreshStatusFailed, autoscaling.InstanceRefreshStatusCancelling, autoscaling.InstanceRefreshStatusCancelled:
+				reason := constants.EmptyString
+				if refresh.StatusReason != nil {
+					reason = *refresh.StatusReason
+				}
+				r.Logger.Errorf("[%s] Instance refresh is %s : %s", region.Region, *refresh.Status, reason)
+				r.Slack.SendSimpleMessage(fmt.Sprintf(":x: Rolling instances is %s in %s : %s", *refresh.Status, asgName, reason))
+				return map[string]bool{stackName: false, "error": true}
+			default:
+				var percentage int64
+				if refresh.PercentageComplete != nil {
+					percentage = *refresh.PercentageComplete
+				}
+				r.Logger.Infof("[%s] Instance refresh is %s : %d%% completed", region.Region, *refresh.Status, percentage)
+
+				// check if healthy instances between batches meet minimum healthy percentage
+				minHealthy := (threshold*GetMinHealthyPercentage(r.Stack.Rolling) + 99) / 100
+				isHealthy, err := r.Deployer.polling(ctx, region, asg, client, minHealthy, false, false)
+				if err != nil {
+					return map[string]bool{stackName: false, "error": true}
+				}
+
+				if isHealthy {
+					delete(r.UnhealthySince, region.Region)
+					continue
+				}
+
+				since, ok := r.UnhealthySince[region.Region]
+				if !ok {
+					r.UnhealthySince[region.Region] = time.Now()
+					continue
+				}
+
+				if timeout := GetUnhealthyTimeout(r.Stack.Rolling); time.Since(since) > timeout {
+					r.Logger.Errorf("[%s] Healthy instances do not meet minimum healthy count %d for %s : %s", region.Region, minHealthy, tool.RoundTime(timeout), asgName)
+					r.Slack.SendSimpleMessage(fmt.Sprintf(":x: Healthy instances do not meet minimum healthy count %d for %s : %s", minHealthy, tool.RoundTime(timeout), asgName))
+					if err := client.EC2Service.CancelInstanceRefresh(ctx, asgName); err != nil {
+						r.Logger.Errorf("cannot cancel instance refresh : %s", err.Error())
+					}
+					return map[string]bool{stackName: false, "error": true}
+				}
+				continue
+			}
+		}
+
+		isHealthy, err := r.Deployer.polling(ctx, region, asg, client, threshold, false, false)
+		if err != nil {
+			return map[string]bool{stackName: false, "error": true}
+		}
+
+		if isHealthy {
+			if r.Collector.MetricConfig.Enabled {
+				if err := r.Collector.UpdateStatus(asgName, "deployed", nil); err != nil {
+					Logger.Errorf("Update status Error, %s : %s", err.Error(), asgName)
+				}
+			}
+			finished = append(finished, region.Region)
+		}
+	}
+
+	if len(finished) == validCount {
+		return map[string]bool{stackName: true, "error": false}
+	}
+
+	return map[string]bool{stackName: false, "error": false}
+}
+
+// Rollback cancels instance refresh and rolls instances back to the previous launch template version
+func (r Rolling) Rollback(ctx context.Context, config schemas.Config) error {
+	if !r.Stack.RollbackOnFailure {
+		r.Logger.Debugf("rollback on failure is disabled : %s", r.Stack.Stack)
+		return nil
+	}
+
+	for _, region := range r.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			continue
+		}
+
+		asgName := r.AsgNames[region.Region]
+		if len(asgName) == 0 {
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(r.AWSClients, region.Region)
+		if err != nil {
+			return err
+		}
+
+		// only autoscaling group created in this run is deleted
+		// the one found in CheckPrevious is updated in place and must be kept
+		if r.CreatedAsgs[region.Region] {
+			if err := r.Deployer.DeleteNewVersion(ctx, client, asgName); err != nil {
+				return err
+			}
+			r.Deployer.MarkRolledBack(asgName)
+			continue
+		}
+
+		// nothing is changed if deployment stopped before new launch template version was created
+		refreshID := r.RefreshIDs[region.Region]
+		if _, ok := r.PrevTemplateVersions[region.Region]; !ok && len(refreshID) == 0 {
+			r.Logger.Debugf("[%s] Launch template is not changed, so nothing to roll back : %s", region.Region, asgName)
+			continue
+		}
+
+		prevVersion, err := GetRollbackTemplateVersion(r.PrevTemplateVersions, region.Region)
+		if err != nil {
+			return err
+		}
+
+		if len(refreshID) > 0 {
+			if err := client.EC2Service.CancelInstanceRefresh(ctx, asgName); err != nil {
+				r.Logger.Warnf("cannot cancel instance refresh : %s", err.Error())
+			}
+		}
+
+		group, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, asgName)
+		if err != nil {
+			return err
+		}
+
+		launchTemplateName, err := GetLaunchTemplateName(group)
+		if err != nil {
+			return err
+		}
+
+		if err := client.EC2Service.SetDefaultLaunchTemplateVersion(ctx, launchTemplateName, prevVersion); err != nil {
+			return err
+		}
+
+		// instances are not replaced yet if instance refresh was not started
+		if len(refreshID) == 0 {
+			r.Logger.Infof("[%s] Launch template is rolled back to version %d : %s", region.Region, prevVersion, asgName)
+			r.Deployer.MarkRolledBack(asgName)
+			continue
+		}
+
+		// instance refresh cannot be started until the cancellation is done
+		for retry := 0; retry < constants.DefaultRollbackRetry; retry++ {
+			refresh, err := client.EC2Service.GetInstanceRefresh(ctx, asgName, refreshID)
+			if err != nil {
+				return err
+			}
+
+			status := *refresh.Status
+			if status != autoscaling.InstanceRefreshStatusPending && status != autoscaling.InstanceRefreshStatusInProgress && status != autoscaling.InstanceRefreshStatusCancelling {
+				break
+			}
+			r.Logger.Infof("[%s] Waiting for instance refresh to be cancelled : %s", region.Region, status)
+			if err := tool.Sleep(ctx, config.PollingInterval); err != nil {
+				return err
+			}
+		}
+
+		var instanceWarmup int64
+		if r.Stack.Rolling != nil {
+			instanceWarmup = r.Stack.Rolling.InstanceWarmup
+		}
+
+		if _, err := client.EC2Service.StartInstanceRefresh(ctx, asgName, GetMinHealthyPercentage(r.Stack.Rolling), instanceWarmup); err != nil {
+			return err
+		}
+		r.Logger.Infof("[%s] Instances are rolled back to launch template version %d : %s", region.Region, prevVersion, asgName)
+		r.Deployer.MarkRolledBack(asgName)
+	}
+
+	return nil
+}
+
+// Cleanup restores launch template version changed in this run and rolls instances back
+func (r Rolling) Cleanup(ctx context.Context, config schemas.Config) error {
+	r.Stack.RollbackOnFailure = true
+	if err := r.Rollback(ctx, config); err != nil {
+		return err
+	}
+
+	r.Deployer.ResetDeployStep()
+	return nil
+}
+
+// GetState returns state of deployer including launch template versions before rolling
+// and instance refreshes started in this run
+func (r Rolling) GetState() schemas.DeployerState {
+	state := r.Deployer.GetState()
+	state.LaunchTemplateVersions = r.PrevTemplateVersions
+	state.RefreshIDs = r.RefreshIDs
+	state.CreatedAsgs = r.CreatedAsgs
+	return state
+}
+
+// SetState restores state of deployer including launch template versions before rolling
+// and instance refreshes started in this run
+func (r Rolling) SetState(state schemas.DeployerState) {
+	r.Deployer.SetState(state)
+	for region, version := range state.LaunchTemplateVersions {
+		r.PrevTemplateVersions[region] = version
+	}
+
+	for region, refreshID := range state.RefreshIDs {
+		r.RefreshIDs[region] = refreshID
+	}
+
+	for region, created := range state.CreatedAsgs {
+		r.CreatedAsgs[region] = created
+	}
+}
+
+// Plan returns changes of rolling deployment in each region without updating anything
+func (r Rolling) Plan(ctx context.Context, config schemas.Config) ([]schemas.RegionPlan, error) {
+	if err := r.CheckPrevious(ctx, config); err != nil {
+		return nil, err
+	}
+
+	r.LocalProvider = builder.SetUserdataProvider(r.Stack.Userdata, r.AwsConfig.Userdata)
+
+	plans := []schemas.RegionPlan{}
+	for _, region := range r.Stack.Regions {
+		if config.Region != "" && config.Region != region.Region {
+			continue
+		}
+
+		//select client
+		client, err := selectClientFromList(r.AWSClients, region.Region)
+		if err != nil {
+			return nil, err
+		}
+
+		appliedCapacity := r.Deployer.GetAppliedCapacity(config.ForceManifestCapacity, region.Region)
+		loadbalancers, targetGroups := GetLoadBalancingTargets(region)
+		plan, err := r.Deployer.PlanNewVersion(ctx, config, region, client, appliedCapacity, loadbalancers, targetGroups)
+		if err != nil {
+			return nil, err
+		}
+
+		// existing autoscaling group only gets a new launch template version
+		if asgName := r.AsgNames[region.Region]; len(asgName) > 0 {
+			group, err := client.EC2Service.GetMatchingAutoscalingGroup(ctx, asgName)
+			if err != nil {
+				return nil, err
+			}
+
+			launchTemplateName, err := GetLaunchTemplateName(group)
+			if err != nil {
+				return nil, err
+			}
+
+			plan.AutoScalingGroup = asgName
+			plan.LaunchTemplate = launchTemplateName
+			plan.AvailabilityZones = nil
+			plan.Subnets = nil
+			plan.LoadBalancers = nil
+			plan.TargetGroups = nil
+			plan.Tags = nil
+		}
+
+		plan.RemovedAutoScalingGroups = r.PrevAsgs[region.Region]
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
@@ -0,0 +1,229 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	eaws "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	Logger "github.com/sirupsen/logrus"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+func TestGetMinHealthyPercentage(t *testing.T) {
+	if output := GetMinHealthyPercentage(nil); output != constants.DefaultMinHealthyPercentage {
+		t.Errorf("expected: %d, output: %d", constants.DefaultMinHealthyPercentage, output)
+	}
+
+	if output := GetMinHealthyPercentage(&schemas.RollingConfig{}); output != constants.DefaultMinHealthyPercentage {
+		t.Errorf("expected: %d, output: %d", constants.DefaultMinHealthyPercentage, output)
+	}
+
+	if output := GetMinHealthyPercentage(&schemas.RollingConfig{MinHealthyPercentage: 50}); output != 50 {
+		t.Errorf("expected: %d, output: %d", 50, output)
+	}
+}
+
+func TestGetUnhealthyTimeout(t *testing.T) {
+	if output := GetUnhealthyTimeout(nil); output != constants.DefaultUnhealthyTimeout {
+		t.Errorf("expected: %s, output: %s", constants.DefaultUnhealthyTimeout, output)
+	}
+
+	if output := GetUnhealthyTimeout(&schemas.RollingConfig{UnhealthyTimeout: 3 * time.Minute}); output != 3*time.Minute {
+		t.Errorf("expected: %s, output: %s", 3*time.Minute, output)
+	}
+}
+
+func TestGetLaunchTemplateName(t *testing.T) {
+	group := &autoscaling.Group{
+		AutoScalingGroupName: eaws.String("hello-dev_apne2-v001"),
+		LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+			LaunchTemplateName: eaws.String("hello-dev_apne2-v001-1602830000"),
+		},
+	}
+
+	if output, err := GetLaunchTemplateName(group); err != nil || output != "hello-dev_apne2-v001-1602830000" {
+		t.Errorf("expected: %s, output: %s", "hello-dev_apne2-v001-1602830000", output)
+	}
+
+	group.LaunchTemplate = nil
+	group.MixedInstancesPolicy = &autoscaling.MixedInstancesPolicy{
+		LaunchTemplate: &autoscaling.LaunchTemplate{
+			LaunchTemplateSpecification: &autoscaling.LaunchTemplateSpecification{
+				LaunchTemplateName: eaws.String("hello-dev_apne2-v001-1602830001"),
+			},
+		},
+	}
+
+	if output, err := GetLaunchTemplateName(group); err != nil || output != "hello-dev_apne2-v001-1602830001" {
+		t.Errorf("expected: %s, output: %s", "hello-dev_apne2-v001-1602830001", output)
+	}
+
+	group.MixedInstancesPolicy = nil
+	if _, err := GetLaunchTemplateName(group); err == nil {
+		t.Errorf("autoscaling group without launch template should return error")
+	}
+}
+
+func TestGetRollbackTemplateVersion(t *testing.T) {
+	versions := map[string]int64{
+		"ap-northeast-2": 3,
+		"us-east-1":      0,
+	}
+
+	if output, err := GetRollbackTemplateVersion(versions, "ap-northeast-2"); err != nil || output != 3 {
+		t.Errorf("expected: %d, output: %d, error: %v", 3, output, err)
+	}
+
+	for _, region := range []string{"us-east-1", "eu-west-1"} {
+		if _, err := GetRollbackTemplateVersion(versions, region); err == nil {
+			t.Errorf("region without previous launch template version should return error: %s", region)
+		}
+	}
+}
+
+func TestRollbackOnFailureDisabled(t *testing.T) {
+	stack := schemas.Stack{
+		Stack:   "artd",
+		Regions: []schemas.RegionConfig{{Region: "ap-northeast-2"}},
+	}
+
+	deployer := Deployer{
+		Logger:   Logger.New(),
+		Stack:    stack,
+		AsgNames: map[string]string{"ap-northeast-2": "hello-artd_apne2-v002"},
+	}
+
+	bluegreen := BlueGreen{deployer}
+	managers := map[string]DeployManager{
+		constants.BlueGreenDeployment: bluegreen,
+		constants.CanaryDeployment:    Canary{BlueGreen: bluegreen},
+		constants.RollingDeployment: Rolling{
+			BlueGreen:            bluegreen,
+			RefreshIDs:           map[string]string{"ap-northeast-2": "refresh-id"},
+			PrevTemplateVersions: map[string]int64{"ap-northeast-2": 3},
+		},
+	}
+
+	for replacementType, manager := range managers {
+		if err := manager.Rollback(context.Background(), schemas.Config{}); err != nil {
+			t.Errorf("%s: rollback should do nothing if rollback_on_failure is disabled: %v", replacementType, err)
+		}
+	}
+
+	// without aws clients, rollback fails as soon as it starts to roll back
+	bluegreen.Stack.RollbackOnFailure = true
+	if err := (Rolling{BlueGreen: bluegreen, PrevTemplateVersions: map[string]int64{}}).Rollback(context.Background(), schemas.Config{}); err == nil {
+		t.Errorf("rollback should be started if rollback_on_failure is enabled")
+	}
+}
+
+func TestRollbackRestoredState(t *testing.T) {
+	results := map[string]string{
+		"DescribeAutoScalingGroups": "<AutoScalingGroups><member><AutoScalingGroupName>hello-artd_apne2-v001</AutoScalingGroupName><LaunchTemplate><LaunchTemplateName>hello-artd_apne2-v001</LaunchTemplateName></LaunchTemplate></member></AutoScalingGroups>",
+		"DescribeInstanceRefreshes": "<InstanceRefreshes><member><InstanceRefreshId>refresh-id</InstanceRefreshId><Status>Cancelled</Status></member></InstanceRefreshes>",
+		"StartInstanceRefresh":      "<InstanceRefreshId>rollback-refresh-id</InstanceRefreshId>",
+	}
+
+	var mutex sync.Mutex
+	actions := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		action := r.FormValue("Action")
+		mutex.Lock()
+		actions = append(actions, action)
+		mutex.Unlock()
+
+		fmt.Fprintf(w, "<%sResponse><%sResult>%s</%sResult></%sResponse>", action, action, results[action], action, action)
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&eaws.Config{
+		Endpoint:    eaws.String(server.URL),
+		Region:      eaws.String("ap-northeast-2"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	}))
+
+	stack := schemas.Stack{
+		Stack:             "artd",
+		RollbackOnFailure: true,
+		Regions:           []schemas.RegionConfig{{Region: "ap-northeast-2"}},
+	}
+
+	// state is restored from the one persisted while rolling is paused for manual promotion
+	b, err := json.Marshal(schemas.DeployerState{
+		Stack:                  "artd",
+		AsgNames:               map[string]string{"ap-northeast-2": "hello-artd_apne2-v001"},
+		LaunchTemplateVersions: map[string]int64{"ap-northeast-2": 3},
+		RefreshIDs:             map[string]string{"ap-northeast-2": "refresh-id"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state schemas.DeployerState
+	if err := json.Unmarshal(b, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	rolling := Rolling{
+		BlueGreen: BlueGreen{Deployer{
+			Logger:   Logger.New(),
+			Stack:    stack,
+			AsgNames: map[string]string{},
+			AWSClients: []aws.Client{{
+				Region: "ap-northeast-2",
+				EC2Service: aws.EC2Client{
+					Client:   ec2.New(sess),
+					AsClient: autoscaling.New(sess),
+				},
+			}},
+		}},
+		RefreshIDs:           map[string]string{},
+		PrevTemplateVersions: map[string]int64{},
+		CreatedAsgs:          map[string]bool{},
+	}
+	rolling.SetState(state)
+
+	if err := rolling.Rollback(context.Background(), schemas.Config{PollingInterval: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"CancelInstanceRefresh", "DescribeAutoScalingGroups", "ModifyLaunchTemplate", "DescribeInstanceRefreshes", "StartInstanceRefresh"}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("existing autoscaling group should be rolled back in place, expected: %v, output: %v", expected, actions)
+	}
+}
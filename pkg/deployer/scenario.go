@@ -0,0 +1,445 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+	"github.com/DevopsArtFactory/goployer/pkg/tool"
+)
+
+// ScenarioResult is the result of a step in API test scenario
+type ScenarioResult struct {
+	Scenario   string        `json:"scenario"`
+	Step       string        `json:"step"`
+	Method     string        `json:"method"`
+	URL        string        `json:"url"`
+	StatusCode int           `json:"status_code"`
+	Latency    time.Duration `json:"latency"`
+	Failures   []string      `json:"failures,omitempty"`
+}
+
+// templateData is data to render templated fields of API test
+type templateData struct {
+	PrivateIP string
+	Vars      map[string]string
+	Env       map[string]string
+}
+
+// newTemplateData creates data for rendering with environment variables
+func newTemplateData(host string, vars map[string]string) templateData {
+	env := map[string]string{}
+	for _, e := range os.Environ() {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) == 2 {
+			env[kv[0]] = kv[1]
+		}
+	}
+
+	return templateData{
+		PrivateIP: host,
+		Vars:      vars,
+		Env:       env,
+	}
+}
+
+// renderTemplate renders text with variables
+// Text without template is returned as it is
+func renderTemplate(text string, data templateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	t, err := texttemplate.New("api").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return constants.EmptyString, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return constants.EmptyString, err
+	}
+
+	return buf.String(), nil
+}
+
+// renderList renders every value in the list
+func renderList(list []string, data templateData) ([]string, error) {
+	ret := []string{}
+	for _, s := range list {
+		r, err := renderTemplate(s, data)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, r)
+	}
+
+	return ret, nil
+}
+
+// RunAPIScenarios calls steps of scenarios in order and returns variables captured from responses
+// Scenario with {{ .PrivateIP }} runs for each host
+func (d Deployer) RunAPIScenarios(ctx context.Context, template schemas.APITestTemplate, hosts []string) (map[string]string, []ScenarioResult) {
+	vars := map[string]string{}
+	for k, v := range template.Vars {
+		vars[k] = v
+	}
+
+	var results []ScenarioResult
+	for _, scenario := range template.Scenarios {
+		targets := []string{constants.EmptyString}
+		if usesPrivateIP(*scenario) {
+			if len(hosts) == 0 {
+				results = append(results, ScenarioResult{
+					Scenario: scenario.Name,
+					Failures: []string{"no host to render url of scenario"},
+				})
+				continue
+			}
+			targets = hosts
+		}
+
+		for _, host := range targets {
+			d.Logger.Debugf("run API test scenario %s %s", scenario.Name, host)
+			results = append(results, runAPIScenario(ctx, *scenario, host, vars)...)
+		}
+	}
+
+	return vars, results
+}
+
+// runAPIScenario calls steps of the scenario and stops at the first failed step
+// Captured values are stored to vars
+func runAPIScenario(ctx context.Context, scenario schemas.APIScenario, host string, vars map[string]string) []ScenarioResult {
+	var results []ScenarioResult
+	for _, step := range scenario.Steps {
+		result, captured := runAPIStep(ctx, *step, newTemplateData(host, vars))
+		result.Scenario = scenario.Name
+		results = append(results, result)
+
+		if len(result.Failures) > 0 {
+			break
+		}
+
+		for k, v := range captured {
+			vars[k] = v
+		}
+	}
+
+	return results
+}
+
+// runAPIStep calls an API and checks the response
+func runAPIStep(ctx context.Context, step schemas.APIStep, data templateData) (ScenarioResult, map[string]string) {
+	result := ScenarioResult{
+		Step:   step.Name,
+		Method: strings.ToUpper(step.Method),
+	}
+
+	req, err := newStepRequest(ctx, step, data)
+	if err != nil {
+		result.Failures = append(result.Failures, err.Error())
+		return result, nil
+	}
+	result.URL = req.URL.String()
+
+	timeout := step.Timeout
+	if timeout == 0 {
+		timeout = constants.DefaultAPIStepTimeout
+	}
+
+	client := http.Client{Timeout: timeout}
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Failures = append(result.Failures, err.Error())
+		return result, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		result.Failures = append(result.Failures, err.Error())
+		return result, nil
+	}
+	result.StatusCode = resp.StatusCode
+
+	result.Failures = checkStepResponse(step.Expect, resp.StatusCode, resp.Header, body)
+	if len(result.Failures) > 0 {
+		return result, nil
+	}
+
+	captured, err := captureJSON(step.Capture, body)
+	if err != nil {
+		result.Failures = append(result.Failures, err.Error())
+		return result, nil
+	}
+
+	return result, captured
+}
+
+// newStepRequest creates request of step with rendered url, body and header
+func newStepRequest(ctx context.Context, step schemas.APIStep, data templateData) (*http.Request, error) {
+	url, err := renderTemplate(step.URL, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if len(step.Body) > 0 {
+		list, err := renderList(step.Body, data)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := tool.CreateBodyStruct(list)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(step.Method), url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = tool.SetCommonHeader()
+	if len(step.Header) > 0 {
+		list, err := renderList(step.Header, data)
+		if err != nil {
+			return nil, err
+		}
+
+		h, err := tool.CreateHeaderStruct(list)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = h
+	}
+
+	return req, nil
+}
+
+// checkStepResponse compares the response with expectation of step
+// Any 2xx status code is expected if status codes are not specified
+// Headers and body are not checked if status code is not expected
+func checkStepResponse(expect *schemas.APIExpectation, statusCode int, header http.Header, body []byte) []string {
+	if expect == nil || len(expect.StatusCodes) == 0 {
+		if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+			return []string{fmt.Sprintf("status code %d is not successful", statusCode)}
+		}
+	} else if !isStatusCodeInList(statusCode, expect.StatusCodes) {
+		return []string{fmt.Sprintf("status code %d is not one of %v", statusCode, expect.StatusCodes)}
+	}
+
+	if expect == nil {
+		return nil
+	}
+
+	var failures []string
+	for _, key := range sortedKeys(expect.Headers) {
+		if value := header.Get(key); value != expect.Headers[key] {
+			failures = append(failures, fmt.Sprintf("header %s is %q, expected %q", key, value, expect.Headers[key]))
+		}
+	}
+
+	if len(expect.JSON) == 0 {
+		return failures
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return append(failures, fmt.Sprintf("response is not JSON : %s", err.Error()))
+	}
+
+	for _, path := range sortedKeys(expect.JSON) {
+		value, err := lookupJSONPath(data, path)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		if s := formatJSONValue(value); s != expect.JSON[path] {
+			failures = append(failures, fmt.Sprintf("%s is %q, expected %q", path, s, expect.JSON[path]))
+		}
+	}
+
+	return failures
+}
+
+// captureJSON returns values of JSON paths in the response
+func captureJSON(capture map[string]string, body []byte) (map[string]string, error) {
+	if len(capture) == 0 {
+		return nil, nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("response is not JSON : %s", err.Error())
+	}
+
+	captured := map[string]string{}
+	for name, path := range capture {
+		value, err := lookupJSONPath(data, path)
+		if err != nil {
+			return nil, err
+		}
+		captured[name] = formatJSONValue(value)
+	}
+
+	return captured, nil
+}
+
+// lookupJSONPath finds value with simple JSON path like $.data.items[0].id
+func lookupJSONPath(data interface{}, path string) (interface{}, error) {
+	p := strings.TrimPrefix(path, "$")
+	p = strings.NewReplacer("[", ".", "]", "").Replace(p)
+
+	current := data
+	for _, key := range strings.Split(p, ".") {
+		if len(key) == 0 {
+			continue
+		}
+
+		switch v := current.(type) {
+		case map[string]interface{}:
+			value, ok := v[key]
+			if !ok {
+				return nil, fmt.Errorf("%s is not found in response", path)
+			}
+			current = value
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, fmt.Errorf("%s is not found in response", path)
+			}
+			current = v[idx]
+		default:
+			return nil, fmt.Errorf("%s is not found in response", path)
+		}
+	}
+
+	return current, nil
+}
+
+// formatJSONValue converts JSON value to string for comparison
+func formatJSONValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(b)
+}
+
+// usesPrivateIP checks if any step of scenario is templated with private ip address
+func usesPrivateIP(scenario schemas.APIScenario) bool {
+	for _, step := range scenario.Steps {
+		fields := append([]string{step.URL}, step.Body...)
+		fields = append(fields, step.Header...)
+		for _, f := range fields {
+			if strings.Contains(f, ".PrivateIP") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isStatusCodeInList checks if status code is one of codes
+func isStatusCodeInList(statusCode int, codes []int) bool {
+	for _, code := range codes {
+		if code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedKeys returns keys of map in order
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// CheckScenarioResults returns failures of API test scenarios
+func CheckScenarioResults(results []ScenarioResult) []string {
+	var violations []string
+	for _, result := range results {
+		for _, f := range result.Failures {
+			violations = append(violations, fmt.Sprintf("[%s/%s] %s", result.Scenario, result.Step, f))
+		}
+	}
+
+	return violations
+}
+
+// printScenarioResults shows results of API test scenarios
+func printScenarioResults(results []ScenarioResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Scenario", "Step", "Method", "URL", "Status", "Latency", "Result"})
+	table.SetCenterSeparator("|")
+	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+
+	for _, r := range results {
+		status := "ok"
+		if len(r.Failures) > 0 {
+			status = strings.Join(r.Failures, ", ")
+		}
+		table.Append([]string{r.Scenario, r.Step, r.Method, r.URL, strconv.Itoa(r.StatusCode), tool.RoundTime(r.Latency), status})
+	}
+	table.Render()
+}
@@ -0,0 +1,176 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	Logger "github.com/sirupsen/logrus"
+
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(`{"data":{"token":"abc","items":[{"id":1},{"id":2.5}],"active":true,"parent":null}}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	testData := []struct {
+		path     string
+		expected string
+		err      bool
+	}{
+		{path: "$.data.token", expected: "abc"},
+		{path: "data.token", expected: "abc"},
+		{path: "$.data.items[0].id", expected: "1"},
+		{path: "$.data.items.1.id", expected: "2.5"},
+		{path: "$.data.active", expected: "true"},
+		{path: "$.data.parent", expected: "null"},
+		{path: "$.data.items[1]", expected: `{"id":2.5}`},
+		{path: "$.data.items[2].id", err: true},
+		{path: "$.data.unknown", err: true},
+		{path: "$.data.token.length", err: true},
+	}
+
+	for _, td := range testData {
+		value, err := lookupJSONPath(data, td.path)
+		if td.err {
+			if err == nil {
+				t.Errorf("error expected: %s", td.path)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", td.path, err.Error())
+			continue
+		}
+
+		if output := formatJSONValue(value); output != td.expected {
+			t.Errorf("%s expected: %s, output: %s", td.path, td.expected, output)
+		}
+	}
+}
+
+func TestCheckStepResponse(t *testing.T) {
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	body := []byte(`{"status":"ok","count":3}`)
+
+	if output := checkStepResponse(nil, http.StatusCreated, header, body); len(output) != 0 {
+		t.Errorf("expected no failure, output: %v", output)
+	}
+
+	if output := checkStepResponse(nil, http.StatusNotFound, header, body); !reflect.DeepEqual(output, []string{"status code 404 is not successful"}) {
+		t.Errorf("unexpected failures: %v", output)
+	}
+
+	expect := &schemas.APIExpectation{
+		StatusCodes: []int{http.StatusOK, http.StatusNotFound},
+		Headers:     map[string]string{"Content-Type": "text/plain"},
+		JSON:        map[string]string{"$.status": "ok", "$.count": "2"},
+	}
+
+	expected := []string{
+		`header Content-Type is "application/json", expected "text/plain"`,
+		`$.count is "3", expected "2"`,
+	}
+	if output := checkStepResponse(expect, http.StatusNotFound, header, body); !reflect.DeepEqual(output, expected) {
+		t.Errorf("expected: %v, output: %v", expected, output)
+	}
+
+	if output := checkStepResponse(expect, http.StatusOK, header, []byte("ok")); len(output) != 2 || !strings.HasPrefix(output[1], "response is not JSON") {
+		t.Errorf("unexpected failures: %v", output)
+	}
+}
+
+func TestRunAPIScenarios(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/login" && r.Method == http.MethodPost:
+			fmt.Fprint(w, `{"data":{"token":"secret"}}`)
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			fmt.Fprint(w, `{"items":[{"id":"item-1"}]}`)
+		}
+	}))
+	defer server.Close()
+
+	template := schemas.APITestTemplate{
+		Vars: map[string]string{"endpoint": server.URL},
+		Scenarios: []*schemas.APIScenario{
+			{
+				Name: "items",
+				Steps: []*schemas.APIStep{
+					{
+						Name:    "login",
+						Method:  "POST",
+						URL:     "{{ .Vars.endpoint }}/login",
+						Body:    []string{"user=goployer"},
+						Capture: map[string]string{"token": "$.data.token"},
+					},
+					{
+						Name:    "list",
+						Method:  "GET",
+						URL:     "{{ .Vars.endpoint }}/items",
+						Header:  []string{"Authorization=Bearer {{ .Vars.token }}"},
+						Expect:  &schemas.APIExpectation{JSON: map[string]string{"$.items[0].id": "item-1"}},
+						Capture: map[string]string{"item": "$.items[0].id"},
+					},
+					{
+						Name:   "delete",
+						Method: "DELETE",
+						URL:    "{{ .Vars.endpoint }}/items/{{ .Vars.item }}",
+						Header: []string{"Authorization=Bearer {{ .Vars.token }}"},
+						Expect: &schemas.APIExpectation{StatusCodes: []int{http.StatusNoContent}},
+					},
+				},
+			},
+		},
+	}
+
+	d := Deployer{Logger: Logger.New()}
+	vars, results := d.RunAPIScenarios(context.Background(), template, nil)
+	if violations := CheckScenarioResults(results); len(violations) != 0 {
+		t.Errorf("expected no failure, output: %v", violations)
+	}
+
+	if len(results) != 3 || results[2].URL != server.URL+"/items/item-1" {
+		t.Errorf("unexpected results: %v", results)
+	}
+
+	if vars["token"] != "secret" || len(template.Vars) != 1 {
+		t.Errorf("captured variables are not stored: %v", vars)
+	}
+
+	template.Scenarios[0].Steps[1].Header = nil
+	_, results = d.RunAPIScenarios(context.Background(), template, nil)
+	expected := []string{"[items/list] status code 401 is not successful"}
+	if violations := CheckScenarioResults(results); !reflect.DeepEqual(violations, expected) {
+		t.Errorf("expected: %v, output: %v", expected, violations)
+	}
+}
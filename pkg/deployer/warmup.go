@@ -0,0 +1,221 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/olekukonko/tablewriter"
+	vegeta "github.com/tsenart/vegeta/lib"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+	"github.com/DevopsArtFactory/goployer/pkg/tool"
+)
+
+// IsWarmupEnabled checks if new autoscaling group is attached to load balancing targets after warmup
+func IsWarmupEnabled(stack schemas.Stack) bool {
+	return stack.Warmup != nil
+}
+
+// NewWarmupAttacker creates attacker which sends warmup requests to each host
+func NewWarmupAttacker(name string, warmup schemas.WarmupConfig, hosts []string) (*APIAttacker, error) {
+	rate := warmup.RequestPerSecond
+	if rate == 0 {
+		rate = constants.DefaultWarmupRequestPerSecond
+	}
+
+	duration := warmup.Duration
+	if duration == 0 {
+		duration = constants.DefaultWarmupDuration
+	}
+
+	attacker := APIAttacker{
+		Name:     name,
+		Rate:     vegeta.Rate{Freq: rate, Per: time.Second},
+		Duration: duration,
+		Attacker: vegeta.NewAttacker(),
+	}
+
+	for _, request := range warmup.Requests {
+		target := vegeta.Target{
+			Method: strings.ToUpper(request.Method),
+			Header: tool.SetCommonHeader(),
+		}
+
+		if len(request.Body) > 0 {
+			b, err := tool.CreateBodyStruct(request.Body)
+			if err != nil {
+				return nil, err
+			}
+			target.Body = b
+		}
+
+		if len(request.Header) > 0 {
+			h, err := tool.CreateHeaderStruct(request.Header)
+			if err != nil {
+				return nil, err
+			}
+			target.Header = h
+		}
+
+		path := request.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+
+		for _, host := range hosts {
+			t := target
+			t.URL = fmt.Sprintf("http://%s:%d%s", host, warmup.Port, path)
+			attacker.Targets = append(attacker.Targets, t)
+			attacker.APIs = append(attacker.APIs, path)
+		}
+	}
+
+	return &attacker, nil
+}
+
+// GetWarmupMinSuccessRatio returns minimum ratio of successful warmup requests to each instance
+func GetWarmupMinSuccessRatio(warmup schemas.WarmupConfig) float64 {
+	if warmup.MinSuccessRatio == 0 {
+		return constants.DefaultWarmupMinSuccessRatio
+	}
+	return warmup.MinSuccessRatio
+}
+
+// GetFailedWarmups returns URLs of warmup requests of which success ratio is lower than the minimum
+func GetFailedWarmups(results []schemas.MetricResult, minSuccessRatio float64) []string {
+	failed := []string{}
+	for _, r := range results {
+		if r.Data.Success < minSuccessRatio {
+			failed = append(failed, fmt.Sprintf("%s %s", r.Method, r.URL))
+		}
+	}
+	return failed
+}
+
+// warmupAndAttach sends warmup requests to instances which are healthy in instance level
+// and attaches new autoscaling group to load balancers and target groups if warmup requests succeed
+func (d Deployer) warmupAndAttach(ctx context.Context, region schemas.RegionConfig, asg *autoscaling.Group, client aws.Client, threshold int64) error {
+	if err := d.completeLaunchHooks(ctx, client, asg); err != nil {
+		return err
+	}
+
+	hosts, err := d.getWarmupHosts(ctx, client, asg)
+	if err != nil {
+		return err
+	}
+
+	validHostCount := d.GetValidHostCount(hosts)
+	if validHostCount < threshold {
+		d.Logger.Infof("Waiting for instances to be healthy before warmup(%s) : %d/%d", d.AsgNames[region.Region], validHostCount, threshold)
+		return nil
+	}
+
+	if len(d.Stack.Warmup.Requests) > 0 {
+		instances := []string{}
+		for _, host := range hosts {
+			if host.Valid {
+				instances = append(instances, host.InstanceID)
+			}
+		}
+
+		ips, err := client.EC2Service.GetPrivateIPAddresses(ctx, instances)
+		if err != nil {
+			return err
+		}
+
+		addresses := []string{}
+		for _, ip := range ips {
+			addresses = append(addresses, ip)
+		}
+		sort.Strings(addresses)
+
+		attacker, err := NewWarmupAttacker(d.AsgNames[region.Region], *d.Stack.Warmup, addresses)
+		if err != nil {
+			return err
+		}
+
+		d.Logger.Infof("[%s] Warmup requests are sent to %d instances for %s", region.Region, len(addresses), tool.RoundTime(attacker.Duration))
+		results, err := attacker.Run()
+		if err != nil {
+			return err
+		}
+		printWarmupResults(results)
+
+		// Instances are warmed up again in the next polling until all of them respond successfully
+		minSuccessRatio := GetWarmupMinSuccessRatio(*d.Stack.Warmup)
+		if failed := GetFailedWarmups(results, minSuccessRatio); len(failed) > 0 {
+			d.Logger.Warnf("[%s] Load balancing targets are not attached because success ratio of warmup requests is lower than %.4f : %s", region.Region, minSuccessRatio, strings.Join(failed, ", "))
+			return nil
+		}
+	}
+
+	if err := AttachLoadBalancingTargets(ctx, client, d.AsgNames[region.Region], region); err != nil {
+		return err
+	}
+	d.WarmupAttached[region.Region] = true
+
+	d.Logger.Infof("[%s] Load balancing targets are attached after warmup : %s", region.Region, d.AsgNames[region.Region])
+	d.Slack.SendSimpleMessage(fmt.Sprintf("Load balancing targets are attached after warmup : %s", d.AsgNames[region.Region]))
+
+	return nil
+}
+
+// getWarmupHosts returns status of instances which are checked before warmup
+// Instances become InService only after launch lifecycle hooks are completed
+func (d Deployer) getWarmupHosts(ctx context.Context, client aws.Client, asg *autoscaling.Group) ([]aws.HealthcheckHost, error) {
+	if d.Stack.Warmup.Healthcheck != nil {
+		return d.getCheckedHosts(ctx, client, asg, *d.Stack.Warmup.Healthcheck)
+	}
+
+	hosts := []aws.HealthcheckHost{}
+	for _, instance := range asg.Instances {
+		hosts = append(hosts, aws.HealthcheckHost{
+			InstanceID:     *instance.InstanceId,
+			LifecycleState: *instance.LifecycleState,
+			TargetStatus:   "lifecycle hook",
+			HealthStatus:   *instance.HealthStatus,
+			Valid:          *instance.LifecycleState == constants.InServiceStatus && *instance.HealthStatus == "Healthy",
+		})
+	}
+
+	return hosts, nil
+}
+
+// printWarmupResults shows results of warmup requests
+func printWarmupResults(results []schemas.MetricResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Method", "URL", "Requests", "Success", "Latency (P99)"})
+	table.SetCenterSeparator("|")
+	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+
+	for _, r := range results {
+		table.Append([]string{r.Method, r.URL, fmt.Sprintf("%d", r.Data.Requests), fmt.Sprintf("%.4f", r.Data.Success), tool.RoundTime(r.Data.Latencies.P99)})
+	}
+	table.Render()
+}
@@ -0,0 +1,148 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package deployer
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+func TestNewWarmupAttacker(t *testing.T) {
+	warmup := schemas.WarmupConfig{
+		Port: 8080,
+		Requests: []*schemas.WarmupRequest{
+			{Method: "get", Path: "ping"},
+			{Method: "POST", Path: "/cache", Body: []string{"key=value"}, Header: []string{"Content-Type=application/json"}},
+		},
+	}
+
+	attacker, err := NewWarmupAttacker("hello-artd_v001", warmup, []string{"10.0.0.1", "10.0.0.2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urls := []string{}
+	for _, target := range attacker.Targets {
+		urls = append(urls, target.Method+" "+target.URL)
+	}
+
+	expected := []string{
+		"GET http://10.0.0.1:8080/ping",
+		"GET http://10.0.0.2:8080/ping",
+		"POST http://10.0.0.1:8080/cache",
+		"POST http://10.0.0.2:8080/cache",
+	}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("expected: %v, output: %v", expected, urls)
+	}
+
+	if attacker.Rate.Freq != constants.DefaultWarmupRequestPerSecond || attacker.Duration != constants.DefaultWarmupDuration {
+		t.Errorf("default rate and duration are not applied: %v, %s", attacker.Rate, attacker.Duration)
+	}
+
+	if string(attacker.Targets[2].Body) != `{"key":"value"}` || attacker.Targets[2].Header.Get("Content-Type") != "application/json" {
+		t.Errorf("wrong request: %s, %v", attacker.Targets[2].Body, attacker.Targets[2].Header)
+	}
+}
+
+func TestRunWarmupAttacker(t *testing.T) {
+	var count int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&count, 1)
+	}))
+	defer server.Close()
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := strconv.ParseInt(port, 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	warmup := schemas.WarmupConfig{
+		Port:             p,
+		Requests:         []*schemas.WarmupRequest{{Method: "GET", Path: "/ping"}},
+		RequestPerSecond: 20,
+		Duration:         500 * time.Millisecond,
+	}
+
+	attacker, err := NewWarmupAttacker("hello-artd_v001", warmup, []string{host})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := attacker.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].Data.Success != 1 || results[0].API != "/ping" {
+		t.Errorf("wrong results: %+v", results)
+	}
+
+	if len(results[0].Results) > 0 {
+		t.Errorf("raw results should not be kept: %d", len(results[0].Results))
+	}
+
+	attacker.KeepResults = true
+	results, err = attacker.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if uint64(len(results[0].Results)) != results[0].Data.Requests {
+		t.Errorf("raw results should be kept for all requests: %d/%d", len(results[0].Results), results[0].Data.Requests)
+	}
+
+	if atomic.LoadInt64(&count) == 0 {
+		t.Errorf("no warmup request is sent")
+	}
+}
+
+func TestGetFailedWarmups(t *testing.T) {
+	if output := GetWarmupMinSuccessRatio(schemas.WarmupConfig{}); output != constants.DefaultWarmupMinSuccessRatio {
+		t.Errorf("expected: %f, output: %f", constants.DefaultWarmupMinSuccessRatio, output)
+	}
+
+	results := []schemas.MetricResult{
+		{Method: "GET", URL: "http://10.0.0.1:8080/ping", Data: vegeta.Metrics{Success: 1}},
+		{Method: "GET", URL: "http://10.0.0.2:8080/ping", Data: vegeta.Metrics{Success: 0}},
+		{Method: "POST", URL: "http://10.0.0.1:8080/cache", Data: vegeta.Metrics{Success: 0.95}},
+	}
+
+	if output := GetFailedWarmups(results, 0.9); !reflect.DeepEqual(output, []string{"GET http://10.0.0.2:8080/ping"}) {
+		t.Errorf("wrong failed warmups: %v", output)
+	}
+
+	if output := GetFailedWarmups(results, 1); len(output) != 2 {
+		t.Errorf("wrong failed warmups: %v", output)
+	}
+}
@@ -17,6 +17,7 @@ limitations under the license.
 package inspector
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -74,8 +75,8 @@ func New(region string) Inspector {
 }
 
 // SelectStack selects a stack
-func (i Inspector) SelectStack(application string) (string, error) {
-	asgOptions, err := i.GetStacks(application)
+func (i Inspector) SelectStack(ctx context.Context, application string) (string, error) {
+	asgOptions, err := i.GetStacks(ctx, application)
 	if err != nil {
 		return "", err
 	}
@@ -99,8 +100,8 @@ func (i Inspector) SelectStack(application string) (string, error) {
 }
 
 // GetStacks returns stacks from application prefix
-func (i Inspector) GetStacks(application string) ([]string, error) {
-	asgGroups := i.AWSClient.EC2Service.GetAllMatchingAutoscalingGroupsWithPrefix(application)
+func (i Inspector) GetStacks(ctx context.Context, application string) ([]string, error) {
+	asgGroups := i.AWSClient.EC2Service.GetAllMatchingAutoscalingGroupsWithPrefix(ctx, application)
 	options := []string{}
 	for _, a := range asgGroups {
 		options = append(options, *a.AutoScalingGroupName)
@@ -113,8 +114,8 @@ func (i Inspector) GetStacks(application string) ([]string, error) {
 	return options, nil
 }
 
-func (i Inspector) GetStackInformation(asgName string) (*autoscaling.Group, error) {
-	asg, err := i.AWSClient.EC2Service.GetMatchingAutoscalingGroup(asgName)
+func (i Inspector) GetStackInformation(ctx context.Context, asgName string) (*autoscaling.Group, error) {
+	asg, err := i.AWSClient.EC2Service.GetMatchingAutoscalingGroup(ctx, asgName)
 	if err != nil {
 		return nil, err
 	}
@@ -123,8 +124,8 @@ func (i Inspector) GetStackInformation(asgName string) (*autoscaling.Group, erro
 }
 
 // GetLaunchTemplateInformation retrieves single launch template information
-func (i Inspector) GetLaunchTemplateInformation(ltID string) (*ec2.LaunchTemplateVersion, error) {
-	lt, err := i.AWSClient.EC2Service.GetMatchingLaunchTemplate(ltID)
+func (i Inspector) GetLaunchTemplateInformation(ctx context.Context, ltID string) (*ec2.LaunchTemplateVersion, error) {
+	lt, err := i.AWSClient.EC2Service.GetMatchingLaunchTemplate(ctx, ltID)
 	if err != nil {
 		return nil, nil
 	}
@@ -133,12 +134,12 @@ func (i Inspector) GetLaunchTemplateInformation(ltID string) (*ec2.LaunchTemplat
 }
 
 // GetSecurityGroupsInformation retrieves security groups' information
-func (i Inspector) GetSecurityGroupsInformation(sgIds []*string) ([]*ec2.SecurityGroup, error) {
+func (i Inspector) GetSecurityGroupsInformation(ctx context.Context, sgIds []*string) ([]*ec2.SecurityGroup, error) {
 	if len(sgIds) == 0 {
 		return nil, nil
 	}
 
-	ret, err := i.AWSClient.EC2Service.GetSecurityGroupDetails(sgIds)
+	ret, err := i.AWSClient.EC2Service.GetSecurityGroupDetails(ctx, sgIds)
 	if err != nil {
 		return nil, err
 	}
@@ -287,8 +288,8 @@ func (i Inspector) Print() error {
 }
 
 // Update will update autoscaling group configuration
-func (i Inspector) Update() error {
-	if err := i.AWSClient.EC2Service.UpdateAutoScalingGroup(i.UpdateFields.AutoscalingName, i.UpdateFields.Capacity); err != nil {
+func (i Inspector) Update(ctx context.Context) error {
+	if err := i.AWSClient.EC2Service.UpdateAutoScalingGroup(ctx, i.UpdateFields.AutoscalingName, i.UpdateFields.Capacity); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,233 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+	"github.com/DevopsArtFactory/goployer/pkg/tool"
+)
+
+// Lock is the deployment lock of autoscaling groups with the same prefix
+type Lock struct {
+	// Prefix of autoscaling groups which is made of application, environment and region
+	ID string `json:"id"`
+
+	// Host and process which holds the lock
+	Owner string `json:"owner"`
+
+	// Deployment which holds the lock
+	DeploymentID string `json:"deployment_id"`
+
+	// Unix timestamp when the lock is acquired
+	AcquiredAt int64 `json:"acquired_at"`
+
+	// Unix timestamp when the lease of the lock expires
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// Locker takes deployment locks with conditional writes on dynamodb table
+type Locker struct {
+	Client aws.DynamoDBClient
+	Table  string
+	Owner  string
+	Lease  time.Duration
+}
+
+// ParseTable parses location of deployment lock
+// Available formats are `dynamodb` and `dynamodb://<table>`
+func ParseTable(location string) (string, error) {
+	switch {
+	case location == constants.DynamoDBStateStore:
+		return constants.EmptyString, nil
+	case strings.HasPrefix(location, constants.DynamoDBPrefix):
+		table := strings.TrimPrefix(location, constants.DynamoDBPrefix)
+		if len(table) == 0 || strings.Contains(table, "/") {
+			return constants.EmptyString, fmt.Errorf("invalid dynamodb table of deployment lock : %s", location)
+		}
+		return table, nil
+	}
+
+	return constants.EmptyString, fmt.Errorf("deployment lock should be one of dynamodb or dynamodb://<table> : %s", location)
+}
+
+// New creates locker with the location
+// Deployment lock is disabled if location is empty, and metric table is used if table is not specified
+func New(location, region string, lease time.Duration, metricConfig schemas.MetricConfig) (*Locker, error) {
+	if len(location) == 0 {
+		return nil, nil
+	}
+
+	table, err := ParseTable(location)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(table) == 0 {
+		table = metricConfig.Storage.Name
+	}
+
+	if len(region) == 0 {
+		region = metricConfig.Region
+	}
+
+	if len(table) == 0 {
+		return nil, errors.New("you have to specify the table of deployment lock or metric storage")
+	}
+
+	if len(region) == 0 {
+		return nil, errors.New("you have to specify the region of deployment lock")
+	}
+
+	if lease == 0 {
+		lease = constants.DefaultLockLease
+	}
+
+	if lease < constants.MinLockLease {
+		return nil, fmt.Errorf("lease of deployment lock cannot be smaller than %.0f sec", constants.MinLockLease.Seconds())
+	}
+
+	client := aws.BootstrapStateService(region, constants.EmptyString)
+
+	return &Locker{
+		Client: client.DynamoDBService,
+		Table:  table,
+		Owner:  generateOwner(),
+		Lease:  lease,
+	}, nil
+}
+
+// GenerateID creates lock id with the prefix of autoscaling groups
+func GenerateID(app, env, region string) string {
+	return tool.BuildPrefixName(app, env, region)
+}
+
+// Acquire takes the lock if no one holds it or the lease of it is expired
+func (l *Locker) Acquire(id, deploymentID string) error {
+	now := time.Now()
+	ok, err := l.Client.PutLock(l.key(id), l.Owner, deploymentID, now.Unix(), now.Add(l.Lease).Unix(), l.Table)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return nil
+	}
+
+	item, err := l.Client.GetSingleItem(l.key(id), l.Table)
+	if err != nil {
+		return err
+	}
+
+	lock := parseItem(item)
+	return fmt.Errorf("deployment is locked by %s(%s) until %s : %s", lock.Owner, lock.DeploymentID, time.Unix(lock.ExpiresAt, 0).Format(time.RFC3339), id)
+}
+
+// Renew extends the lease of the lock held by this locker
+func (l *Locker) Renew(id string) error {
+	ok, err := l.Client.RenewLock(l.key(id), l.Owner, time.Now().Add(l.Lease).Unix(), l.Table)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("deployment lock is taken by another owner : %s", id)
+	}
+
+	return nil
+}
+
+// Release deletes the lock only if it is held by this locker
+func (l *Locker) Release(id string) error {
+	return l.Client.DeleteLock(l.key(id), l.Owner, l.Table)
+}
+
+// ForceRelease deletes the lock regardless of the owner
+func (l *Locker) ForceRelease(id string) error {
+	return l.Client.DeleteItem(l.key(id), l.Table)
+}
+
+// List returns all locks in the table sorted by id
+func (l *Locker) List() ([]Lock, error) {
+	items, err := l.Client.ScanItemsWithPrefix(constants.DeploymentLockKeyPrefix, l.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	locks := []Lock{}
+	for _, item := range items {
+		locks = append(locks, parseItem(item))
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].ID < locks[j].ID
+	})
+
+	return locks, nil
+}
+
+// key returns hash key of deployment lock
+func (l *Locker) key(id string) string {
+	return fmt.Sprintf("%s%s", constants.DeploymentLockKeyPrefix, id)
+}
+
+// parseItem converts dynamodb item to lock
+func parseItem(item map[string]*dynamodb.AttributeValue) Lock {
+	lock := Lock{}
+	if v, ok := item[constants.HashKey]; ok && v.S != nil {
+		lock.ID = strings.TrimPrefix(*v.S, constants.DeploymentLockKeyPrefix)
+	}
+
+	if v, ok := item["lock_owner"]; ok && v.S != nil {
+		lock.Owner = *v.S
+	}
+
+	if v, ok := item["deployment_id"]; ok && v.S != nil {
+		lock.DeploymentID = *v.S
+	}
+
+	if v, ok := item["acquired_at"]; ok && v.N != nil {
+		lock.AcquiredAt, _ = strconv.ParseInt(*v.N, 10, 64)
+	}
+
+	if v, ok := item["expires_at"]; ok && v.N != nil {
+		lock.ExpiresAt, _ = strconv.ParseInt(*v.N, 10, 64)
+	}
+
+	return lock
+}
+
+// generateOwner creates owner of lock with hostname and process id
+func generateOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
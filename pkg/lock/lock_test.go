@@ -0,0 +1,80 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package lock
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/go-test/deep"
+)
+
+func TestParseTable(t *testing.T) {
+	testData := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{input: "dynamodb", expected: ""},
+		{input: "dynamodb://goployer-locks", expected: "goployer-locks"},
+		{input: "dynamodb://", err: true},
+		{input: "dynamodb://table/prefix", err: true},
+		{input: "s3://goployer/locks", err: true},
+		{input: "local", err: true},
+	}
+
+	for _, td := range testData {
+		table, err := ParseTable(td.input)
+		if td.err {
+			if err == nil {
+				t.Errorf("error expected: %s", td.input)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", td.input, err.Error())
+		}
+
+		if table != td.expected {
+			t.Errorf("expected: %s, got: %s", td.expected, table)
+		}
+	}
+}
+
+func TestParseItem(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"identifier":    {S: aws.String("goployer-locks/hello-dev_apnortheast2")},
+		"lock_owner":    {S: aws.String("ci-runner:1234")},
+		"deployment_id": {S: aws.String("hello-1602830000")},
+		"acquired_at":   {N: aws.String("1602830000")},
+		"expires_at":    {N: aws.String("1602830300")},
+	}
+
+	expected := Lock{
+		ID:           GenerateID("hello", "dev", "ap-northeast-2"),
+		Owner:        "ci-runner:1234",
+		DeploymentID: "hello-1602830000",
+		AcquiredAt:   1602830000,
+		ExpiresAt:    1602830300,
+	}
+
+	if diff := deep.Equal(parseItem(item), expected); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package runner
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	Logger "github.com/sirupsen/logrus"
+
+	"github.com/DevopsArtFactory/goployer/pkg/lock"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+	"github.com/DevopsArtFactory/goployer/pkg/tool"
+)
+
+// lease holds deployment locks and renews them until goployer finishes changing autoscaling groups
+// All methods do nothing if lease is nil
+type lease struct {
+	locker *lock.Locker
+	ids    []string
+	logger *Logger.Logger
+	stop   context.CancelFunc
+	done   chan struct{}
+}
+
+// acquireLease takes deployment locks of all ids and starts renewing them in background
+// Returned context is cancelled if any lock is lost in the middle
+func acquireLease(ctx context.Context, locker *lock.Locker, ids []string, deploymentID string, logger *Logger.Logger) (context.Context, *lease, error) {
+	if locker == nil || len(ids) == 0 {
+		return ctx, nil, nil
+	}
+
+	l := &lease{
+		locker: locker,
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+
+	for _, id := range ids {
+		if err := locker.Acquire(id, deploymentID); err != nil {
+			l.releaseLocks()
+			return ctx, nil, err
+		}
+		logger.Debugf("deployment lock is acquired : %s", id)
+		l.ids = append(l.ids, id)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	renewCtx, stop := context.WithCancel(context.Background())
+	l.stop = stop
+	go l.renew(renewCtx, cancel)
+
+	return ctx, l, nil
+}
+
+// renew extends leases of locks periodically and cancels the deployment if any lock is lost
+func (l *lease) renew(ctx context.Context, cancel context.CancelFunc) {
+	defer close(l.done)
+	defer cancel()
+
+	for {
+		if err := tool.Sleep(ctx, l.locker.Lease/3); err != nil {
+			return
+		}
+
+		for _, id := range l.ids {
+			if err := l.locker.Renew(id); err != nil {
+				l.logger.Errorf("deployment is cancelled because lease of lock cannot be renewed: %s", err.Error())
+				return
+			}
+		}
+	}
+}
+
+// release stops renewal and deletes all locks
+func (l *lease) release() {
+	if l == nil {
+		return
+	}
+
+	l.stop()
+	<-l.done
+	l.releaseLocks()
+}
+
+// releaseLocks deletes locks which are acquired
+func (l *lease) releaseLocks() {
+	for _, id := range l.ids {
+		if err := l.locker.Release(id); err != nil {
+			l.logger.Errorf("failed to release deployment lock %s: %s", id, err.Error())
+			continue
+		}
+		l.logger.Debugf("deployment lock is released : %s", id)
+	}
+}
+
+// lockStacks takes deployment locks of the stacks in the selected regions
+func (r Runner) lockStacks(ctx context.Context, locker *lock.Locker, stacks []schemas.Stack) (context.Context, *lease, error) {
+	ids := getLockIDs(r.Builder.AwsConfig.Name, stacks, r.Builder.Config.Stack, r.Builder.Config.Region)
+	return acquireLease(ctx, locker, ids, r.Builder.Config.DeploymentID, r.Logger)
+}
+
+// getLockIDs returns sorted ids of deployment locks for regions of the stacks
+func getLockIDs(app string, stacks []schemas.Stack, selectedStack, selectedRegion string) []string {
+	ids := []string{}
+	for _, stack := range stacks {
+		if len(selectedStack) > 0 && stack.Stack != selectedStack {
+			continue
+		}
+
+		for _, rc := range stack.Regions {
+			if len(selectedRegion) > 0 && rc.Region != selectedRegion {
+				continue
+			}
+
+			id := lock.GenerateID(app, stack.Env, rc.Region)
+			if !tool.IsStringInArray(id, ids) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	// locks are always taken in the same order not to be deadlocked with other deployments
+	sort.Strings(ids)
+
+	return ids
+}
+
+// formatLockTime formats unix timestamp of lock
+func formatLockTime(timestamp int64) string {
+	return time.Unix(timestamp, 0).Format(time.RFC3339)
+}
@@ -18,9 +18,15 @@ package runner
 
 import (
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/go-test/deep"
 
+	"github.com/DevopsArtFactory/goployer/pkg/builder"
+	"github.com/DevopsArtFactory/goployer/pkg/collector"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
 	"github.com/DevopsArtFactory/goployer/pkg/schemas"
 )
 
@@ -86,3 +92,332 @@ func TestCheckUpdateInformation(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRollbackVersion(t *testing.T) {
+	testData := map[string]int{
+		"v012": 12,
+		"12":   12,
+		"v000": 0,
+	}
+
+	for input, expected := range testData {
+		output, err := parseRollbackVersion(input)
+		if err != nil || output != expected {
+			t.Errorf("expected: %d, output: %d", expected, output)
+		}
+	}
+
+	for _, input := range []string{"", "vabc", "v1000", "-1"} {
+		if _, err := parseRollbackVersion(input); err == nil {
+			t.Errorf("invalid version should not be parsed: %s", input)
+		}
+	}
+}
+
+func TestGetRollbackCandidates(t *testing.T) {
+	if diff := deep.Equal(getRollbackCandidates(5, 3), []int{4, 3, 2}); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(getRollbackCandidates(1, 3), []int{0, 999, 998}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestGetRollbackRegions(t *testing.T) {
+	stack := schemas.Stack{
+		Stack:   "artd",
+		Regions: []schemas.RegionConfig{{Region: "ap-northeast-2"}, {Region: "us-east-1"}},
+	}
+
+	output, err := getRollbackRegions(stack, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(output, []string{"ap-northeast-2", "us-east-1"}); diff != nil {
+		t.Error(diff)
+	}
+
+	output, err = getRollbackRegions(stack, "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(output, []string{"us-east-1"}); diff != nil {
+		t.Error(diff)
+	}
+
+	if _, err := getRollbackRegions(stack, "eu-west-1"); err == nil {
+		t.Errorf("region which is not in the stack should return error")
+	}
+}
+
+func TestGetRollbackStack(t *testing.T) {
+	apne2 := collector.DeploymentRecord{
+		AutoScalingGroup: "hello-artd_apne2-v002",
+		Stack: schemas.Stack{
+			Stack:           "artd",
+			ReplacementType: constants.CanaryDeployment,
+			ManualPromotion: true,
+			Rollout:         &schemas.RolloutConfig{Waves: [][]string{{"ap-northeast-2"}, {"us-east-1"}}},
+			Capacity:        schemas.Capacity{Min: 1, Max: 2, Desired: 1},
+			Regions:         []schemas.RegionConfig{{Region: "ap-northeast-2", AmiID: "ami-manifest"}, {Region: "us-east-1"}},
+		},
+		Config:          schemas.Config{Ami: "ami-recorded", OverrideInstanceType: "c5.large"},
+		AppliedCapacity: &schemas.Capacity{Min: 4, Max: 8, Desired: 6},
+	}
+	use1 := collector.DeploymentRecord{
+		AutoScalingGroup: "hello-artd_use1-v001",
+		Stack: schemas.Stack{
+			Stack:    "artd",
+			Capacity: schemas.Capacity{Min: 2, Max: 4, Desired: 2},
+			Regions:  []schemas.RegionConfig{{Region: "ap-northeast-2"}, {Region: "us-east-1", AmiID: "ami-use1", InstanceType: "t3.medium"}},
+		},
+	}
+	records := map[string]collector.DeploymentRecord{"ap-northeast-2": apne2, "us-east-1": use1}
+
+	output, err := getRollbackStack(records, schemas.Stack{AssumeRole: "arn:aws:iam::123456789012:role/deploy"}, []string{"ap-northeast-2", "us-east-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output.ReplacementType != constants.BlueGreenDeployment || output.AssumeRole != "arn:aws:iam::123456789012:role/deploy" {
+		t.Errorf("wrong rollback stack: %s, %s", output.ReplacementType, output.AssumeRole)
+	}
+
+	if output.ManualPromotion || output.Rollout != nil {
+		t.Errorf("manual promotion and rollout waves should not be applied to rollback: %t, %+v", output.ManualPromotion, output.Rollout)
+	}
+
+	if len(output.Regions) != 2 {
+		t.Fatalf("wrong regions: %+v", output.Regions)
+	}
+
+	if output.Regions[0].AmiID != "ami-recorded" || output.Regions[0].InstanceType != "c5.large" {
+		t.Errorf("wrong region: %+v", output.Regions[0])
+	}
+
+	if diff := deep.Equal(*output.Regions[0].Capacity, *apne2.AppliedCapacity); diff != nil {
+		t.Error(diff)
+	}
+
+	if output.Regions[1].AmiID != "ami-use1" || output.Regions[1].InstanceType != "t3.medium" {
+		t.Errorf("wrong region: %+v", output.Regions[1])
+	}
+
+	// capacity of manifest is used for the record without applied capacity
+	if diff := deep.Equal(*output.Regions[1].Capacity, use1.Stack.Capacity); diff != nil {
+		t.Error(diff)
+	}
+
+	if _, err := getRollbackStack(records, schemas.Stack{}, []string{"eu-west-1"}); err == nil {
+		t.Errorf("region without deployment record should return error")
+	}
+
+	records["eu-west-1"] = apne2
+	if _, err := getRollbackStack(records, schemas.Stack{}, []string{"eu-west-1"}); err == nil {
+		t.Errorf("region which is not in the deployment record should return error")
+	}
+}
+
+func TestSelectStandbyTarget(t *testing.T) {
+	prefix := "hello-dev_apne2"
+	standbyTags := []*autoscaling.TagDescription{{Key: aws.String(constants.StandbyTagKey), Value: aws.String("true")}}
+	groups := []*autoscaling.Group{
+		{AutoScalingGroupName: aws.String("hello-dev_apne2-v001"), Tags: standbyTags},
+		{AutoScalingGroupName: aws.String("hello-dev_apne2-v002"), Tags: standbyTags},
+		{AutoScalingGroupName: aws.String("hello-dev_apne2-v003")},
+	}
+
+	current, target, err := selectStandbyTarget(groups, prefix, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *current.AutoScalingGroupName != "hello-dev_apne2-v003" || *target.AutoScalingGroupName != "hello-dev_apne2-v002" {
+		t.Errorf("wrong standby target: %s -> %s", *current.AutoScalingGroupName, *target.AutoScalingGroupName)
+	}
+
+	if _, target, err = selectStandbyTarget(groups, prefix, 1); err != nil || *target.AutoScalingGroupName != "hello-dev_apne2-v001" {
+		t.Errorf("specified standby version is not selected: %v", err)
+	}
+
+	if _, _, err := selectStandbyTarget(groups, prefix, 3); err == nil {
+		t.Errorf("current version should not be a standby target")
+	}
+
+	if _, _, err := selectStandbyTarget(groups[:2], prefix, -1); err == nil {
+		t.Errorf("standby target should not be selected without current version")
+	}
+}
+
+func TestNeedsPromotion(t *testing.T) {
+	stacks := []schemas.Stack{
+		{Stack: "artd"},
+		{Stack: "artp", ManualPromotion: true},
+	}
+
+	if !needsPromotion(stacks, "") {
+		t.Errorf("deployment with all stacks should wait for promotion")
+	}
+
+	if !needsPromotion(stacks, "artp") {
+		t.Errorf("deployment of artp should wait for promotion")
+	}
+
+	if needsPromotion(stacks, "artd") {
+		t.Errorf("deployment of artd should not wait for promotion")
+	}
+}
+
+func TestBuildWaves(t *testing.T) {
+	stacks := []schemas.Stack{
+		{
+			Stack: "artd",
+			Rollout: &schemas.RolloutConfig{
+				Waves: [][]string{{"us-east-1"}, {"eu-west-1", "ap-northeast-2"}},
+			},
+			Regions: []schemas.RegionConfig{{Region: "ap-northeast-2"}, {Region: "us-east-1"}, {Region: "eu-west-1"}},
+		},
+		{
+			Stack:   "artp",
+			Regions: []schemas.RegionConfig{{Region: "ap-northeast-2"}},
+		},
+	}
+
+	waves := buildWaves(stacks, "")
+	if len(waves) != 2 {
+		t.Fatalf("expected 2 waves, output: %d", len(waves))
+	}
+
+	if diff := deep.Equal(getWaveRegions(waves[0]), []string{"us-east-1", "ap-northeast-2"}); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(getWaveRegions(waves[1]), []string{"ap-northeast-2", "eu-west-1"}); diff != nil {
+		t.Error(diff)
+	}
+
+	if waves := buildWaves(stacks, "us-east-1"); len(waves) != 1 || len(waves[0]) != 2 {
+		t.Errorf("all stacks should be in one wave if region is specified")
+	}
+}
+
+func TestMakePlanRows(t *testing.T) {
+	plan := schemas.DeploymentPlan{
+		Action: "delete",
+		Regions: []schemas.RegionPlan{
+			{
+				Stack:                    "artd",
+				Region:                   "ap-northeast-2",
+				RemovedAutoScalingGroups: []string{"hello-artd_apne2-v001", "hello-artd_apne2-v002"},
+			},
+			{
+				Stack:  "artd",
+				Region: "us-east-1",
+			},
+		},
+	}
+
+	expected := [][]string{
+		{"artd", "ap-northeast-2", "removed", "hello-artd_apne2-v001,hello-artd_apne2-v002"},
+		{"artd", "us-east-1", "removed", "-"},
+	}
+
+	if diff := deep.Equal(makePlanRows(plan), expected); diff != nil {
+		t.Error(diff)
+	}
+
+	plan.Regions[0].AutoScalingGroup = "hello-artd_apne2-v003"
+	plan.Regions[0].Capacity = &schemas.Capacity{Min: 1, Max: 3, Desired: 2}
+	rows := makePlanRows(plan)
+	if len(rows) != 16 {
+		t.Fatalf("expected 16 rows, output: %d", len(rows))
+	}
+
+	if diff := deep.Equal(rows[1], []string{"artd", "ap-northeast-2", "autoscaling group", "hello-artd_apne2-v003"}); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(rows[13], []string{"artd", "ap-northeast-2", "capacity", "min: 1, desired: 2, max: 3"}); diff != nil {
+		t.Error(diff)
+	}
+
+	plan.Regions[0].AttachAfterWarmup = true
+	rows = makePlanRows(plan)
+	if diff := deep.Equal(rows[14], []string{"artd", "ap-northeast-2", "attach", "load balancers and target groups are attached after warmup"}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPostHealthSteps(t *testing.T) {
+	steps := []int64{}
+	for _, step := range postHealthSteps {
+		steps = append(steps, step.id)
+		if step.forceRollback != (step.id == constants.StepBake) {
+			t.Errorf("only bake should delete new versions regardless of rollback_on_failure: %s", step.name)
+		}
+	}
+
+	if diff := deep.Equal(steps, []int64{constants.StepPostDeployCallback, constants.StepBake, constants.StepAPITest}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestSetDeployerState(t *testing.T) {
+	states := setDeployerState(nil, schemas.DeployerState{Stack: "artd", Wave: 0})
+	states = setDeployerState(states, schemas.DeployerState{Stack: "artp", Wave: 0})
+	states = setDeployerState(states, schemas.DeployerState{Stack: "artd", Wave: 1})
+
+	expected := []schemas.DeployerState{
+		{Stack: "artd", Wave: 1},
+		{Stack: "artp", Wave: 0},
+	}
+
+	if diff := deep.Equal(states, expected); diff != nil {
+		t.Error(diff)
+	}
+
+	var tr *tracker
+	if tr.resumed("artd") != nil {
+		t.Errorf("nil tracker should not return saved state")
+	}
+}
+
+func TestGetLockIDs(t *testing.T) {
+	stacks := []schemas.Stack{
+		{Stack: "artd", Env: "dev", Regions: []schemas.RegionConfig{{Region: "us-east-1"}, {Region: "ap-northeast-2"}}},
+		{Stack: "artd2", Env: "dev", Regions: []schemas.RegionConfig{{Region: "ap-northeast-2"}}},
+		{Stack: "artp", Env: "prod", Regions: []schemas.RegionConfig{{Region: "ap-northeast-2"}}},
+	}
+
+	expected := []string{"hello-dev_apnortheast2", "hello-dev_useast1", "hello-prod_apnortheast2"}
+	if diff := deep.Equal(getLockIDs("hello", stacks, "", ""), expected); diff != nil {
+		t.Error(diff)
+	}
+
+	expected = []string{"hello-dev_apnortheast2"}
+	if diff := deep.Equal(getLockIDs("hello", stacks, "artd", "ap-northeast-2"), expected); diff != nil {
+		t.Error(diff)
+	}
+
+	var l *lease
+	l.release()
+}
+
+func TestWithDeploymentLock(t *testing.T) {
+	deployment := schemas.DeploymentState{
+		Config: schemas.Config{Lock: "dynamodb://goployer-locks", LockRegion: "ap-northeast-2", LockLease: 10 * time.Minute},
+	}
+
+	r := Runner{Builder: builder.Builder{Config: schemas.Config{DeploymentID: "hello-1602830000"}}}.withDeploymentLock(deployment)
+	if r.Builder.Config.Lock != "dynamodb://goployer-locks" || r.Builder.Config.LockRegion != "ap-northeast-2" || r.Builder.Config.LockLease != 10*time.Minute {
+		t.Errorf("lock of deployment should be used: %+v", r.Builder.Config)
+	}
+
+	r = Runner{Builder: builder.Builder{Config: schemas.Config{Lock: "dynamodb"}}}.withDeploymentLock(deployment)
+	if r.Builder.Config.Lock != "dynamodb" || len(r.Builder.Config.LockRegion) > 0 {
+		t.Errorf("lock of command should be used: %+v", r.Builder.Config)
+	}
+}
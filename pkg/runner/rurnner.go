@@ -17,16 +17,21 @@ limitations under the license.
 package runner
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/GwonsooLee/kubenx/pkg/color"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/olekukonko/tablewriter"
 	Logger "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
@@ -37,8 +42,10 @@ import (
 	"github.com/DevopsArtFactory/goployer/pkg/deployer"
 	"github.com/DevopsArtFactory/goployer/pkg/initializer"
 	"github.com/DevopsArtFactory/goployer/pkg/inspector"
+	"github.com/DevopsArtFactory/goployer/pkg/lock"
 	"github.com/DevopsArtFactory/goployer/pkg/schemas"
 	"github.com/DevopsArtFactory/goployer/pkg/slack"
+	"github.com/DevopsArtFactory/goployer/pkg/state"
 	"github.com/DevopsArtFactory/goployer/pkg/tool"
 )
 
@@ -47,7 +54,8 @@ type Runner struct {
 	Builder    builder.Builder
 	Collector  collector.Collector
 	Slacker    slack.Slack
-	FuncMapper map[string]func() error
+	Tracker    *tracker
+	FuncMapper map[string]func(context.Context) error
 }
 
 // SetupBuilder setup builder struct for configuration
@@ -179,7 +187,7 @@ func AddManifest(args []string) error {
 }
 
 // Start function is the starting point of all processes.
-func Start(builderSt builder.Builder, mode string) error {
+func Start(ctx context.Context, builderSt builder.Builder, mode string) error {
 	if checkManifestCommands(mode) {
 		// Check validation of configurations
 		if err := builderSt.CheckValidation(); err != nil {
@@ -188,16 +196,20 @@ func Start(builderSt builder.Builder, mode string) error {
 	}
 
 	// run with runner
-	return withRunner(builderSt, mode, func(slacker slack.Slack) error {
+	return withRunner(ctx, builderSt, mode, func(slacker slack.Slack) error {
 		// These are post actions after deployment
 		if !builderSt.Config.SlackOff {
-			if mode == "deploy" {
+			if mode == "deploy" && !builderSt.Config.Plan && !needsPromotion(builderSt.Stacks, builderSt.Config.Stack) {
 				slacker.SendSimpleMessage(fmt.Sprintf(":100: Deployment is done: %s", builderSt.AwsConfig.Name))
 			}
 
-			if mode == "delete" {
+			if mode == "delete" && !builderSt.Config.Plan {
 				slacker.SendSimpleMessage(fmt.Sprintf(":100: Delete process is done: %s", builderSt.AwsConfig.Name))
 			}
+
+			if mode == "rollback" {
+				slacker.SendSimpleMessage(fmt.Sprintf(":100: Rollback is done: %s", builderSt.AwsConfig.Name))
+			}
 		}
 
 		return nil
@@ -205,14 +217,14 @@ func Start(builderSt builder.Builder, mode string) error {
 }
 
 // withRunner creates runner and runs the deployment process
-func withRunner(builderSt builder.Builder, mode string, postAction func(slacker slack.Slack) error) error {
+func withRunner(ctx context.Context, builderSt builder.Builder, mode string, postAction func(slacker slack.Slack) error) error {
 	runner, err := NewRunner(builderSt, mode)
 	if err != nil {
 		return err
 	}
 	runner.LogFormatting(builderSt.Config.LogLevel)
 
-	if err := runner.Run(mode); err != nil {
+	if err := runner.Run(ctx, mode); err != nil {
 		return err
 	}
 
@@ -231,11 +243,17 @@ func NewRunner(newBuilder builder.Builder, mode string) (Runner, error) {
 		newRunner.Collector = collector.NewCollector(newBuilder.MetricConfig, newBuilder.Config.AssumeRole)
 	}
 
-	newRunner.FuncMapper = map[string]func() error{
-		"deploy": newRunner.Deploy,
-		"delete": newRunner.Delete,
-		"status": newRunner.Status,
-		"update": newRunner.Update,
+	newRunner.FuncMapper = map[string]func(context.Context) error{
+		"deploy":       newRunner.Deploy,
+		"delete":       newRunner.Delete,
+		"status":       newRunner.Status,
+		"update":       newRunner.Update,
+		"rollback":     newRunner.Rollback,
+		"promote":      newRunner.Promote,
+		"abort":        newRunner.Abort,
+		"resume":       newRunner.Resume,
+		"lock-list":    newRunner.LockList,
+		"lock-release": newRunner.LockRelease,
 	}
 
 	return newRunner, nil
@@ -248,16 +266,16 @@ func (r Runner) LogFormatting(logLevel string) {
 }
 
 // Run executes all required steps for deployments
-func (r Runner) Run(mode string) error {
+func (r Runner) Run(ctx context.Context, mode string) error {
 	f, ok := r.FuncMapper[mode]
 	if !ok {
 		return fmt.Errorf("no function exists to run for %s", mode)
 	}
-	return f()
+	return f(ctx)
 }
 
 // Deploy is the main function of `goployer deploy`
-func (r Runner) Deploy() error {
+func (r Runner) Deploy(ctx context.Context) error {
 	out := os.Stdout
 	defer func() {
 		if err := recover(); err != nil {
@@ -266,6 +284,20 @@ func (r Runner) Deploy() error {
 		}
 	}()
 
+	if r.Builder.Config.Plan {
+		return r.Plan(ctx, "deploy")
+	}
+
+	store, err := r.newStateStore()
+	if err != nil {
+		return err
+	}
+
+	locker, err := r.newLocker()
+	if err != nil {
+		return err
+	}
+
 	if err := r.LocalCheck("Do you really want to deploy this application? "); err != nil {
 		return err
 	}
@@ -300,18 +332,203 @@ func (r Runner) Deploy() error {
 		}
 	}
 
-	r.Logger.Debugf("create wait group for deployer setup")
-	wg := sync.WaitGroup{}
-
-	//Prepare deployers
-	r.Logger.Debug("create deployers for stacks")
-	deployers := []deployer.DeployManager{}
+	//Prepare stacks
+	stacks := []schemas.Stack{}
 	for _, stack := range r.Builder.Stacks {
 		if r.Builder.Config.Stack != "" && stack.Stack != r.Builder.Config.Stack {
 			r.Logger.Debugf("Skipping this stack, stack=%s", stack.Stack)
 			continue
 		}
+		stacks = append(stacks, stack)
+	}
+
+	// steps of deployment are saved to resume it when the process stops in the middle
+	r.Builder.Config.DeploymentID = state.GenerateID(r.Builder.AwsConfig.Name, time.Now().Unix())
 
+	// other deployments cannot change autoscaling groups of the same prefix until the lock is released
+	ctx, lease, err := r.lockStacks(ctx, locker, stacks)
+	if err != nil {
+		return err
+	}
+	defer lease.release()
+
+	r.Tracker = newTracker(store, r.newDeploymentState(constants.RunningStatus))
+	if err := r.Tracker.save(); err != nil {
+		return err
+	}
+	r.Logger.Infof("Deployment id : %s", r.Builder.Config.DeploymentID)
+
+	return r.deployStacks(ctx, stacks)
+}
+
+// stackResult is the result of deployment for a stack
+type stackResult struct {
+	paused  []deployer.DeployManager
+	skipped bool
+	err     error
+}
+
+// deployStacks deploys stacks concurrently in order of dependencies between them
+func (r Runner) deployStacks(ctx context.Context, stacks []schemas.Stack) error {
+	done := map[string]chan struct{}{}
+	results := map[string]*stackResult{}
+	for _, stack := range stacks {
+		done[stack.Stack] = make(chan struct{})
+		results[stack.Stack] = &stackResult{}
+	}
+
+	wg := sync.WaitGroup{}
+	for _, stack := range stacks {
+		wg.Add(1)
+		go func(stack schemas.Stack) {
+			defer wg.Done()
+			defer close(done[stack.Stack])
+
+			result := results[stack.Stack]
+			for _, upstream := range stack.DependsOn {
+				// dependency which is not selected for this deployment is not waited for
+				ch, ok := done[upstream]
+				if !ok {
+					continue
+				}
+
+				r.Logger.Debugf("[%s] wait for upstream stack : %s", stack.Stack, upstream)
+				<-ch
+				if results[upstream].err != nil || results[upstream].skipped {
+					r.Logger.Errorf("[%s] deployment is skipped because upstream stack is not deployed : %s", stack.Stack, upstream)
+					r.Slacker.SendSimpleMessage(fmt.Sprintf(":x: Deployment of %s is skipped because upstream stack is not deployed : %s", stack.Stack, upstream))
+					result.skipped = true
+					return
+				}
+			}
+
+			result.paused, result.err = r.deployStack(ctx, stack, r.Tracker.resumed(stack.Stack))
+		}(stack)
+	}
+	wg.Wait()
+
+	paused := []deployer.DeployManager{}
+	failed := []string{}
+	var lastErr error
+	for _, stack := range stacks {
+		result := results[stack.Stack]
+		if result.err != nil {
+			r.Logger.Errorf("[%s] %s", stack.Stack, result.err.Error())
+			failed = append(failed, stack.Stack)
+			lastErr = result.err
+		}
+
+		if result.skipped {
+			failed = append(failed, stack.Stack)
+		}
+
+		paused = append(paused, result.paused...)
+	}
+
+	switch {
+	case len(paused) > 0:
+		if err := r.pauseDeployment(paused); err != nil {
+			return err
+		}
+	case ctx.Err() != nil:
+		// new versions of unfinished waves are already deleted, so deployment can be resumed later
+		r.Logger.Warnf("Deployment is cancelled : %s", r.Builder.Config.DeploymentID)
+		color.Cyan.Fprintf(os.Stdout, "Run `goployer resume %s` to continue the deployment\n", r.Builder.Config.DeploymentID)
+		r.Slacker.SendSimpleMessage(fmt.Sprintf(":warning: Deployment is cancelled : %s", r.Builder.Config.DeploymentID))
+		return ctx.Err()
+	case len(failed) > 0:
+		r.Tracker.fail()
+	default:
+		r.Tracker.close()
+	}
+
+	if len(failed) == 1 && lastErr != nil {
+		return lastErr
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("deployment failed or skipped for stacks : %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// deployStack deploys regions of a stack wave by wave and returns deployers waiting for manual promotion
+// If saved state of the stack is given, deployment continues from the wave and the step of it
+func (r Runner) deployStack(ctx context.Context, stack schemas.Stack, resumed *schemas.DeployerState) ([]deployer.DeployManager, error) {
+	waves := buildWaves([]schemas.Stack{stack}, r.Builder.Config.Region)
+	start := 0
+	if resumed != nil {
+		start = resumed.Wave
+	}
+
+	for i := start; i < len(waves); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		wave := waves[i]
+		r.Builder.Config.StartTimestamp = time.Now().Unix()
+		r.Tracker.setWave(stack.Stack, i)
+		if len(waves) > 1 {
+			r.Logger.Infof("[%s] Start rollout wave %d/%d : %s", stack.Stack, i+1, len(waves), strings.Join(getWaveRegions(wave), ", "))
+			r.Slacker.SendSimpleMessage(fmt.Sprintf("Start rollout wave %d/%d of %s : %s", i+1, len(waves), stack.Stack, strings.Join(getWaveRegions(wave), ", ")))
+		}
+
+		var deployers []deployer.DeployManager
+		var err error
+		if resumed != nil && i == start {
+			deployers, err = r.resumeWave(ctx, wave, *resumed)
+		} else {
+			deployers, err = r.deployWave(ctx, wave)
+		}
+
+		if err != nil {
+			if i > 0 {
+				r.Slacker.SendSimpleMessage(fmt.Sprintf(":x: Rollout of %s is stopped at wave %d/%d", stack.Stack, i+1, len(waves)))
+			}
+			return nil, err
+		}
+
+		if needsPromotion(wave, r.Builder.Config.Stack) {
+			return deployers, nil
+		}
+
+		if bakeTime := getWaveBakeTime(wave); bakeTime > 0 && i+1 < len(waves) {
+			r.Logger.Infof("[%s] Bake rollout wave %d/%d for %s", stack.Stack, i+1, len(waves), bakeTime)
+			if err := tool.Sleep(ctx, bakeTime); err != nil {
+				r.cancelWave(deployers)
+				return nil, err
+			}
+
+			if err := doHealthchecking(ctx, deployers, r.Builder.Config, r.Logger); err != nil {
+				err = r.failWave(ctx, deployers, err, false)
+				if ctx.Err() == nil {
+					r.Slacker.SendSimpleMessage(fmt.Sprintf(":x: Rollout of %s is stopped at wave %d/%d", stack.Stack, i+1, len(waves)))
+				}
+				return nil, err
+			}
+		}
+
+		if err := r.finishDeployment(ctx, deployers); err != nil {
+			return nil, err
+		}
+	}
+
+	r.Tracker.complete(stack.Stack)
+
+	return nil, nil
+}
+
+// deployWave launches new versions of stacks in a wave and waits for them to be healthy
+func (r Runner) deployWave(ctx context.Context, stacks []schemas.Stack) ([]deployer.DeployManager, error) {
+	r.Logger.Debugf("create wait group for deployer setup")
+	wg := sync.WaitGroup{}
+
+	//Prepare deployers
+	r.Logger.Debug("create deployers for stacks")
+	deployers := []deployer.DeployManager{}
+	for _, stack := range stacks {
 		r.Logger.Debugf("add deployer setup function : %s", stack.Stack)
 		deployers = append(deployers, getDeployer(r.Logger, stack, r.Builder.AwsConfig, r.Builder.APITestTemplates, r.Builder.Config.Region, r.Slacker, r.Collector))
 	}
@@ -323,46 +540,272 @@ func (r Runner) Deploy() error {
 		wg.Add(1)
 		go func(deployer deployer.DeployManager) {
 			defer wg.Done()
-			if err := deployer.CheckPrevious(r.Builder.Config); err != nil {
+			if err := deployer.CheckPrevious(ctx, r.Builder.Config); err != nil {
 				r.Logger.Errorf("[StepCheckPrevious] check previous deployer error occurred: %s", err.Error())
 			}
 
-			if err := deployer.Deploy(r.Builder.Config); err != nil {
+			if err := deployer.Deploy(ctx, r.Builder.Config); err != nil {
 				r.Logger.Errorf("[StepDeploy] deploy step error occurred: %s", err.Error())
 			}
+			r.Tracker.record(deployer)
 		}(d)
 	}
 
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		r.cancelWave(deployers)
+		return nil, err
+	}
+
 	// healthcheck
-	if err := doHealthchecking(deployers, r.Builder.Config, r.Logger); err != nil {
-		return err
+	if err := doHealthchecking(ctx, deployers, r.Builder.Config, r.Logger); err != nil {
+		return nil, r.failWave(ctx, deployers, err, false)
+	}
+
+	if err := r.runPostHealthSteps(ctx, deployers); err != nil {
+		return nil, err
 	}
 
+	return deployers, nil
+}
+
+// resumeWave restores deployer of the wave with saved state and checks health of new version again
+func (r Runner) resumeWave(ctx context.Context, wave []schemas.Stack, saved schemas.DeployerState) ([]deployer.DeployManager, error) {
+	// new version might not be launched, so the wave starts from the beginning
+	if !saved.StepStatus[constants.StepDeploy] {
+		r.Logger.Infof("[%s] new version was not launched, deployment of wave starts again", saved.Stack)
+		return r.deployWave(ctx, wave)
+	}
+
+	deployers := []deployer.DeployManager{}
+	for _, stack := range wave {
+		d := getDeployer(r.Logger, stack, r.Builder.AwsConfig, r.Builder.APITestTemplates, r.Builder.Config.Region, r.Slacker, r.Collector)
+		d.SetState(saved)
+		deployers = append(deployers, d)
+	}
+
+	if saved.StepStatus[constants.StepAdditionalWork] {
+		return deployers, nil
+	}
+
+	if err := doHealthchecking(ctx, deployers, r.Builder.Config, r.Logger); err != nil {
+		return nil, r.failWave(ctx, deployers, err, false)
+	}
+
+	if err := r.runPostHealthSteps(ctx, deployers); err != nil {
+		return nil, err
+	}
+
+	return deployers, nil
+}
+
+// postHealthStep is a step which runs on healthy new versions before previous versions are cleaned
+type postHealthStep struct {
+	// step of deployer which is skipped if it is already done before resuming
+	id int64
+
+	// name of step in error logs
+	name string
+
+	// function of deployer which runs the step
+	run func(deployer.DeployManager, context.Context, schemas.Config) error
+
+	// new versions are deleted regardless of rollback_on_failure if the step fails
+	forceRollback bool
+}
+
+// postHealthSteps is the list of steps which run in order after new versions become healthy
+var postHealthSteps = []postHealthStep{
+	{id: constants.StepPostDeployCallback, name: "post deploy callback", run: deployer.DeployManager.TriggerPostDeployCallbacks},
+	{id: constants.StepBake, name: "bake", run: deployer.DeployManager.Bake, forceRollback: true},
+	{id: constants.StepAPITest, name: "API test", run: deployer.DeployManager.RunPreCleanupAPITest},
+}
+
+// runPostHealthSteps runs steps on healthy new versions in order and deletes new versions if any step fails
+func (r Runner) runPostHealthSteps(ctx context.Context, deployers []deployer.DeployManager) error {
+	for _, step := range postHealthSteps {
+		if err := r.runStep(ctx, deployers, step); err != nil {
+			return r.failWave(ctx, deployers, err, step.forceRollback)
+		}
+	}
+
+	return nil
+}
+
+// runStep runs the step of all deployers at once and returns error if the step fails in any of them
+func (r Runner) runStep(ctx context.Context, deployers []deployer.DeployManager, step postHealthStep) error {
+	wg := sync.WaitGroup{}
+	errs := make(chan error, len(deployers))
+	for _, d := range deployers {
+		wg.Add(1)
+		go func(deployer deployer.DeployManager) {
+			defer wg.Done()
+			// step which is already done before resuming is skipped
+			if deployer.GetState().StepStatus[step.id] {
+				return
+			}
+
+			if err := step.run(deployer, ctx, r.Builder.Config); err != nil {
+				r.Logger.Errorf("[%s] %s error occurred: %s", deployer.GetStackName(), step.name, err.Error())
+				errs <- err
+			}
+			r.Tracker.record(deployer)
+		}(d)
+	}
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+// failWave deletes new versions of the wave which failed and returns the error
+// If deployment is cancelled, the wave is cancelled so that it can be deployed again when resumed
+func (r Runner) failWave(ctx context.Context, deployers []deployer.DeployManager, err error, force bool) error {
+	if ctx.Err() != nil {
+		r.cancelWave(deployers)
+		return ctx.Err()
+	}
+
+	if force {
+		forceRollback(ctx, deployers, r.Builder.Config, r.Logger)
+	} else {
+		rollback(ctx, deployers, r.Builder.Config, r.Logger)
+	}
+
+	return err
+}
+
+// cancelWave deletes new versions of the wave which is cancelled before previous versions are cleaned
+// Previous versions keep serving traffic and the wave is deployed again when the deployment is resumed
+func (r Runner) cancelWave(deployers []deployer.DeployManager) {
+	r.Logger.Warnf("Deployment is cancelled, so new versions of this wave will be deleted")
+
+	// context of deployment is already cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), constants.CleanupTimeout)
+	defer cancel()
+
+	wg := sync.WaitGroup{}
+	for _, d := range deployers {
+		wg.Add(1)
+		go func(deployer deployer.DeployManager) {
+			defer wg.Done()
+			if err := deployer.Cleanup(ctx, r.Builder.Config); err != nil {
+				r.Logger.Errorf("[%s] cleanup error occurred: %s", deployer.GetStackName(), err.Error())
+			}
+			r.Tracker.record(deployer)
+		}(d)
+	}
+	wg.Wait()
+}
+
+// buildWaves groups stacks with regions by rollout waves
+func buildWaves(stacks []schemas.Stack, region string) [][]schemas.Stack {
+	waves := [][]schemas.Stack{}
+	addToWave := func(index int, stack schemas.Stack) {
+		for len(waves) <= index {
+			waves = append(waves, []schemas.Stack{})
+		}
+		waves[index] = append(waves[index], stack)
+	}
+
+	for _, stack := range stacks {
+		// all regions are deployed at once if region is specified from command line
+		if stack.Rollout == nil || len(stack.Rollout.Waves) == 0 || len(region) > 0 {
+			addToWave(0, stack)
+			continue
+		}
+
+		for i, waveRegions := range stack.Rollout.Waves {
+			waveStack := stack
+			waveStack.Regions = []schemas.RegionConfig{}
+			for _, rc := range stack.Regions {
+				if tool.IsStringInArray(rc.Region, waveRegions) {
+					waveStack.Regions = append(waveStack.Regions, rc)
+				}
+			}
+			addToWave(i, waveStack)
+		}
+	}
+
+	return waves
+}
+
+// getWaveRegions returns regions deployed in the wave
+func getWaveRegions(wave []schemas.Stack) []string {
+	regions := []string{}
+	for _, stack := range wave {
+		for _, rc := range stack.Regions {
+			if !tool.IsStringInArray(rc.Region, regions) {
+				regions = append(regions, rc.Region)
+			}
+		}
+	}
+
+	return regions
+}
+
+// getWaveBakeTime returns the longest bake time of stacks in the wave
+func getWaveBakeTime(wave []schemas.Stack) time.Duration {
+	var bakeTime time.Duration
+	for _, stack := range wave {
+		if stack.Rollout != nil && stack.Rollout.BakeTime > bakeTime {
+			bakeTime = stack.Rollout.BakeTime
+		}
+	}
+
+	return bakeTime
+}
+
+// finishDeployment runs remaining steps after new versions are healthy
+func (r Runner) finishDeployment(ctx context.Context, deployers []deployer.DeployManager) error {
+	wg := sync.WaitGroup{}
+	errs := make(chan error, len(deployers))
+
 	// Attach scaling policy
 	for _, d := range deployers {
 		wg.Add(1)
 		go func(deployer deployer.DeployManager) {
 			defer wg.Done()
-			if err := deployer.FinishAdditionalWork(r.Builder.Config); err != nil {
-				r.Logger.Errorf(err.Error())
+			// steps which are already done before resuming are skipped
+			done := deployer.GetState().StepStatus
+			if !done[constants.StepAdditionalWork] {
+				err := deployer.FinishAdditionalWork(ctx, r.Builder.Config)
+				r.Tracker.record(deployer)
+				if err != nil {
+					r.Logger.Errorf(err.Error())
+					errs <- err
+					return
+				}
 			}
 
-			if err := deployer.TriggerLifecycleCallbacks(r.Builder.Config); err != nil {
-				r.Logger.Errorf(err.Error())
+			if !done[constants.StepTriggerLifecycleCallback] {
+				if err := deployer.TriggerLifecycleCallbacks(ctx, r.Builder.Config); err != nil {
+					r.Logger.Errorf(err.Error())
+					errs <- err
+				}
+				r.Tracker.record(deployer)
 			}
 
-			if err := deployer.CleanPreviousVersion(r.Builder.Config); err != nil {
-				r.Logger.Errorf(err.Error())
+			if !done[constants.StepCleanPreviousVersion] {
+				if err := deployer.CleanPreviousVersion(ctx, r.Builder.Config); err != nil {
+					r.Logger.Errorf(err.Error())
+				}
+				r.Tracker.record(deployer)
 			}
 		}(d)
 	}
 
 	wg.Wait()
+	close(errs)
 
 	// Checking all previous version before delete asg
-	if err := cleanChecking(deployers, r.Builder.Config, r.Logger); err != nil {
+	if err := cleanChecking(ctx, deployers, r.Builder.Config, r.Logger); err != nil {
+		return err
+	}
+
+	// previous versions are kept if additional work or lifecycle callback with fail_on_error fails
+	if err := <-errs; err != nil {
 		return err
 	}
 
@@ -371,7 +814,7 @@ func (r Runner) Deploy() error {
 		wg.Add(1)
 		go func(deployer deployer.DeployManager) {
 			defer wg.Done()
-			if err := deployer.GatherMetrics(r.Builder.Config); err != nil {
+			if err := deployer.GatherMetrics(ctx, r.Builder.Config); err != nil {
 				r.Logger.Errorf(err.Error())
 			}
 		}(d)
@@ -379,22 +822,399 @@ func (r Runner) Deploy() error {
 	wg.Wait()
 
 	// API Test
+	testErrs := make(chan error, len(deployers))
 	for _, d := range deployers {
 		wg.Add(1)
 		go func(deployer deployer.DeployManager) {
 			defer wg.Done()
-			if err := deployer.RunAPITest(r.Builder.Config); err != nil {
+			if err := deployer.RunAPITest(ctx, r.Builder.Config); err != nil {
 				r.Logger.Errorf("API test error occurred: %s", err.Error())
+				testErrs <- err
 			}
 		}(d)
 	}
-	wg.Wait()
+	wg.Wait()
+	close(testErrs)
+
+	// failed assertions of API test fail the deployment
+	return <-testErrs
+}
+
+// pauseDeployment saves the state of deployment which waits for manual promotion
+func (r Runner) pauseDeployment(deployers []deployer.DeployManager) error {
+	deployment := r.newDeploymentState(constants.PausedStatus)
+	for _, d := range deployers {
+		deployment.Deployers = append(deployment.Deployers, d.GetState())
+	}
+
+	if err := r.Tracker.store.Save(deployment); err != nil {
+		return err
+	}
+
+	r.Logger.Infof("Deployment is waiting for manual promotion : %s", deployment.ID)
+	color.Cyan.Fprintf(os.Stdout, "Run `goployer promote %s` to clean previous versions or `goployer abort %s` to roll back\n", deployment.ID, deployment.ID)
+	r.Slacker.SendSimpleMessage(fmt.Sprintf(":double_vertical_bar: Deployment is waiting for manual promotion : %s", deployment.ID))
+
+	return nil
+}
+
+// Promote resumes the paused deployment and cleans previous versions
+func (r Runner) Promote(ctx context.Context) error {
+	store, err := r.newStateStore()
+	if err != nil {
+		return err
+	}
+
+	deployment, err := r.loadDeployment(store)
+	if err != nil {
+		return err
+	}
+
+	r = r.withDeploymentLock(*deployment)
+	locker, err := r.newLocker()
+	if err != nil {
+		return err
+	}
+
+	if err := r.LocalCheck(fmt.Sprintf("Do you really want to promote %s? ", deployment.ID)); err != nil {
+		return err
+	}
+
+	r, deployers := r.restoreDeployers(*deployment)
+	ctx, lease, err := r.lockStacks(ctx, locker, deployment.Stacks)
+	if err != nil {
+		return err
+	}
+	defer lease.release()
+
+	r.Slacker.SendSimpleMessage(fmt.Sprintf("Deployment is promoted : %s", deployment.ID))
+
+	if err := r.finishDeployment(ctx, deployers); err != nil {
+		return err
+	}
+
+	if err := store.Delete(deployment.ID); err != nil {
+		return err
+	}
+
+	r.Logger.Infof("promotion is finished : %s", deployment.ID)
+	r.Slacker.SendSimpleMessage(fmt.Sprintf(":100: Deployment is done: %s", r.Builder.AwsConfig.Name))
+
+	return nil
+}
+
+// Abort rolls back new versions of the paused deployment
+func (r Runner) Abort(ctx context.Context) error {
+	store, err := r.newStateStore()
+	if err != nil {
+		return err
+	}
+
+	deployment, err := r.loadDeployment(store)
+	if err != nil {
+		return err
+	}
+
+	r = r.withDeploymentLock(*deployment)
+	locker, err := r.newLocker()
+	if err != nil {
+		return err
+	}
+
+	if err := r.LocalCheck(fmt.Sprintf("Do you really want to abort %s? ", deployment.ID)); err != nil {
+		return err
+	}
+
+	// new versions are always deleted when deployment is aborted
+	for i := range deployment.Stacks {
+		deployment.Stacks[i].RollbackOnFailure = true
+	}
+
+	r, deployers := r.restoreDeployers(*deployment)
+	ctx, lease, err := r.lockStacks(ctx, locker, deployment.Stacks)
+	if err != nil {
+		return err
+	}
+	defer lease.release()
+
+	r.Slacker.SendSimpleMessage(fmt.Sprintf("Deployment is aborted : %s", deployment.ID))
+
+	rollback(ctx, deployers, r.Builder.Config, r.Logger)
+
+	if err := store.Delete(deployment.ID); err != nil {
+		return err
+	}
+
+	r.Logger.Infof("abort is finished : %s", deployment.ID)
+
+	return nil
+}
+
+// Resume continues deployment stopped in the middle from the last completed step
+func (r Runner) Resume(ctx context.Context) error {
+	store, err := r.newStateStore()
+	if err != nil {
+		return err
+	}
+
+	if len(r.Builder.Config.DeploymentID) == 0 {
+		return errors.New("you have to specify the deployment id")
+	}
+
+	deployment, err := store.Load(r.Builder.Config.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	r = r.withDeploymentLock(*deployment)
+	locker, err := r.newLocker()
+	if err != nil {
+		return err
+	}
+
+	if deployment.Status != constants.RunningStatus {
+		return fmt.Errorf("deployment cannot be resumed: %s(%s)", deployment.ID, deployment.Status)
+	}
+
+	if err := r.LocalCheck(fmt.Sprintf("Do you really want to resume %s? ", deployment.ID)); err != nil {
+		return err
+	}
+
+	r = r.restoreRunner(*deployment)
+	r.Tracker = newTracker(store, *deployment)
+	ctx, lease, err := r.lockStacks(ctx, locker, deployment.Stacks)
+	if err != nil {
+		return err
+	}
+	defer lease.release()
+
+	r.Slacker.SendSimpleMessage(fmt.Sprintf("Deployment is resumed : %s", deployment.ID))
+
+	stacks := []schemas.Stack{}
+	for _, stack := range r.Builder.Stacks {
+		if r.Builder.Config.Stack != "" && stack.Stack != r.Builder.Config.Stack {
+			continue
+		}
+
+		if tool.IsStringInArray(stack.Stack, deployment.CompletedStacks) {
+			r.Logger.Debugf("Skipping this stack which is already deployed, stack=%s", stack.Stack)
+			continue
+		}
+		stacks = append(stacks, stack)
+	}
+
+	if err := r.deployStacks(ctx, stacks); err != nil {
+		return err
+	}
+
+	if !needsPromotion(stacks, r.Builder.Config.Stack) {
+		r.Logger.Infof("resumed deployment is finished : %s", deployment.ID)
+		r.Slacker.SendSimpleMessage(fmt.Sprintf(":100: Deployment is done: %s", r.Builder.AwsConfig.Name))
+	}
+
+	return nil
+}
+
+// LockList shows deployment locks held by running deployments
+func (r Runner) LockList(ctx context.Context) error {
+	locker, err := r.newLocker()
+	if err != nil {
+		return err
+	}
+
+	locks, err := locker.List()
+	if err != nil {
+		return err
+	}
+
+	if len(locks) == 0 {
+		r.Logger.Infof("no deployment lock exists")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Owner", "Deployment", "Acquired", "Expires"})
+	table.SetCenterSeparator("|")
+	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+
+	now := time.Now().Unix()
+	for _, l := range locks {
+		expires := formatLockTime(l.ExpiresAt)
+		if l.ExpiresAt < now {
+			expires = fmt.Sprintf("%s (expired)", expires)
+		}
+		table.Append([]string{l.ID, l.Owner, l.DeploymentID, formatLockTime(l.AcquiredAt), expires})
+	}
+	table.Render()
+
+	return nil
+}
+
+// LockRelease deletes the deployment lock which is left by stopped deployment
+func (r Runner) LockRelease(ctx context.Context) error {
+	if len(r.Builder.Config.LockID) == 0 {
+		return errors.New("you have to specify the lock id")
+	}
+
+	if !r.Builder.Config.Force {
+		return errors.New("lock might be held by running deployment, so you have to release it with --force")
+	}
+
+	locker, err := r.newLocker()
+	if err != nil {
+		return err
+	}
+
+	if err := r.LocalCheck(fmt.Sprintf("Do you really want to release the lock %s? ", r.Builder.Config.LockID)); err != nil {
+		return err
+	}
+
+	if err := locker.ForceRelease(r.Builder.Config.LockID); err != nil {
+		return err
+	}
+
+	r.Logger.Infof("deployment lock is released : %s", r.Builder.Config.LockID)
+
+	return nil
+}
+
+// newDeploymentState creates the state of current deployment without deployers
+func (r Runner) newDeploymentState(status string) schemas.DeploymentState {
+	return schemas.DeploymentState{
+		ID:               r.Builder.Config.DeploymentID,
+		Status:           status,
+		CreatedAt:        time.Now().Unix(),
+		Config:           r.Builder.Config,
+		AwsConfig:        r.Builder.AwsConfig,
+		MetricConfig:     r.Builder.MetricConfig,
+		Stacks:           r.Builder.Stacks,
+		APITestTemplates: r.Builder.APITestTemplates,
+	}
+}
+
+// loadDeployment reads the state of deployment waiting for manual promotion
+func (r Runner) loadDeployment(store state.Store) (*schemas.DeploymentState, error) {
+	if len(r.Builder.Config.DeploymentID) == 0 {
+		return nil, errors.New("you have to specify the deployment id")
+	}
+
+	deployment, err := store.Load(r.Builder.Config.DeploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if deployment.Status != constants.PausedStatus {
+		return nil, fmt.Errorf("deployment is not waiting for promotion: %s(%s)", deployment.ID, deployment.Status)
+	}
+
+	return deployment, nil
+}
+
+// restoreRunner sets configurations of runner from the saved state of deployment
+func (r Runner) restoreRunner(deployment schemas.DeploymentState) Runner {
+	config := deployment.Config
+	config.StartTimestamp = time.Now().Unix()
+
+	r.Builder.Config = config
+	r.Builder.AwsConfig = deployment.AwsConfig
+	r.Builder.MetricConfig = deployment.MetricConfig
+	r.Builder.Stacks = deployment.Stacks
+	r.Builder.APITestTemplates = deployment.APITestTemplates
+	r.Slacker = slack.NewSlackClient(config.SlackOff)
+	r.Collector = collector.NewCollector(deployment.MetricConfig, config.AssumeRole)
+
+	return r
+}
+
+// restoreDeployers creates deployers with the saved state of deployment
+func (r Runner) restoreDeployers(deployment schemas.DeploymentState) (Runner, []deployer.DeployManager) {
+	r = r.restoreRunner(deployment)
+	config := r.Builder.Config
+
+	deployers := []deployer.DeployManager{}
+	for _, ds := range deployment.Deployers {
+		for _, stack := range deployment.Stacks {
+			if stack.Stack != ds.Stack {
+				continue
+			}
+
+			d := getDeployer(r.Logger, stack, r.Builder.AwsConfig, r.Builder.APITestTemplates, config.Region, r.Slacker, r.Collector)
+			d.SetState(ds)
+			deployers = append(deployers, d)
+		}
+	}
+
+	return r, deployers
+}
+
+// needsPromotion checks if any stack to deploy waits for manual promotion
+func needsPromotion(stacks []schemas.Stack, selected string) bool {
+	for _, stack := range stacks {
+		if len(selected) > 0 && stack.Stack != selected {
+			continue
+		}
+
+		if stack.ManualPromotion {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newStateStore returns the store of deployment states
+func (r Runner) newStateStore() (state.Store, error) {
+	metricConfig, err := r.storageMetricConfig(r.Builder.Config.StateStore)
+	if err != nil {
+		return nil, err
+	}
+
+	return state.New(r.Builder.Config.StateStore, r.Builder.Config.StateStoreRegion, metricConfig)
+}
+
+// newLocker returns the locker of deployment locks
+func (r Runner) newLocker() (*lock.Locker, error) {
+	metricConfig, err := r.storageMetricConfig(r.Builder.Config.Lock)
+	if err != nil {
+		return nil, err
+	}
+
+	return lock.New(r.Builder.Config.Lock, r.Builder.Config.LockRegion, r.Builder.Config.LockLease, metricConfig)
+}
+
+// withDeploymentLock uses the deployment lock which the deployment was started with if no lock is specified
+// so that promote, abort and resume cannot run while another one holds the lock
+func (r Runner) withDeploymentLock(deployment schemas.DeploymentState) Runner {
+	if len(r.Builder.Config.Lock) > 0 {
+		return r
+	}
+
+	r.Builder.Config.Lock = deployment.Config.Lock
+	r.Builder.Config.LockRegion = deployment.Config.LockRegion
+	r.Builder.Config.LockLease = deployment.Config.LockLease
+
+	return r
+}
+
+// storageMetricConfig returns metric configuration of which table is used if dynamodb table is not specified
+func (r Runner) storageMetricConfig(location string) (schemas.MetricConfig, error) {
+	metricConfig := r.Builder.MetricConfig
+	if location == constants.DynamoDBStateStore && len(metricConfig.Storage.Name) == 0 {
+		// metric configuration is not loaded for commands without manifest
+		m, err := builder.ParseMetricConfig(false, constants.MetricYamlPath)
+		if err != nil {
+			return schemas.MetricConfig{}, err
+		}
+		metricConfig = m
+	}
 
-	return nil
+	return metricConfig, nil
 }
 
 // Delete is the main function for `goployer delete`
-func (r Runner) Delete() error {
+func (r Runner) Delete(ctx context.Context) error {
 	defer func() {
 		if err := recover(); err != nil {
 			Logger.Error(err)
@@ -402,10 +1222,25 @@ func (r Runner) Delete() error {
 		}
 	}()
 
+	if r.Builder.Config.Plan {
+		return r.Plan(ctx, "delete")
+	}
+
+	locker, err := r.newLocker()
+	if err != nil {
+		return err
+	}
+
 	if err := r.LocalCheck("Do you really want to delete applications? "); err != nil {
 		return err
 	}
 
+	ctx, lease, err := r.lockStacks(ctx, locker, r.Builder.Stacks)
+	if err != nil {
+		return err
+	}
+	defer lease.release()
+
 	//Send Beginning Message
 	r.Logger.Info("Beginning delete process: ", r.Builder.AwsConfig.Name)
 	r.Builder.Config.SlackOff = true
@@ -430,7 +1265,7 @@ func (r Runner) Delete() error {
 		}
 
 		r.Logger.Debugf("add deployer setup function : %s", stack.Stack)
-		d := getDeployer(r.Logger, stack, r.Builder.AwsConfig, r.Builder.APITestTemplates, r.Builder.Config.Region, r.Slacker, r.Collector)
+		d := getDeployer(r.Logger, deletionStack(stack), r.Builder.AwsConfig, r.Builder.APITestTemplates, r.Builder.Config.Region, r.Slacker, r.Collector)
 		deployers = append(deployers, d)
 	}
 
@@ -441,19 +1276,19 @@ func (r Runner) Delete() error {
 		wg.Add(1)
 		go func(deployer deployer.DeployManager) {
 			defer wg.Done()
-			if err := deployer.CheckPrevious(r.Builder.Config); err != nil {
+			if err := deployer.CheckPrevious(ctx, r.Builder.Config); err != nil {
 				r.Logger.Errorf("[StepCheckPrevious] check previous deployer error occurred: %s", err.Error())
 			}
 
 			deployer.SkipDeployStep()
 
 			// Trigger Lifecycle Callbacks
-			if err := deployer.TriggerLifecycleCallbacks(r.Builder.Config); err != nil {
+			if err := deployer.TriggerLifecycleCallbacks(ctx, r.Builder.Config); err != nil {
 				r.Logger.Errorf(err.Error())
 			}
 
 			// Clear previous Version
-			if err := deployer.CleanPreviousVersion(r.Builder.Config); err != nil {
+			if err := deployer.CleanPreviousVersion(ctx, r.Builder.Config); err != nil {
 				r.Logger.Errorf(err.Error())
 			}
 		}(d)
@@ -461,7 +1296,7 @@ func (r Runner) Delete() error {
 	wg.Wait()
 
 	// Checking all previous version before delete asg
-	if err := cleanChecking(deployers, r.Builder.Config, r.Logger); err != nil {
+	if err := cleanChecking(ctx, deployers, r.Builder.Config, r.Logger); err != nil {
 		return err
 	}
 
@@ -470,7 +1305,7 @@ func (r Runner) Delete() error {
 		wg.Add(1)
 		go func(deployer deployer.DeployManager) {
 			defer wg.Done()
-			if err := deployer.GatherMetrics(r.Builder.Config); err != nil {
+			if err := deployer.GatherMetrics(ctx, r.Builder.Config); err != nil {
 				r.Logger.Errorf(err.Error())
 			}
 		}(d)
@@ -480,26 +1315,144 @@ func (r Runner) Delete() error {
 	return nil
 }
 
+// deletionStack returns stack with which all autoscaling groups are deleted regardless of deployment type
+func deletionStack(stack schemas.Stack) schemas.Stack {
+	stack.ReplacementType = constants.BlueGreenDeployment
+	stack.RetainPreviousVersions = 0
+	return stack
+}
+
+// Plan prints changes of deploy or delete only with read-only calls
+func (r Runner) Plan(ctx context.Context, mode string) error {
+	// logs should not be mixed with json output
+	if r.Builder.Config.Output == "json" {
+		r.Logger.SetOutput(os.Stderr)
+	}
+
+	plan := schemas.DeploymentPlan{
+		Action:      mode,
+		Application: r.Builder.AwsConfig.Name,
+		Regions:     []schemas.RegionPlan{},
+	}
+
+	for _, stack := range r.Builder.Stacks {
+		if r.Builder.Config.Stack != "" && stack.Stack != r.Builder.Config.Stack {
+			r.Logger.Debugf("Skipping this stack, stack=%s", stack.Stack)
+			continue
+		}
+
+		var regionPlans []schemas.RegionPlan
+		var err error
+		if mode == "delete" {
+			d := deployer.NewBlueGrean(constants.BlueGreenDeployment, r.Logger, r.Builder.AwsConfig, nil, deletionStack(stack), r.Builder.Config.Region)
+			regionPlans, err = d.PlanDelete(ctx, r.Builder.Config)
+		} else {
+			d := getDeployer(r.Logger, stack, r.Builder.AwsConfig, r.Builder.APITestTemplates, r.Builder.Config.Region, r.Slacker, r.Collector)
+			regionPlans, err = d.Plan(ctx, r.Builder.Config)
+		}
+
+		if err != nil {
+			return err
+		}
+		plan.Regions = append(plan.Regions, regionPlans...)
+	}
+
+	if r.Builder.Config.Output == "json" {
+		b, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	printPlan(plan)
+	return nil
+}
+
+// printPlan shows deployment plan as a table
+func printPlan(plan schemas.DeploymentPlan) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Stack", "Region", "Resource", "Value"})
+	table.SetCenterSeparator("|")
+	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+
+	table.AppendBulk(makePlanRows(plan))
+	table.Render()
+}
+
+// makePlanRows makes table rows of deployment plan
+func makePlanRows(plan schemas.DeploymentPlan) [][]string {
+	data := [][]string{}
+	for _, rp := range plan.Regions {
+		rows := [][]string{}
+		if len(rp.AutoScalingGroup) > 0 {
+			rows = append(rows,
+				[]string{"replacement type", rp.ReplacementType},
+				[]string{"autoscaling group", rp.AutoScalingGroup},
+				[]string{"launch template", rp.LaunchTemplate},
+				[]string{"ami", rp.Ami},
+				[]string{"instance type", rp.InstanceType},
+				[]string{"ssh key", rp.SSHKey},
+				[]string{"iam instance profile", rp.IamInstanceProfile},
+				[]string{"security groups", strings.Join(rp.SecurityGroups, ",")},
+				[]string{"availability zones", strings.Join(rp.AvailabilityZones, ",")},
+				[]string{"subnets", strings.Join(rp.Subnets, ",")},
+				[]string{"load balancers", strings.Join(rp.LoadBalancers, ",")},
+				[]string{"target groups", strings.Join(rp.TargetGroups, ",")},
+				[]string{"tags", strings.Join(rp.Tags, ",")},
+			)
+		}
+
+		if rp.Capacity != nil {
+			rows = append(rows, []string{"capacity", fmt.Sprintf("min: %d, desired: %d, max: %d", rp.Capacity.Min, rp.Capacity.Desired, rp.Capacity.Max)})
+		}
+
+		if rp.AttachAfterWarmup {
+			rows = append(rows, []string{"attach", "load balancers and target groups are attached after warmup"})
+		}
+
+		if len(rp.StandbyAutoScalingGroups) > 0 {
+			rows = append(rows, []string{"standby", strings.Join(rp.StandbyAutoScalingGroups, ",")})
+		}
+
+		removed := "-"
+		if len(rp.RemovedAutoScalingGroups) > 0 {
+			removed = strings.Join(rp.RemovedAutoScalingGroups, ",")
+		}
+		rows = append(rows, []string{"removed", removed})
+
+		for _, row := range rows {
+			data = append(data, append([]string{rp.Stack, rp.Region}, row...))
+		}
+	}
+
+	return data
+}
+
 // Status shows the detailed information about autoscaling deployment
-func (r Runner) Status() error {
+func (r Runner) Status(ctx context.Context) error {
 	inspector := inspector.New(r.Builder.Config.Region)
 
-	asg, err := inspector.SelectStack(r.Builder.Config.Application)
+	asg, err := inspector.SelectStack(ctx, r.Builder.Config.Application)
 	if err != nil {
 		return err
 	}
 
-	group, err := inspector.GetStackInformation(asg)
+	group, err := inspector.GetStackInformation(ctx, asg)
 	if err != nil {
 		return err
 	}
 
-	launchTemplateInfo, err := inspector.GetLaunchTemplateInformation(*group.LaunchTemplate.LaunchTemplateId)
+	launchTemplateInfo, err := inspector.GetLaunchTemplateInformation(ctx, *group.LaunchTemplate.LaunchTemplateId)
 	if err != nil {
 		return err
 	}
 
-	securityGroups, err := inspector.GetSecurityGroupsInformation(launchTemplateInfo.LaunchTemplateData.SecurityGroupIds)
+	securityGroups, err := inspector.GetSecurityGroupsInformation(ctx, launchTemplateInfo.LaunchTemplateData.SecurityGroupIds)
 	if err != nil {
 		return err
 	}
@@ -514,15 +1467,15 @@ func (r Runner) Status() error {
 }
 
 // Update will changes configuration of current deployment on live
-func (r Runner) Update() error {
+func (r Runner) Update(ctx context.Context) error {
 	i := inspector.New(r.Builder.Config.Region)
 
-	asg, err := i.SelectStack(r.Builder.Config.Application)
+	asg, err := i.SelectStack(ctx, r.Builder.Config.Application)
 	if err != nil {
 		return err
 	}
 
-	group, err := i.GetStackInformation(asg)
+	group, err := i.GetStackInformation(ctx, asg)
 	if err != nil {
 		return err
 	}
@@ -551,7 +1504,7 @@ func (r Runner) Update() error {
 	}
 
 	r.Logger.Debugf("start updating configuration")
-	if err := i.Update(); err != nil {
+	if err := i.Update(ctx); err != nil {
 		return err
 	}
 	r.Logger.Debugf("update configuration is triggered")
@@ -568,7 +1521,7 @@ func (r Runner) Update() error {
 
 	// healthcheck
 	r.Logger.Debugf("start healthchecking")
-	if err := doHealthchecking(deployers, r.Builder.Config, r.Logger); err != nil {
+	if err := doHealthchecking(ctx, deployers, r.Builder.Config, r.Logger); err != nil {
 		return err
 	}
 	r.Logger.Debugf("healthcheck process is done")
@@ -578,6 +1531,377 @@ func (r Runner) Update() error {
 	return nil
 }
 
+// Rollback is the main function of `goployer rollback`
+func (r Runner) Rollback(ctx context.Context) error {
+	if len(r.Builder.Config.Stack) == 0 {
+		return errors.New("you have to specify the stack to roll back: --stack")
+	}
+
+	var stack schemas.Stack
+	for _, s := range r.Builder.Stacks {
+		if s.Stack == r.Builder.Config.Stack {
+			stack = s
+			break
+		}
+	}
+
+	regions, err := getRollbackRegions(stack, r.Builder.Config.Region)
+	if err != nil {
+		return err
+	}
+
+	if !r.Builder.Config.FastRollback {
+		if !r.Builder.MetricConfig.Enabled {
+			return errors.New("rollback needs deployment records, so metrics should be enabled")
+		}
+
+		if err := r.CheckEnabledMetrics(); err != nil {
+			return err
+		}
+	}
+
+	records := map[string]collector.DeploymentRecord{}
+	for _, region := range regions {
+		prefix := tool.BuildPrefixName(r.Builder.AwsConfig.Name, stack.Env, region)
+		client := aws.BootstrapServices(region, stack.AssumeRole)
+
+		if r.Builder.Config.FastRollback {
+			if err := r.FastRollback(ctx, stack, region, prefix, client); err != nil {
+				return err
+			}
+			continue
+		}
+
+		record, err := r.findRollbackRecord(ctx, region, prefix, client)
+		if err != nil {
+			return err
+		}
+		records[region] = *record
+	}
+
+	if r.Builder.Config.FastRollback {
+		return nil
+	}
+
+	return r.rollbackRegions(ctx, stack, regions, records)
+}
+
+// findRollbackRecord returns the deployment record of the version to roll back to in the region
+func (r Runner) findRollbackRecord(ctx context.Context, region, prefix string, client aws.Client) (*collector.DeploymentRecord, error) {
+	var candidates []int
+	if len(r.Builder.Config.RollbackVersion) > 0 {
+		version, err := parseRollbackVersion(r.Builder.Config.RollbackVersion)
+		if err != nil {
+			return nil, err
+		}
+		candidates = []int{version}
+	} else {
+		asgs := client.EC2Service.GetAllMatchingAutoscalingGroupsWithPrefix(ctx, prefix)
+		if len(asgs) == 0 {
+			return nil, fmt.Errorf("no current version exists in %s, so you have to specify the version to roll back: --to", region)
+		}
+		current := tool.ParseVersion(*asgs[len(asgs)-1].AutoScalingGroupName)
+		candidates = getRollbackCandidates(current, constants.MaxRollbackCandidates)
+	}
+
+	var record *collector.DeploymentRecord
+	for _, version := range candidates {
+		asg := tool.GenerateAsgName(prefix, version)
+		r.Logger.Debugf("check deployment record : %s", asg)
+		rec, err := r.Collector.GetDeploymentRecord(asg)
+		if err != nil {
+			return nil, err
+		}
+
+		if rec == nil {
+			continue
+		}
+
+		// explicitly specified version is used whatever the status is
+		if len(r.Builder.Config.RollbackVersion) > 0 || tool.IsStringInArray(rec.Status, constants.RollbackableStatus) {
+			record = rec
+			break
+		}
+	}
+
+	if record == nil {
+		return nil, fmt.Errorf("no deployment record to roll back exists: %s", prefix)
+	}
+	r.Logger.Infof("Rollback target : %s(%s)", record.AutoScalingGroup, record.Status)
+
+	return record, nil
+}
+
+// rollbackRegions redeploys the recorded versions of stack in all regions with a single blue/green deployment
+func (r Runner) rollbackRegions(ctx context.Context, stack schemas.Stack, regions []string, records map[string]collector.DeploymentRecord) error {
+	rollbackStack, err := getRollbackStack(records, stack, regions)
+	if err != nil {
+		return err
+	}
+
+	// userdata, tags and extra variables are applied to every region of a deployment
+	base := records[regions[0]]
+	targets := []string{}
+	for _, region := range regions {
+		record := records[region]
+		if record.Userdata != base.Userdata || record.Config.ExtraTags != base.Config.ExtraTags || record.Config.AnsibleExtraVars != base.Config.AnsibleExtraVars {
+			return fmt.Errorf("recorded versions of %s and %s are deployed with different userdata, tags or extra vars, so you have to roll back each region: --region", base.AutoScalingGroup, record.AutoScalingGroup)
+		}
+		targets = append(targets, record.AutoScalingGroup)
+	}
+
+	r.Builder.Config.Ami = constants.EmptyString
+	r.Builder.Config.OverrideInstanceType = constants.EmptyString
+	r.Builder.Config.OverrideUserdata = base.Userdata
+	r.Builder.Config.ExtraTags = base.Config.ExtraTags
+	r.Builder.Config.AnsibleExtraVars = base.Config.AnsibleExtraVars
+	r.Builder.Config.ForceManifestCapacity = true
+	r.Builder.Config.ReleaseNotes = fmt.Sprintf("rollback to %s", strings.Join(targets, ", "))
+	r.Builder.Config.ReleaseNotesBase64 = constants.EmptyString
+	r.Builder.Stacks = []schemas.Stack{rollbackStack}
+
+	return r.Deploy(ctx)
+}
+
+// getRollbackRegions returns regions of stack to roll back
+// Every region of stack is rolled back if region is not specified
+func getRollbackRegions(stack schemas.Stack, region string) ([]string, error) {
+	if len(region) > 0 {
+		if !deployer.CheckRegionExist(region, stack.Regions) {
+			return nil, fmt.Errorf("region [ %s ] is not in the stack [ %s ]", region, stack.Stack)
+		}
+		return []string{region}, nil
+	}
+
+	regions := []string{}
+	for _, rc := range stack.Regions {
+		regions = append(regions, rc.Region)
+	}
+
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("no region to roll back exists in the stack [ %s ]", stack.Stack)
+	}
+
+	return regions, nil
+}
+
+// getRollbackStack returns the recorded stack of the regions with the capacity which was applied at the time
+// Manual promotion and rollout waves are not applied to rollback
+func getRollbackStack(records map[string]collector.DeploymentRecord, stack schemas.Stack, regions []string) (schemas.Stack, error) {
+	if len(regions) == 0 {
+		return schemas.Stack{}, fmt.Errorf("no region to roll back exists in the stack [ %s ]", stack.Stack)
+	}
+
+	rollbackStack := records[regions[0]].Stack
+	rollbackStack.ReplacementType = constants.BlueGreenDeployment
+	rollbackStack.AssumeRole = stack.AssumeRole
+	rollbackStack.ManualPromotion = false
+	rollbackStack.Rollout = nil
+	rollbackStack.DependsOn = nil
+
+	rollbackStack.Regions = []schemas.RegionConfig{}
+	for _, region := range regions {
+		record, ok := records[region]
+		if !ok {
+			return rollbackStack, fmt.Errorf("no deployment record to roll back exists in region [ %s ]", region)
+		}
+
+		found := false
+		for _, rc := range record.Stack.Regions {
+			if rc.Region != region {
+				continue
+			}
+
+			if len(record.Config.Ami) > 0 {
+				rc.AmiID = record.Config.Ami
+			}
+			if len(record.Config.OverrideInstanceType) > 0 {
+				rc.InstanceType = record.Config.OverrideInstanceType
+			}
+
+			capacity := record.Stack.Capacity
+			if record.AppliedCapacity != nil {
+				capacity = *record.AppliedCapacity
+			}
+			rc.Capacity = &capacity
+
+			rollbackStack.Regions = append(rollbackStack.Regions, rc)
+			found = true
+		}
+
+		if !found {
+			return rollbackStack, fmt.Errorf("region [ %s ] does not exist in the deployment record: %s", region, record.AutoScalingGroup)
+		}
+	}
+
+	return rollbackStack, nil
+}
+
+// FastRollback scales a standby autoscaling group back up and swaps it in for the current version
+func (r Runner) FastRollback(ctx context.Context, stack schemas.Stack, region, prefix string, client aws.Client) error {
+	version := -1
+	if len(r.Builder.Config.RollbackVersion) > 0 {
+		v, err := parseRollbackVersion(r.Builder.Config.RollbackVersion)
+		if err != nil {
+			return err
+		}
+		version = v
+	}
+
+	current, target, err := selectStandbyTarget(client.EC2Service.GetAllMatchingAutoscalingGroupsWithPrefix(ctx, prefix), prefix, version)
+	if err != nil {
+		return err
+	}
+
+	currentAsg := *current.AutoScalingGroupName
+	targetAsg := *target.AutoScalingGroupName
+	capacity := makeCapacityStruct(*current.MinSize, *current.MaxSize, *current.DesiredCapacity)
+	r.Logger.Infof("Fast rollback target : %s -> %s", currentAsg, targetAsg)
+	color.Cyan.Fprintf(os.Stdout, "Min: %d, Desired: %d, Max: %d\n", capacity.Min, capacity.Desired, capacity.Max)
+
+	if err := r.LocalCheck(fmt.Sprintf("Do you really want to roll back to %s? ", targetAsg)); err != nil {
+		return err
+	}
+
+	locker, err := r.newLocker()
+	if err != nil {
+		return err
+	}
+
+	ctx, lease, err := r.lockStacks(ctx, locker, []schemas.Stack{stack})
+	if err != nil {
+		return err
+	}
+	defer lease.release()
+
+	r.Slacker.SendSimpleMessage(fmt.Sprintf("Fast rollback is triggered : %s -> %s", currentAsg, targetAsg))
+
+	if err := client.EC2Service.ResumeProcesses(ctx, targetAsg, constants.StandbySuspendedProcesses); err != nil {
+		return err
+	}
+
+	if err := client.EC2Service.UpdateStandbyTag(ctx, targetAsg, false); err != nil {
+		return err
+	}
+
+	if err := client.EC2Service.UpdateAutoScalingGroup(ctx, targetAsg, capacity); err != nil {
+		return err
+	}
+
+	// standby autoscaling group is still attached to load balancing targets of the stack
+	rollbackStack := stack
+	rollbackStack.ReplacementType = constants.BlueGreenDeployment
+	rollbackStack.RetainPreviousVersions = 0
+	rollbackStack.Capacity = capacity
+	rollbackStack.Regions = []schemas.RegionConfig{}
+	for _, rc := range stack.Regions {
+		if rc.Region == region {
+			rollbackStack.Regions = append(rollbackStack.Regions, rc)
+		}
+	}
+
+	r.Builder.Config.TargetAutoscalingGroup = targetAsg
+	r.Builder.Config.DownSizingUpdate = false
+	r.Builder.Config.ForceManifestCapacity = true
+
+	bg := deployer.NewBlueGrean(
+		rollbackStack.ReplacementType,
+		r.Logger,
+		r.Builder.AwsConfig,
+		nil,
+		rollbackStack,
+		region,
+	)
+	bg.Slack = r.Slacker
+	bg.Collector = r.Collector
+
+	r.Logger.Debugf("start healthchecking of standby autoscaling group")
+	if err := doHealthchecking(ctx, []deployer.DeployManager{bg}, r.Builder.Config, r.Logger); err != nil {
+		return err
+	}
+
+	// current version is drained and deleted in the same way as previous versions
+	bg.SetFastRollbackTarget(region, current, targetAsg)
+	if err := bg.TriggerLifecycleCallbacks(ctx, r.Builder.Config); err != nil {
+		return err
+	}
+
+	if err := bg.CleanPreviousVersion(ctx, r.Builder.Config); err != nil {
+		return err
+	}
+
+	if err := cleanChecking(ctx, []deployer.DeployManager{bg}, r.Builder.Config, r.Logger); err != nil {
+		return err
+	}
+	bg.MarkFastRolledBack(currentAsg, targetAsg)
+
+	r.Logger.Infof("fast rollback is finished : %s", targetAsg)
+	r.Slacker.SendSimpleMessage(fmt.Sprintf(":+1: Fast rollback is finished : %s", targetAsg))
+
+	return nil
+}
+
+// selectStandbyTarget returns the current version and the standby autoscaling group to roll back to
+func selectStandbyTarget(groups []*autoscaling.Group, prefix string, version int) (*autoscaling.Group, *autoscaling.Group, error) {
+	var current *autoscaling.Group
+	standby := map[string]*autoscaling.Group{}
+	for _, group := range groups {
+		if isStandby(group) {
+			standby[*group.AutoScalingGroupName] = group
+			continue
+		}
+		current = group
+	}
+
+	if current == nil {
+		return nil, nil, fmt.Errorf("no current version exists: %s", prefix)
+	}
+
+	candidates := []int{version}
+	if version < 0 {
+		candidates = getRollbackCandidates(tool.ParseVersion(*current.AutoScalingGroupName), constants.MaxRollbackCandidates)
+	}
+
+	for _, v := range candidates {
+		if group, ok := standby[tool.GenerateAsgName(prefix, v)]; ok {
+			return current, group, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("no standby autoscaling group to roll back exists: %s", prefix)
+}
+
+// isStandby checks if autoscaling group is retained as standby
+func isStandby(group *autoscaling.Group) bool {
+	for _, tag := range group.Tags {
+		if *tag.Key == constants.StandbyTagKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseRollbackVersion parses version string like v012
+func parseRollbackVersion(version string) (int, error) {
+	v, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil || v < 0 || v > 999 {
+		return 0, fmt.Errorf("no valid version to roll back: %s", version)
+	}
+
+	return v, nil
+}
+
+// getRollbackCandidates returns versions before the current version in descending order
+func getRollbackCandidates(current, count int) []int {
+	candidates := []int{}
+	for i := 1; i <= count; i++ {
+		candidates = append(candidates, (current-i+1000)%1000)
+	}
+
+	return candidates
+}
+
 //Generate new deployer
 func getDeployer(logger *Logger.Logger, stack schemas.Stack, awsConfig schemas.AWSConfig, apiTestTemplates []*schemas.APITestTemplate, region string, slack slack.Slack, c collector.Collector) deployer.DeployManager {
 	var att *schemas.APITestTemplate
@@ -590,23 +1914,54 @@ func getDeployer(logger *Logger.Logger, stack schemas.Stack, awsConfig schemas.A
 		}
 	}
 
-	deployer := deployer.NewBlueGrean(
-		stack.ReplacementType,
-		logger,
-		awsConfig,
-		att,
-		stack,
-		region,
-	)
-
-	deployer.Slack = slack
-	deployer.Collector = c
-
-	return deployer
+	switch stack.ReplacementType {
+	case constants.CanaryDeployment:
+		canary := deployer.NewCanary(
+			stack.ReplacementType,
+			logger,
+			awsConfig,
+			att,
+			stack,
+			region,
+		)
+
+		canary.Slack = slack
+		canary.Collector = c
+
+		return canary
+	case constants.RollingDeployment:
+		rolling := deployer.NewRolling(
+			stack.ReplacementType,
+			logger,
+			awsConfig,
+			att,
+			stack,
+			region,
+		)
+
+		rolling.Slack = slack
+		rolling.Collector = c
+
+		return rolling
+	default:
+		blueGreen := deployer.NewBlueGrean(
+			stack.ReplacementType,
+			logger,
+			awsConfig,
+			att,
+			stack,
+			region,
+		)
+
+		blueGreen.Slack = slack
+		blueGreen.Collector = c
+
+		return blueGreen
+	}
 }
 
 // doHealthchecking checks if newly deployed autoscaling group is healthy
-func doHealthchecking(deployers []deployer.DeployManager, config schemas.Config, logger *Logger.Logger) error {
+func doHealthchecking(ctx context.Context, deployers []deployer.DeployManager, config schemas.Config, logger *Logger.Logger) error {
 	healthyStackList := []string{}
 	healthy := false
 
@@ -630,7 +1985,7 @@ func doHealthchecking(deployers []deployer.DeployManager, config schemas.Config,
 
 			//Start healthcheck thread
 			go func(deployer deployer.DeployManager) {
-				ch <- deployer.HealthChecking(config)
+				ch <- deployer.HealthChecking(ctx, config)
 			}(d)
 		}
 
@@ -655,15 +2010,48 @@ func doHealthchecking(deployers []deployer.DeployManager, config schemas.Config,
 			healthy = true
 		} else {
 			logger.Info("All stacks are not healthy... Please waiting to be deployed...")
-			time.Sleep(config.PollingInterval)
+			if err := tool.Sleep(ctx, config.PollingInterval); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// rollback deletes new versions of stacks with rollback_on_failure
+func rollback(ctx context.Context, deployers []deployer.DeployManager, config schemas.Config, logger *Logger.Logger) {
+	wg := sync.WaitGroup{}
+	for _, d := range deployers {
+		wg.Add(1)
+		go func(deployer deployer.DeployManager) {
+			defer wg.Done()
+			if err := deployer.Rollback(ctx, config); err != nil {
+				logger.Errorf("[%s] rollback error occurred: %s", deployer.GetStackName(), err.Error())
+			}
+		}(d)
+	}
+	wg.Wait()
+}
+
+// forceRollback deletes new versions of stacks regardless of rollback_on_failure
+// It is used when new versions are proven to be bad after they become healthy
+func forceRollback(ctx context.Context, deployers []deployer.DeployManager, config schemas.Config, logger *Logger.Logger) {
+	wg := sync.WaitGroup{}
+	for _, d := range deployers {
+		wg.Add(1)
+		go func(deployer deployer.DeployManager) {
+			defer wg.Done()
+			if err := deployer.Cleanup(ctx, config); err != nil {
+				logger.Errorf("[%s] rollback error occurred: %s", deployer.GetStackName(), err.Error())
+			}
+		}(d)
+	}
+	wg.Wait()
+}
+
 // cleanChecking cleans old autoscaling groups
-func cleanChecking(deployers []deployer.DeployManager, config schemas.Config, logger *Logger.Logger) error {
+func cleanChecking(ctx context.Context, deployers []deployer.DeployManager, config schemas.Config, logger *Logger.Logger) error {
 	doneStackList := []string{}
 	done := false
 
@@ -680,7 +2068,7 @@ func cleanChecking(deployers []deployer.DeployManager, config schemas.Config, lo
 
 			//Start terminateChecking thread
 			go func(deployer deployer.DeployManager) {
-				ch <- deployer.TerminateChecking(config)
+				ch <- deployer.TerminateChecking(ctx, config)
 			}(d)
 		}
 
@@ -700,7 +2088,9 @@ func cleanChecking(deployers []deployer.DeployManager, config schemas.Config, lo
 			done = true
 		} else {
 			logger.Info("All stacks are not ready to be terminated... Please waiting...")
-			time.Sleep(config.PollingInterval)
+			if err := tool.Sleep(ctx, config.PollingInterval); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -743,7 +2133,7 @@ func askApplicationName() (string, error) {
 
 // checkManifestCommands checks if mode is needed to run manifest validation
 func checkManifestCommands(mode string) bool {
-	return tool.IsStringInArray(mode, []string{"deploy", "delete"})
+	return tool.IsStringInArray(mode, []string{"deploy", "delete", "rollback"})
 }
 
 func (r Runner) LocalCheck(message string) error {
@@ -0,0 +1,188 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	Logger "github.com/sirupsen/logrus"
+
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/deployer"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+	"github.com/DevopsArtFactory/goployer/pkg/state"
+	"github.com/DevopsArtFactory/goployer/pkg/tool"
+)
+
+// tracker saves step transitions of deployers to state store
+// All methods do nothing if tracker is nil
+type tracker struct {
+	mu         sync.Mutex
+	store      state.Store
+	deployment schemas.DeploymentState
+	waves      map[string]int
+}
+
+// newTracker creates new tracker of the deployment
+func newTracker(store state.Store, deployment schemas.DeploymentState) *tracker {
+	return &tracker{
+		store:      store,
+		deployment: deployment,
+		waves:      map[string]int{},
+	}
+}
+
+// resumed returns saved state of the stack if it exists
+func (t *tracker) resumed(stack string) *schemas.DeployerState {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, ds := range t.deployment.Deployers {
+		if ds.Stack == stack {
+			saved := ds
+			return &saved
+		}
+	}
+
+	return nil
+}
+
+// setWave sets rollout wave which the stack is running
+func (t *tracker) setWave(stack string, wave int) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.waves[stack] = wave
+}
+
+// record saves the current state of deployer
+func (t *tracker) record(d deployer.DeployManager) {
+	if t == nil {
+		return
+	}
+
+	// state is copied because maps of deployer are updated in other goroutines
+	ds, err := copyDeployerState(d.GetState())
+	if err != nil {
+		Logger.Warnf("failed to copy state of deployer : %s", err.Error())
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ds.Wave = t.waves[ds.Stack]
+	t.deployment.Deployers = setDeployerState(t.deployment.Deployers, ds)
+	t.saveLocked()
+}
+
+// complete marks the stack as deployed in all rollout waves
+func (t *tracker) complete(stack string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !tool.IsStringInArray(stack, t.deployment.CompletedStacks) {
+		t.deployment.CompletedStacks = append(t.deployment.CompletedStacks, stack)
+	}
+	t.saveLocked()
+}
+
+// fail marks the deployment as failed so that it cannot be resumed
+func (t *tracker) fail() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.deployment.Status = constants.FailedStatus
+	t.saveLocked()
+}
+
+// close removes the state of finished deployment
+func (t *tracker) close() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.store.Delete(t.deployment.ID); err != nil {
+		Logger.Warnf("failed to delete deployment state : %s", err.Error())
+	}
+}
+
+// save writes the deployment state to store
+func (t *tracker) save() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.deployment.CreatedAt = time.Now().Unix()
+	return t.store.Save(t.deployment)
+}
+
+// saveLocked writes the deployment state and only warns on failure not to stop deployment
+func (t *tracker) saveLocked() {
+	t.deployment.CreatedAt = time.Now().Unix()
+	if err := t.store.Save(t.deployment); err != nil {
+		Logger.Warnf("failed to save deployment state : %s", err.Error())
+	}
+}
+
+// setDeployerState replaces the state of the same stack or appends new one
+func setDeployerState(states []schemas.DeployerState, ds schemas.DeployerState) []schemas.DeployerState {
+	for i := range states {
+		if states[i].Stack == ds.Stack {
+			states[i] = ds
+			return states
+		}
+	}
+
+	return append(states, ds)
+}
+
+// copyDeployerState makes deep copy of deployer state
+func copyDeployerState(ds schemas.DeployerState) (schemas.DeployerState, error) {
+	var copied schemas.DeployerState
+	b, err := json.Marshal(ds)
+	if err != nil {
+		return copied, err
+	}
+
+	err = json.Unmarshal(b, &copied)
+	return copied, err
+}
@@ -32,18 +32,34 @@ type Config struct { // Do not add comments for this struct
 	OverrideInstanceType   string `json:"override_instance_type"`
 	ReleaseNotes           string `json:"release_notes"`
 	ReleaseNotesBase64     string `json:"release_notes_base64"`
+	RollbackVersion        string `json:"to"`
+	Output                 string `json:"output"`
+	StateStore             string `json:"state_store"`
+	StateStoreRegion       string `json:"state_store_region"`
+	Lock                   string `json:"lock"`
+	LockRegion             string `json:"lock_region"`
+	APITestReportDir       string `json:"api_test_report_dir"`
 	Application            string
 	TargetAutoscalingGroup string
+	OverrideUserdata       string
+	DeploymentID           string
+	LockID                 string
 	Min                    int64 `json:"min"`
 	Max                    int64 `json:"max"`
 	Desired                int64 `json:"desired"`
 	StartTimestamp         int64
 	Timeout                time.Duration `json:"timeout"`
 	PollingInterval        time.Duration `json:"polling_interval"`
+	LockLease              time.Duration `json:"lock_lease"`
 	AutoApply              bool          `json:"auto-apply"`
 	DisableMetrics         bool          `json:"disable_metrics"`
 	SlackOff               bool          `json:"slack_off"`
 	ForceManifestCapacity  bool          `json:"force_manifest_capacity"`
+	RollbackOnFailure      bool          `json:"rollback_on_failure"`
+	FastRollback           bool          `json:"fast"`
+	ManualPromotion        bool          `json:"manual_promotion"`
+	Plan                   bool          `json:"plan"`
+	Force                  bool          `json:"force"`
 	DownSizingUpdate       bool
 }
 
@@ -110,6 +126,9 @@ type Stack struct {
 	// Type of Replacement for deployment
 	ReplacementType string `yaml:"replacement_type"`
 
+	// How traffic is switched to the new version. With listener, listener forwards traffic to blue or green target group
+	TrafficSwitch string `yaml:"traffic_switch,omitempty"`
+
 	// Userdata configuration for stack deployment
 	Userdata Userdata `yaml:"userdata,omitempty"`
 
@@ -128,6 +147,15 @@ type Stack struct {
 	// Polling interval when health checking
 	PollingInterval time.Duration `yaml:"polling_interval,omitempty"`
 
+	// Whether or not to delete the new version when health checking fails
+	RollbackOnFailure bool `yaml:"rollback_on_failure,omitempty"`
+
+	// Whether or not to wait for manual promotion before cleaning previous versions
+	ManualPromotion bool `yaml:"manual_promotion,omitempty"`
+
+	// The number of previous autoscaling groups retained with zero capacity as warm standby
+	RetainPreviousVersions int64 `yaml:"retain_previous_versions,omitempty"`
+
 	// Whether using EBS Optimized option or not
 	EbsOptimized bool `yaml:"ebs_optimized,omitempty"`
 
@@ -137,6 +165,9 @@ type Stack struct {
 	// Name of API test template
 	APITestTemplate string `yaml:"api_test_template"`
 
+	// Whether or not to run API test against new version before cleaning previous versions
+	APITestBeforeCleanup bool `yaml:"api_test_before_cleanup,omitempty"`
+
 	// Instance market options like spot
 	InstanceMarketOptions *InstanceMarketOptions `yaml:"instance_market_options,omitempty"`
 
@@ -161,6 +192,27 @@ type Stack struct {
 	// Lifecycle hooks of autoscaling group
 	LifecycleHooks *LifecycleHooks `yaml:"lifecycle_hooks,omitempty"`
 
+	// Canary deployment configuration
+	Canary *CanaryConfig `yaml:"canary,omitempty"`
+
+	// Rolling deployment configuration
+	Rolling *RollingConfig `yaml:"rolling,omitempty"`
+
+	// Multi-region rollout configuration
+	Rollout *RolloutConfig `yaml:"rollout,omitempty"`
+
+	// Warmup of new instances which are attached to load balancers and target groups after warmup
+	Warmup *WarmupConfig `yaml:"warmup,omitempty"`
+
+	// Connection draining of previous versions before they are resized to zero
+	Drain *DrainConfig `yaml:"drain,omitempty"`
+
+	// Alarms and metrics watched after new version becomes healthy
+	Bake *BakeConfig `yaml:"bake,omitempty"`
+
+	// List of stacks which should be deployed before this stack
+	DependsOn []string `yaml:"depends_on,omitempty"`
+
 	// List of region configurations
 	Regions []RegionConfig `yaml:"regions"`
 }
@@ -232,6 +284,24 @@ type BlockDevice struct {
 type LifecycleCallbacks struct {
 	// List of command before terminating previous autoscaling group
 	PreTerminatePastClusters []string `yaml:"pre_terminate_past_cluster"`
+
+	// List of command on all instances of new autoscaling group after it becomes healthy
+	PostDeployNewClusters []string `yaml:"post_deploy_new_cluster,omitempty"`
+
+	// List of command on a single instance of new autoscaling group after it becomes healthy
+	RunOnce []string `yaml:"run_once,omitempty"`
+
+	// List of command on the machine running goployer after new autoscaling group becomes healthy
+	Local []string `yaml:"local,omitempty"`
+
+	// Whether or not to stop the deployment if any post deploy callback fails
+	AbortOnFailure bool `yaml:"abort_on_failure,omitempty"`
+
+	// Whether or not to keep previous versions if pre_terminate_past_cluster callback fails
+	FailOnError bool `yaml:"fail_on_error,omitempty"`
+
+	// Time to wait for each callback to finish on all instances (default 10m)
+	Timeout time.Duration `yaml:"timeout,omitempty"`
 }
 
 // Policy of scaling policy
@@ -304,6 +374,12 @@ type RegionConfig struct {
 	// Target group name for healthcheck
 	HealthcheckTargetGroup string `yaml:"healthcheck_target_group"`
 
+	// Direct healthcheck of instances which is used when neither target group nor load balancer is for healthcheck
+	Healthcheck *InstanceCheck `yaml:"healthcheck,omitempty"`
+
+	// Target group which new version is attached to only while API test runs before cleanup
+	APITestTargetGroup string `yaml:"api_test_target_group,omitempty"`
+
 	// List of security group name
 	SecurityGroups []string `yaml:"security_groups"`
 
@@ -324,6 +400,151 @@ type RegionConfig struct {
 
 	// Detailed Monitoring Enabled
 	DetailedMonitoringEnabled bool `yaml:"detailed_monitoring_enabled"`
+
+	// Target group which receives canary traffic
+	CanaryTargetGroup string `yaml:"canary_target_group,omitempty"`
+
+	// ARN of load balancer listener which forwards traffic to target groups
+	ListenerArn string `yaml:"listener_arn,omitempty"`
+
+	// ARN of listener rule which forwards traffic to target groups
+	ListenerRuleArn string `yaml:"listener_rule_arn,omitempty"`
+
+	// Blue target group of listener traffic switch
+	BlueTargetGroup string `yaml:"blue_target_group,omitempty"`
+
+	// Green target group of listener traffic switch
+	GreenTargetGroup string `yaml:"green_target_group,omitempty"`
+
+	// Capacity of autoscaling group in the region which overrides capacity of stack
+	Capacity *Capacity `yaml:"capacity,omitempty"`
+}
+
+// Canary deployment configuration
+type CanaryConfig struct {
+	// Percentage of desired capacity launched as canary
+	CapacityPercentage int64 `yaml:"capacity_percentage"`
+
+	// List of traffic weights in percentage applied to canary target group in order
+	TrafficSteps []int64 `yaml:"traffic_steps"`
+
+	// Waiting time between traffic steps
+	BakeTime time.Duration `yaml:"bake_time,omitempty"`
+}
+
+// Rolling deployment configuration
+type RollingConfig struct {
+	// Minimum percentage of healthy instances while rolling instances in batches
+	MinHealthyPercentage int64 `yaml:"min_healthy_percentage"`
+
+	// Seconds until newly launched instance is ready to receive traffic
+	InstanceWarmup int64 `yaml:"instance_warmup,omitempty"`
+
+	// Time to wait for healthy instances to meet minimum healthy percentage before instance refresh is cancelled
+	UnhealthyTimeout time.Duration `yaml:"unhealthy_timeout,omitempty"`
+}
+
+// Multi-region rollout configuration
+type RolloutConfig struct {
+	// Groups of regions deployed in order
+	Waves [][]string `yaml:"waves"`
+
+	// Waiting time after each wave becomes healthy before the next wave starts
+	BakeTime time.Duration `yaml:"bake_time,omitempty"`
+}
+
+// Warmup configuration
+// New autoscaling group is created without load balancers and target groups,
+// and they are attached after instances are healthy and warmed up
+type WarmupConfig struct {
+	// Direct healthcheck of instances before warmup
+	// Instances validated by launch_transition lifecycle hooks are warmed up if empty
+	Healthcheck *InstanceCheck `yaml:"healthcheck,omitempty"`
+
+	// Port of instance to which warmup requests are sent
+	Port int64 `yaml:"port,omitempty"`
+
+	// Requests sent to each instance
+	Requests []*WarmupRequest `yaml:"requests,omitempty"`
+
+	// Request per second to each instance (default 10)
+	RequestPerSecond int `yaml:"request_per_second,omitempty"`
+
+	// Duration of warmup requests (default 30s)
+	Duration time.Duration `yaml:"duration,omitempty"`
+
+	// Minimum ratio of successful warmup requests to each instance between 0 and 1 (default 0.9)
+	// Load balancing targets are not attached until every instance meets the ratio
+	MinSuccessRatio float64 `yaml:"min_success_ratio,omitempty"`
+}
+
+// Request sent to instance for warmup
+type WarmupRequest struct {
+	// Method of request: [ GET, POST, PUT, PATCH, DELETE ]
+	Method string `yaml:"method,omitempty"`
+
+	// Path of request
+	Path string `yaml:"path,omitempty"`
+
+	// list of body value as JSON format
+	Body []string `yaml:"body,omitempty"`
+
+	// list of header value
+	Header []string `yaml:"header,omitempty"`
+}
+
+// Connection draining configuration
+// Previous versions are detached from load balancers and target groups before they are resized to zero
+type DrainConfig struct {
+	// Time to wait after previous versions are detached
+	// The longest deregistration delay of target groups or connection draining timeout of load balancers is used if empty
+	Duration time.Duration `yaml:"duration,omitempty"`
+
+	// The number of instances removed from previous version at once while new version is healthy
+	// Previous versions are not stepped down if empty
+	StepSize int64 `yaml:"step_size,omitempty"`
+
+	// Waiting time after each step (default 30s)
+	StepInterval time.Duration `yaml:"step_interval,omitempty"`
+}
+
+// Bake configuration
+type BakeConfig struct {
+	// Time to watch new version before cleaning previous versions
+	Duration time.Duration `yaml:"duration"`
+
+	// List of cloudwatch alarm names which should not be in ALARM state
+	Alarms []string `yaml:"alarms,omitempty"`
+
+	// List of metric thresholds which should not be breached
+	Metrics []BakeMetric `yaml:"metrics,omitempty"`
+}
+
+// Metric threshold watched during bake
+type BakeMetric struct {
+	// Name of metric threshold
+	Name string `yaml:"name"`
+
+	// Namespace of metrics
+	Namespace string `yaml:"namespace"`
+
+	// Name of metric
+	Metric string `yaml:"metric"`
+
+	// Type of statistics for metrics like Average, Sum or p99
+	Statistic string `yaml:"statistic"`
+
+	// Dimensions of metric (default AutoScalingGroupName of new version)
+	Dimensions map[string]string `yaml:"dimensions,omitempty"`
+
+	// Comparison operator with threshold like GreaterThanThreshold
+	Comparison string `yaml:"comparison"`
+
+	// Threshold of metric
+	Threshold float64 `yaml:"threshold"`
+
+	// Period for metrics in seconds (default 60)
+	Period int64 `yaml:"period,omitempty"`
 }
 
 // Instance capacity of autoscaling group
@@ -366,6 +587,34 @@ type LifecycleHookSpecification struct {
 
 	// IAM Role ARN for notification
 	RoleARN string `yaml:"role_arn"`
+
+	// Validation of instances which goployer runs before completing launch lifecycle action
+	// Instances which fail validation are abandoned and never go in service
+	Validation *InstanceCheck `yaml:"validation,omitempty"`
+}
+
+// Check of a single instance
+type InstanceCheck struct {
+	// Type of check - http, tcp or ssm-command
+	Type string `yaml:"type"`
+
+	// Port of instance to check with http or tcp
+	Port int64 `yaml:"port,omitempty"`
+
+	// Path of http request (default /)
+	Path string `yaml:"path,omitempty"`
+
+	// Expected status code of http response (default 2xx or 3xx)
+	ExpectedStatus int `yaml:"expected_status,omitempty"`
+
+	// Commands to run on instance with ssm
+	Commands []string `yaml:"commands,omitempty"`
+
+	// Number of consecutive successful checks for instance to be healthy (default 1)
+	SuccessCount int64 `yaml:"success_count,omitempty"`
+
+	// Time to wait for the instance to pass the check (default 5m)
+	Timeout time.Duration `yaml:"timeout,omitempty"`
 }
 
 // Templates for API Test
@@ -380,6 +629,86 @@ type APITestTemplate struct {
 	RequestPerSecond int `yaml:"request_per_second,omitempty"`
 
 	APIs []*APIManifest `yaml:"apis,omitempty"`
+
+	// Thresholds which every API should meet
+	Assertions *APITestAssertions `yaml:"assertions,omitempty"`
+
+	// Variables which can be used in apis and scenarios with {{ .Vars.<name> }}
+	// Environment variables can be used with {{ .Env.<name> }}
+	Vars map[string]string `yaml:"vars,omitempty"`
+
+	// Scenarios which call APIs in order before load test
+	// Variables captured in scenarios can be used in apis of load test
+	Scenarios []*APIScenario `yaml:"scenarios,omitempty"`
+
+	// Tolerances compared with API test of the last successful deployment
+	Regression *APITestRegression `yaml:"regression,omitempty"`
+}
+
+// Tolerances of performance regression against the previous release
+// Summary of API test is stored with deployment record only if metrics are enabled
+type APITestRegression struct {
+	// Maximum ratio of increase of 99th percentile latency (default 0.2 which means +20%)
+	MaxLatencyP99Increase float64 `yaml:"max_latency_p99_increase,omitempty"`
+
+	// Maximum ratio of increase of 95th percentile latency
+	MaxLatencyP95Increase float64 `yaml:"max_latency_p95_increase,omitempty"`
+
+	// Maximum decrease of success ratio (default 0.01 which means -1%)
+	MaxSuccessRatioDecrease float64 `yaml:"max_success_ratio_decrease,omitempty"`
+
+	// Whether deployment fails if regression is detected
+	// Regression is only reported if false
+	FailOnRegression bool `yaml:"fail_on_regression,omitempty"`
+}
+
+// Scenario of API test which is made of requests called in order
+type APIScenario struct {
+	// Name of scenario
+	Name string `yaml:"name,omitempty"`
+
+	// Requests which are called in order
+	// Scenario stops at the first step which fails
+	Steps []*APIStep `yaml:"steps,omitempty"`
+}
+
+// Request in scenario of API test
+type APIStep struct {
+	// Name of step
+	Name string `yaml:"name,omitempty"`
+
+	// Method of API Call: [ GET, POST, PUT, PATCH, DELETE ]
+	Method string `yaml:"method,omitempty"`
+
+	// Full URL of API
+	URL string `yaml:"url,omitempty"`
+
+	// list of body value as JSON format
+	Body []string `yaml:"body,omitempty"`
+
+	// list of header value as JSON format
+	Header []string `yaml:"header,omitempty"`
+
+	// Time to wait for the response (default 10s)
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// Assertions on the response
+	Expect *APIExpectation `yaml:"expect,omitempty"`
+
+	// Variables to capture from JSON response with JSON path like $.data.token
+	Capture map[string]string `yaml:"capture,omitempty"`
+}
+
+// Assertions on response of scenario step
+type APIExpectation struct {
+	// List of expected status codes (default 2xx)
+	StatusCodes []int `yaml:"status_codes,omitempty"`
+
+	// Expected values of response headers
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Expected values in JSON response with JSON path like $.items[0].id
+	JSON map[string]string `yaml:"json,omitempty"`
 }
 
 // Configuration of API test
@@ -395,4 +724,26 @@ type APIManifest struct {
 
 	// list of header value as JSON format
 	Header []string `yaml:"header,omitempty"`
+
+	// Thresholds of this API which override assertions of template
+	Assertions *APITestAssertions `yaml:"assertions,omitempty"`
+}
+
+// Thresholds of API test result
+// Deployment fails if any of them is violated
+type APITestAssertions struct {
+	// Minimum ratio of successful requests between 0 and 1
+	MinSuccessRatio float64 `yaml:"min_success_ratio,omitempty"`
+
+	// Maximum 95th percentile latency
+	MaxLatencyP95 time.Duration `yaml:"max_latency_p95,omitempty"`
+
+	// Maximum 99th percentile latency
+	MaxLatencyP99 time.Duration `yaml:"max_latency_p99,omitempty"`
+
+	// List of status codes which are allowed in responses
+	AllowedStatusCodes []int `yaml:"allowed_status_codes,omitempty"`
+
+	// Maximum number of failed requests
+	MaxErrors *int `yaml:"max_errors,omitempty"`
 }
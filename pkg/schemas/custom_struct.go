@@ -16,10 +16,41 @@ limitations under the license.
 
 package schemas
 
-import vegeta "github.com/tsenart/vegeta/lib"
+import (
+	"time"
+
+	vegeta "github.com/tsenart/vegeta/lib"
+)
 
 type MetricResult struct {
-	URL    string
-	Method string
-	Data   vegeta.Metrics
+	API        string
+	URL        string
+	Method     string
+	Data       vegeta.Metrics
+	Assertions *APITestAssertions
+	Results    vegeta.Results
+}
+
+// APITestSummary is the summary of API test which is stored with the deployment record
+type APITestSummary struct {
+	Name      string             `json:"name"`
+	Timestamp int64              `json:"timestamp"`
+	Passed    bool               `json:"passed"`
+	APIs      []APIMetricSummary `json:"apis"`
+}
+
+// APIMetricSummary is the summary of metrics of an API
+type APIMetricSummary struct {
+	API         string         `json:"api"`
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	Requests    uint64         `json:"requests"`
+	Throughput  float64        `json:"throughput"`
+	Success     float64        `json:"success"`
+	LatencyMean time.Duration  `json:"latency_mean"`
+	LatencyP50  time.Duration  `json:"latency_p50"`
+	LatencyP95  time.Duration  `json:"latency_p95"`
+	LatencyP99  time.Duration  `json:"latency_p99"`
+	LatencyMax  time.Duration  `json:"latency_max"`
+	StatusCodes map[string]int `json:"status_codes"`
 }
@@ -0,0 +1,95 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package schemas
+
+// DeploymentState is the state of deployment which is persisted to resume or promote it later
+type DeploymentState struct {
+	// ID of deployment
+	ID string `json:"id"`
+
+	// Status of deployment
+	Status string `json:"status"`
+
+	// Unix timestamp when the state is saved
+	CreatedAt int64 `json:"created_at"`
+
+	// Configuration from command
+	Config Config `json:"config"`
+
+	// AWS related configuration of application
+	AwsConfig AWSConfig `json:"aws_config"`
+
+	// Configuration for metrics
+	MetricConfig MetricConfig `json:"metric_config"`
+
+	// Stack configurations to deploy
+	Stacks []Stack `json:"stacks"`
+
+	// API test templates
+	APITestTemplates []*APITestTemplate `json:"api_test_templates,omitempty"`
+
+	// States of deployers for stacks
+	Deployers []DeployerState `json:"deployers"`
+
+	// Stacks of which all rollout waves are finished
+	CompletedStacks []string `json:"completed_stacks,omitempty"`
+}
+
+// DeployerState is the state of deployer for a stack
+type DeployerState struct {
+	// Name of stack
+	Stack string `json:"stack"`
+
+	// New autoscaling group of each region
+	AsgNames map[string]string `json:"asg_names"`
+
+	// Previous autoscaling groups of each region
+	PrevAsgs map[string][]string `json:"prev_asgs"`
+
+	// Instances of previous autoscaling groups of each region
+	PrevInstances map[string][]string `json:"prev_instances"`
+
+	// Versions of previous autoscaling groups of each region
+	PrevVersions map[string][]int `json:"prev_versions"`
+
+	// Capacity of previous autoscaling group of each region
+	PrevInstanceCount map[string]Capacity `json:"prev_instance_count"`
+
+	// Status of deployment steps
+	StepStatus map[int64]bool `json:"step_status"`
+
+	// Launch template versions before rolling deployment
+	LaunchTemplateVersions map[string]int64 `json:"launch_template_versions,omitempty"`
+
+	// Instance refreshes started by rolling deployment in each region
+	RefreshIDs map[string]string `json:"refresh_ids,omitempty"`
+
+	// Whether rolling deployment created new autoscaling group in each region because there was nothing to roll
+	CreatedAsgs map[string]bool `json:"created_asgs,omitempty"`
+
+	// Index of canary traffic step of each region
+	TrafficSteps map[string]int `json:"traffic_steps,omitempty"`
+
+	// Target group which listener forwarded traffic to before deployment in each region
+	ActiveTargetGroups map[string]string `json:"active_target_groups,omitempty"`
+
+	// Whether new autoscaling group is attached to load balancing targets after warmup in each region
+	WarmupAttached map[string]bool `json:"warmup_attached,omitempty"`
+
+	// Index of rollout wave which the deployer is running
+	Wave int `json:"wave"`
+}
@@ -0,0 +1,89 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package schemas
+
+// DeploymentPlan is the list of changes which deploy or delete would make
+type DeploymentPlan struct {
+	// deploy or delete
+	Action string `json:"action"`
+
+	// Name of application
+	Application string `json:"application"`
+
+	// Plans of stacks in each region
+	Regions []RegionPlan `json:"regions"`
+}
+
+// RegionPlan is the plan of a stack in a region
+type RegionPlan struct {
+	// Name of stack
+	Stack string `json:"stack"`
+
+	// Region ID
+	Region string `json:"region"`
+
+	// Replacement type of deployment
+	ReplacementType string `json:"replacement_type,omitempty"`
+
+	// Autoscaling group which will be created or updated
+	AutoScalingGroup string `json:"autoscaling_group,omitempty"`
+
+	// Launch template which will be created or get a new version
+	LaunchTemplate string `json:"launch_template,omitempty"`
+
+	// AMI ID of launch template
+	Ami string `json:"ami,omitempty"`
+
+	// Instance type of launch template
+	InstanceType string `json:"instance_type,omitempty"`
+
+	// SSH key of launch template
+	SSHKey string `json:"ssh_key,omitempty"`
+
+	// IAM instance profile of launch template
+	IamInstanceProfile string `json:"iam_instance_profile,omitempty"`
+
+	// Resolved security group IDs
+	SecurityGroups []string `json:"security_groups,omitempty"`
+
+	// Resolved availability zones
+	AvailabilityZones []string `json:"availability_zones,omitempty"`
+
+	// Resolved subnet IDs
+	Subnets []string `json:"subnets,omitempty"`
+
+	// Classic load balancers attached to autoscaling group
+	LoadBalancers []string `json:"load_balancers,omitempty"`
+
+	// Resolved target group ARNs attached to autoscaling group
+	TargetGroups []string `json:"target_groups,omitempty"`
+
+	// Load balancers and target groups are attached after warmup instead of at creation
+	AttachAfterWarmup bool `json:"attach_after_warmup,omitempty"`
+
+	// Tags of autoscaling group
+	Tags []string `json:"tags,omitempty"`
+
+	// Applied capacity of autoscaling group
+	Capacity *Capacity `json:"capacity,omitempty"`
+
+	// Previous autoscaling groups which will be removed
+	RemovedAutoScalingGroups []string `json:"removed_autoscaling_groups"`
+
+	// Previous autoscaling groups which will be retained as standby
+	StandbyAutoScalingGroups []string `json:"standby_autoscaling_groups,omitempty"`
+}
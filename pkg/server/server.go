@@ -17,6 +17,7 @@ limitations under the license.
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -40,6 +41,10 @@ type Server struct {
 	ServerConfig Config
 	Router       *http.ServeMux
 	Logger       *Logger.Logger
+
+	// Context of server lifetime on which deployments run
+	// Deployments are not cancelled even if clients of requests are disconnected
+	Ctx context.Context
 }
 
 type Config struct {
@@ -48,13 +53,15 @@ type Config struct {
 }
 
 type RequestBody struct {
-	Config schemas.Config `json:"config"`
+	Config       schemas.Config `json:"config"`
+	DeploymentID string         `json:"deployment_id"`
 }
 
 func New() Server {
 	return Server{
 		Router: http.NewServeMux(),
 		Logger: Logger.New(),
+		Ctx:    context.Background(),
 		ServerConfig: Config{
 			Addr: defaultServerAddr,
 			Port: defaultServerPort,
@@ -65,6 +72,15 @@ func New() Server {
 func (s Server) SetRouter() Server {
 	s.Router.HandleFunc("/health", s.Healthcheck)
 	s.Router.HandleFunc("/deploy", s.TriggerDeploy)
+	s.Router.HandleFunc("/promote", s.TriggerPromote)
+	s.Router.HandleFunc("/abort", s.TriggerAbort)
+	s.Router.HandleFunc("/resume", s.TriggerResume)
+	return s
+}
+
+// SetContext sets context of server lifetime
+func (s Server) SetContext(ctx context.Context) Server {
+	s.Ctx = ctx
 	return s
 }
 
@@ -94,8 +110,60 @@ func (s Server) TriggerDeploy(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if err := runner.Start(builder, "server"); err != nil {
+	if err := runner.Start(s.Ctx, builder, "server"); err != nil {
+		s.Logger.Errorf(err.Error())
+		return
+	}
+}
+
+// TriggerPromote resumes deployment waiting for manual promotion
+func (s Server) TriggerPromote(w http.ResponseWriter, req *http.Request) {
+	s.resumeDeployment(w, req, "promote")
+}
+
+// TriggerAbort rolls back deployment waiting for manual promotion
+func (s Server) TriggerAbort(w http.ResponseWriter, req *http.Request) {
+	s.resumeDeployment(w, req, "abort")
+}
+
+// TriggerResume continues deployment stopped in the middle of steps
+func (s Server) TriggerResume(w http.ResponseWriter, req *http.Request) {
+	s.resumeDeployment(w, req, "resume")
+}
+
+// resumeDeployment runs promote, abort or resume for the saved deployment
+func (s Server) resumeDeployment(w http.ResponseWriter, req *http.Request, mode string) {
+	var body RequestBody
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		s.Logger.Errorf(err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(body.DeploymentID) == 0 {
+		s.Logger.Errorf("no deployment id is specified")
+		http.Error(w, "no deployment id is specified", http.StatusBadRequest)
+		return
+	}
+
+	builderSt, err := builder.NewBuilder(&schemas.Config{
+		DeploymentID:     body.DeploymentID,
+		StateStore:       body.Config.StateStore,
+		StateStoreRegion: body.Config.StateStoreRegion,
+		Lock:             body.Config.Lock,
+		LockRegion:       body.Config.LockRegion,
+		LockLease:        body.Config.LockLease,
+		AutoApply:        true,
+	})
+	if err != nil {
+		s.Logger.Errorf(err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := runner.Start(s.Ctx, builderSt, mode); err != nil {
 		s.Logger.Errorf(err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 }
@@ -0,0 +1,80 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+// DynamoDBStore saves deployment states as items in dynamodb table
+type DynamoDBStore struct {
+	Client aws.DynamoDBClient
+	Table  string
+}
+
+// NewDynamoDBStore creates new dynamodb state store
+func NewDynamoDBStore(client aws.DynamoDBClient, table string) DynamoDBStore {
+	return DynamoDBStore{
+		Client: client,
+		Table:  table,
+	}
+}
+
+// Save puts deployment state to the table
+func (d DynamoDBStore) Save(deployment schemas.DeploymentState) error {
+	b, err := json.Marshal(deployment)
+	if err != nil {
+		return err
+	}
+
+	return d.Client.PutDeploymentState(d.key(deployment.ID), deployment.Status, string(b), d.Table)
+}
+
+// Load gets deployment state from the table
+func (d DynamoDBStore) Load(id string) (*schemas.DeploymentState, error) {
+	item, err := d.Client.GetSingleItem(d.key(id), d.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := item["deployment_state"]
+	if !ok || value.S == nil {
+		return nil, fmt.Errorf("no deployment state exists: %s", id)
+	}
+
+	var deployment schemas.DeploymentState
+	if err := json.Unmarshal([]byte(*value.S), &deployment); err != nil {
+		return nil, err
+	}
+
+	return &deployment, nil
+}
+
+// Delete removes deployment state from the table
+func (d DynamoDBStore) Delete(id string) error {
+	return d.Client.DeleteItem(d.key(id), d.Table)
+}
+
+// key returns hash key of deployment state
+func (d DynamoDBStore) key(id string) string {
+	return fmt.Sprintf("%s%s", constants.DeploymentStateKeyPrefix, id)
+}
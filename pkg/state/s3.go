@@ -0,0 +1,82 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+// S3Store saves deployment states as objects in s3 bucket
+type S3Store struct {
+	Client aws.S3Client
+	Bucket string
+	Prefix string
+}
+
+// NewS3Store creates new s3 state store
+func NewS3Store(client aws.S3Client, bucket, prefix string) S3Store {
+	return S3Store{
+		Client: client,
+		Bucket: bucket,
+		Prefix: prefix,
+	}
+}
+
+// Save uploads deployment state to s3
+func (s S3Store) Save(deployment schemas.DeploymentState) error {
+	b, err := json.MarshalIndent(deployment, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return s.Client.PutObject(s.Bucket, s.key(deployment.ID), b)
+}
+
+// Load downloads deployment state from s3
+func (s S3Store) Load(id string) (*schemas.DeploymentState, error) {
+	b, err := s.Client.GetObject(s.Bucket, s.key(id))
+	if err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return nil, fmt.Errorf("no deployment state exists: %s", id)
+	}
+
+	var deployment schemas.DeploymentState
+	if err := json.Unmarshal(b, &deployment); err != nil {
+		return nil, err
+	}
+
+	return &deployment, nil
+}
+
+// Delete removes deployment state from s3
+func (s S3Store) Delete(id string) error {
+	return s.Client.DeleteObject(s.Bucket, s.key(id))
+}
+
+// key returns object key of deployment state
+func (s S3Store) key(id string) string {
+	return path.Join(s.Prefix, constants.DeploymentStateKeyPrefix, fmt.Sprintf("%s.json", path.Base(id)))
+}
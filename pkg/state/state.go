@@ -0,0 +1,180 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DevopsArtFactory/goployer/pkg/aws"
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+// Store saves deployment states to resume them later
+type Store interface {
+	Save(deployment schemas.DeploymentState) error
+	Load(id string) (*schemas.DeploymentState, error)
+	Delete(id string) error
+}
+
+// LocalStore saves deployment states as files in local directory
+type LocalStore struct {
+	Dir string
+}
+
+// Location is the parsed location of state store
+type Location struct {
+	// local, s3 or dynamodb
+	Type string
+
+	// Name of bucket or table
+	Name string
+
+	// Key prefix in the bucket
+	Prefix string
+}
+
+// ParseLocation parses location of state store
+// Available formats are `local`, `s3://<bucket>/<prefix>`, `dynamodb` and `dynamodb://<table>`
+func ParseLocation(location string) (Location, error) {
+	switch {
+	case len(location) == 0 || location == constants.LocalStateStore:
+		return Location{Type: constants.LocalStateStore}, nil
+	case location == constants.DynamoDBStateStore:
+		return Location{Type: constants.DynamoDBStateStore}, nil
+	case strings.HasPrefix(location, constants.DynamoDBPrefix):
+		table := strings.TrimPrefix(location, constants.DynamoDBPrefix)
+		if len(table) == 0 || strings.Contains(table, "/") {
+			return Location{}, fmt.Errorf("invalid dynamodb table of state store : %s", location)
+		}
+		return Location{Type: constants.DynamoDBStateStore, Name: table}, nil
+	case strings.HasPrefix(location, constants.S3Prefix):
+		path := strings.TrimPrefix(location, constants.S3Prefix)
+		bucket, prefix := path, constants.EmptyString
+		if i := strings.Index(path, "/"); i >= 0 {
+			bucket, prefix = path[:i], strings.Trim(path[i+1:], "/")
+		}
+		if len(bucket) == 0 {
+			return Location{}, fmt.Errorf("invalid s3 bucket of state store : %s", location)
+		}
+		return Location{Type: constants.S3StateStore, Name: bucket, Prefix: prefix}, nil
+	}
+
+	return Location{}, fmt.Errorf("state store should be one of local, s3://<bucket>/<prefix>, dynamodb or dynamodb://<table> : %s", location)
+}
+
+// New creates state store of the location
+// metric table is used if dynamodb table is not specified
+func New(location, region string, metricConfig schemas.MetricConfig) (Store, error) {
+	l, err := ParseLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.Type == constants.LocalStateStore {
+		return NewLocalStore(constants.DeploymentStatePath), nil
+	}
+
+	if l.Type == constants.DynamoDBStateStore {
+		if len(l.Name) == 0 {
+			l.Name = metricConfig.Storage.Name
+		}
+
+		if len(region) == 0 {
+			region = metricConfig.Region
+		}
+
+		if len(l.Name) == 0 {
+			return nil, errors.New("you have to specify the table of state store or metric storage")
+		}
+	}
+
+	if len(region) == 0 {
+		return nil, errors.New("you have to specify the region of state store")
+	}
+
+	client := aws.BootstrapStateService(region, constants.EmptyString)
+	if l.Type == constants.S3StateStore {
+		return NewS3Store(client.S3Service, l.Name, l.Prefix), nil
+	}
+
+	return NewDynamoDBStore(client.DynamoDBService, l.Name), nil
+}
+
+// NewLocalStore creates new local state store
+func NewLocalStore(dir string) LocalStore {
+	return LocalStore{
+		Dir: dir,
+	}
+}
+
+// GenerateID creates deployment id with application name and start timestamp
+func GenerateID(app string, timestamp int64) string {
+	return fmt.Sprintf("%s-%d", app, timestamp)
+}
+
+// Save writes deployment state to file
+func (l LocalStore) Save(deployment schemas.DeploymentState) error {
+	if err := os.MkdirAll(l.Dir, 0755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(deployment, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(l.path(deployment.ID), b, 0600)
+}
+
+// Load reads deployment state from file
+func (l LocalStore) Load(id string) (*schemas.DeploymentState, error) {
+	b, err := ioutil.ReadFile(l.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no deployment state exists: %s", id)
+		}
+		return nil, err
+	}
+
+	var deployment schemas.DeploymentState
+	if err := json.Unmarshal(b, &deployment); err != nil {
+		return nil, err
+	}
+
+	return &deployment, nil
+}
+
+// Delete removes deployment state file
+func (l LocalStore) Delete(id string) error {
+	if err := os.Remove(l.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// path returns file path of deployment state
+func (l LocalStore) path(id string) string {
+	return filepath.Join(l.Dir, fmt.Sprintf("%s.json", filepath.Base(id)))
+}
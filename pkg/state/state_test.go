@@ -0,0 +1,114 @@
+/*
+copyright 2020 the Goployer authors
+
+licensed under the apache license, version 2.0 (the "license");
+you may not use this file except in compliance with the license.
+you may obtain a copy of the license at
+
+    http://www.apache.org/licenses/license-2.0
+
+unless required by applicable law or agreed to in writing, software
+distributed under the license is distributed on an "as is" basis,
+without warranties or conditions of any kind, either express or implied.
+see the license for the specific language governing permissions and
+limitations under the license.
+*/
+
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/DevopsArtFactory/goployer/pkg/constants"
+	"github.com/DevopsArtFactory/goployer/pkg/schemas"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goployer-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewLocalStore(dir)
+	deployment := schemas.DeploymentState{
+		ID:     GenerateID("hello", 1602830000),
+		Status: constants.PausedStatus,
+		Config: schemas.Config{Region: "ap-northeast-2", Timeout: constants.DefaultDeploymentTimeout},
+		Stacks: []schemas.Stack{{Stack: "artd", ManualPromotion: true}},
+		Deployers: []schemas.DeployerState{
+			{
+				Stack:      "artd",
+				AsgNames:   map[string]string{"ap-northeast-2": "hello-artd_apne2-v002"},
+				PrevAsgs:   map[string][]string{"ap-northeast-2": {"hello-artd_apne2-v001"}},
+				StepStatus: map[int64]bool{constants.StepDeploy: true},
+			},
+		},
+	}
+
+	if deployment.ID != "hello-1602830000" {
+		t.Errorf("wrong deployment id: %s", deployment.ID)
+	}
+
+	if err := store.Save(deployment); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.Load(deployment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(*loaded, deployment); diff != nil {
+		t.Error(diff)
+	}
+
+	if err := store.Delete(deployment.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Load(deployment.ID); err == nil {
+		t.Errorf("deleted deployment state should not be loaded")
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	testData := []struct {
+		location string
+		expected Location
+		err      bool
+	}{
+		{location: "", expected: Location{Type: constants.LocalStateStore}},
+		{location: "local", expected: Location{Type: constants.LocalStateStore}},
+		{location: "s3://goployer", expected: Location{Type: constants.S3StateStore, Name: "goployer"}},
+		{location: "s3://goployer/states/", expected: Location{Type: constants.S3StateStore, Name: "goployer", Prefix: "states"}},
+		{location: "dynamodb", expected: Location{Type: constants.DynamoDBStateStore}},
+		{location: "dynamodb://goployer-metrics", expected: Location{Type: constants.DynamoDBStateStore, Name: "goployer-metrics"}},
+		{location: "s3://", err: true},
+		{location: "dynamodb://", err: true},
+		{location: "redis://goployer", err: true},
+	}
+
+	for _, td := range testData {
+		l, err := ParseLocation(td.location)
+		if td.err {
+			if err == nil {
+				t.Errorf("invalid location should return error: %s", td.location)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", td.location, err.Error())
+			continue
+		}
+
+		if diff := deep.Equal(l, td.expected); diff != nil {
+			t.Errorf("%s: %v", td.location, diff)
+		}
+	}
+}
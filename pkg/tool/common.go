@@ -17,6 +17,7 @@ limitations under the license.
 package tool
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -59,6 +60,16 @@ func CheckTimeout(start int64, timeout time.Duration) (bool, error) {
 	return false, nil
 }
 
+// Sleep waits for the duration and returns error of context if it is cancelled in the middle
+func Sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
 // GetBaseTimeWithTimezone returns time with timezone
 func GetBaseTimeWithTimezone(timezone string) time.Time {
 	now := time.Now()
@@ -146,7 +157,10 @@ func JoinString(arr []string, delimiter string) string {
 func CreateBodyStruct(slice []string) ([]byte, error) {
 	bd := map[string]string{}
 	for _, s := range slice {
-		split := strings.Split(s, "=")
+		split := strings.SplitN(s, "=", 2)
+		if len(split) != 2 {
+			return nil, fmt.Errorf("wrong format body: %s", s)
+		}
 		bd[split[0]] = split[1]
 	}
 
@@ -162,7 +176,7 @@ func CreateBodyStruct(slice []string) ([]byte, error) {
 func CreateHeaderStruct(slice []string) (http.Header, error) {
 	hd := SetCommonHeader()
 	for _, s := range slice {
-		split := strings.Split(s, "=")
+		split := strings.SplitN(s, "=", 2)
 		if len(split) != 2 {
 			return nil, fmt.Errorf("wrong format header: %s", s)
 		}
@@ -17,6 +17,7 @@ limitations under the license.
 package main
 
 import (
+	"context"
 	"net/http"
 
 	Logger "github.com/sirupsen/logrus"
@@ -26,7 +27,11 @@ import (
 
 func main() {
 	Logger.Infof("Booting up goployer server")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	s := server.New().
+		SetContext(ctx).
 		SetDefaultSetting().
 		SetRouter()
 